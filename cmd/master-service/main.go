@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/boreas/internal/pkg/client/operator"
+	"github.com/boreas/internal/pkg/client/transport"
 	"github.com/boreas/internal/pkg/config"
 	"github.com/boreas/internal/pkg/database"
 	"github.com/boreas/internal/pkg/logger"
@@ -89,39 +90,27 @@ func main() {
 		logger.GetLogger().Fatal("Failed to list environments", zap.Error(err))
 	}
 
-	operatorConfig := &operator.Config{
-		K8SOperatorURL: masterCfg.Operator.K8SOperatorURL,
-		PMOperatorURL:  masterCfg.Operator.PMOperatorURL,
-		UseMock:        masterCfg.Operator.UseMock,
-	}
+	operatorConfig := newOperatorConfig(masterCfg)
 
+	operatorFactory := operator.NewFactory(operatorConfig)
 	operatorManager, err := operator.InitializeOperators(envList, operatorConfig)
 	if err != nil {
 		logger.GetLogger().Fatal("Failed to initialize operator manager", zap.Error(err))
 	}
+	healthCtx, stopHealthPolling := context.WithCancel(context.Background())
+	defer stopHealthPolling()
+	operatorManager.StartHealthPolling(healthCtx, time.Duration(masterCfg.Operator.HealthCheckInterval)*time.Second)
 	logger.GetLogger().Info("Operator manager initialized",
 		zap.Int("registered_operators", len(operatorManager.ListOperators())),
 		zap.Bool("use_mock", masterCfg.Operator.UseMock),
 	)
 
-	// 创建服务
-	versionService := service.NewVersionService(versionRepo)
-	appService := service.NewApplicationService(appRepo, versionRepo, deploymentRepo, operatorManager)
-	envService := service.NewEnvironmentService(envRepo)
-	triggerService := service.NewTriggerService(&service.TriggerConfig{
-		WebhookSecret:  cfg.Trigger.WebhookSecret,
-		WorkDir:        cfg.Trigger.WorkDir,
-		DockerRegistry: cfg.Trigger.DockerRegistry,
-		Apps:           appService,
-		Version:        versionService,
-	})
-	webhookHandler := handler.NewWebhookHandler(triggerService, cfg.Trigger.WebhookSecret)
-
 	// 创建工作流控制器
 	workflowController := service.NewWorkflowController(
 		taskRepo,
 		deploymentRepo,
 		versionRepo,
+		operatorManager,
 		service.WorkflowConfig{},
 		logger.GetLogger(),
 	)
@@ -129,6 +118,37 @@ func main() {
 	defer workflowController.Stop()
 
 	deploymentService := service.NewDeploymentService(deploymentRepo, versionRepo, appRepo, envRepo, workflowController)
+
+	// 创建服务
+	versionService := service.NewVersionService(versionRepo, envRepo, deploymentService)
+	appService := service.NewApplicationService(appRepo, versionRepo, deploymentRepo, operatorManager)
+	envService := service.NewEnvironmentService(envRepo, operatorManager, operatorFactory.Create)
+	registryCredentials := make([]service.RegistryCredential, 0, len(masterCfg.Trigger.Registries))
+	for _, r := range masterCfg.Trigger.Registries {
+		registryCredentials = append(registryCredentials, service.RegistryCredential{
+			Host:          r.Host,
+			Username:      r.Username,
+			Password:      r.Password,
+			IdentityToken: r.IdentityToken,
+		})
+	}
+	triggerService, err := service.NewTriggerService(&service.TriggerConfig{
+		WebhookSecret:       cfg.Trigger.WebhookSecret,
+		WorkDir:             cfg.Trigger.WorkDir,
+		DockerRegistry:      cfg.Trigger.DockerRegistry,
+		BuildConcurrency:    masterCfg.Trigger.BuildConcurrency,
+		BuildTimeout:        time.Duration(masterCfg.Trigger.BuildTimeout) * time.Second,
+		AppBuildTimeout:     time.Duration(masterCfg.Trigger.AppBuildTimeout) * time.Second,
+		RegistryCredentials: registryCredentials,
+		DockerConfigPath:    masterCfg.Trigger.DockerConfig,
+		Apps:                appService,
+		Version:             versionService,
+	})
+	if err != nil {
+		logger.GetLogger().Fatal("Failed to create trigger service", zap.Error(err))
+	}
+	webhookHandler := handler.NewWebhookHandler(triggerService, cfg.Trigger.WebhookSecret)
+
 	taskService := service.NewTaskService(taskRepo)
 
 	// 创建处理器
@@ -137,6 +157,9 @@ func main() {
 	envHandler := handler.NewEnvironmentHandler(envService)
 	deploymentHandler := handler.NewDeploymentHandler(deploymentService)
 	taskHandler := handler.NewTaskHandler(taskService)
+	buildLogHandler := handler.NewBuildLogHandler(triggerService.BuildLogs())
+	callbackService := service.NewOperatorCallbackService(envRepo, operatorManager, workflowController, masterCfg.Operator.CallbackSecret)
+	operatorHandler := handler.NewOperatorHandler(operatorManager, callbackService)
 
 	// 设置 Gin 模式
 	if cfg.Log.Level == "debug" {
@@ -197,6 +220,7 @@ func main() {
 		versions.GET("/:version", versionHandler.GetVersion)
 		versions.DELETE("/:version", versionHandler.DeleteVersion)
 		versions.POST("/:version/rollback", versionHandler.RollbackVersion)
+		versions.GET("/:version/builds/:app/logs", buildLogHandler.GetBuildLogs)
 	}
 
 	// 应用管理路由
@@ -207,6 +231,8 @@ func main() {
 		applications.GET("/:name", appHandler.GetApplication) // 按应用名称查询
 		applications.PUT("/:id", appHandler.UpdateApplication)
 		applications.DELETE("/:id", appHandler.DeleteApplication)
+		// gin 要求同一位置的通配参数同名，这里的 :id 是应用名称
+		applications.DELETE("/:id/environments/:env", appHandler.UndeployApplication)
 	}
 
 	// 应用版本信息路由（使用应用名称）
@@ -214,6 +240,13 @@ func main() {
 	api.GET("/applications/:name/versions/summary", appHandler.GetApplicationVersionsSummary)           // 版本概要
 	api.GET("/applications/:name/versions/:version/coverage", appHandler.GetApplicationVersionCoverage) // 版本覆盖率（累积）
 
+	// 应用运维路由（:env 为环境 ID 或名称）
+	api.GET("/applications/:name/environments/:env/status", appHandler.GetApplicationStatus)
+	api.POST("/applications/:name/environments/:env/scale", appHandler.ScaleApplication)
+	api.POST("/applications/:name/environments/:env/restart", appHandler.RestartApplication)
+	api.GET("/applications/:name/environments/:env/logs", appHandler.GetApplicationLogs)
+	api.GET("/applications/:name/environments/:env/events", appHandler.GetApplicationEvents)
+
 	// 环境管理路由
 	environments := api.Group("/environments")
 	{
@@ -236,6 +269,11 @@ func main() {
 		deployments.POST("/:id/rollback", deploymentHandler.RollbackDeployment)
 	}
 
+	// Operator 健康状态
+	api.GET("/operators", operatorHandler.ListOperators)
+	// Operator 状态回调（签名校验，:env 为环境 ID 或名称）
+	api.POST("/operators/:env/callback", operatorHandler.Callback)
+
 	// 任务管理路由
 	tasks := api.Group("/tasks")
 	{
@@ -264,6 +302,24 @@ func main() {
 		}
 	}()
 
+	// SIGHUP 重新加载 Operator 配置，不中断服务
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			logger.GetLogger().Info("Reloading operator config")
+			newCfg, err := masterconfig.Load("")
+			if err != nil {
+				logger.GetLogger().Error("Failed to reload master config", zap.Error(err))
+				continue
+			}
+			operatorFactory.SetConfig(newOperatorConfig(newCfg))
+			if err := envService.ReloadOperators(context.Background()); err != nil {
+				logger.GetLogger().Error("Failed to reload operators", zap.Error(err))
+			}
+		}
+	}()
+
 	// 等待中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -281,3 +337,20 @@ func main() {
 
 	logger.GetLogger().Info("Server exited")
 }
+
+// newOperatorConfig 由 master 配置构造 Operator 客户端配置
+func newOperatorConfig(cfg *masterconfig.Config) *operator.Config {
+	return &operator.Config{
+		K8SOperatorURL: cfg.Operator.K8SOperatorURL,
+		PMOperatorURL:  cfg.Operator.PMOperatorURL,
+		UseMock:        cfg.Operator.UseMock,
+		Retry: transport.RetryPolicy{
+			MaxAttempts: cfg.Operator.RetryMaxAttempts,
+		},
+		Breaker: transport.BreakerConfig{
+			FailureThreshold: cfg.Operator.CircuitFailureThreshold,
+			OpenTimeout:      time.Duration(cfg.Operator.CircuitOpenTimeout) * time.Second,
+		},
+		StatusCacheTTL: time.Duration(cfg.Operator.StatusCacheTTL) * time.Second,
+	}
+}
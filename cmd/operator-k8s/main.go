@@ -1,12 +1,16 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/services/operator-k8s/config"
 	"github.com/boreas/internal/services/operator-k8s/handler"
 	"github.com/boreas/internal/services/operator-k8s/service"
@@ -39,16 +43,34 @@ func main() {
 
 	logger.Init(cfg.Log.Level, cfg.Log.Format)
 
-	operatorService, err := service.NewOperatorK8sService(
-		cfg.K8s.ConfigPath,
-		cfg.K8s.Namespace,
-		cfg.K8s.Timeout,
-	)
+	router, err := service.NewClusterRouter(service.RouterConfig{
+		Kubeconfig: cfg.K8s.ConfigPath,
+		Context:    cfg.K8s.Context,
+		Namespace:  cfg.K8s.Namespace,
+		Clusters:   cfg.K8s.Clusters,
+		Namespaces: cfg.K8s.Namespaces,
+		Timeout:    cfg.K8s.Timeout,
+		Renderer:   service.NewCommandRenderer(cfg.K8s.Renderer.Helm, cfg.K8s.Renderer.Kustomize, cfg.K8s.Renderer.Sources),
+	})
 	if err != nil {
 		log.Fatal("Failed to initialize operator service:", err)
 	}
+	router.StartHealthCheck(context.Background(), time.Duration(cfg.K8s.HealthCheck.Interval)*time.Second)
+
+	if cfg.K8s.Controller.Enabled {
+		dyn, err := router.DynamicClient("")
+		if err != nil {
+			log.Fatal("Failed to initialize controller:", err)
+		}
+		controller := service.NewController(dyn, router, cfg.K8s.Controller.Namespace, time.Duration(cfg.K8s.Controller.Resync)*time.Second)
+		go controller.Run(context.Background())
+	}
 
-	operatorHandler := handler.NewOperatorK8sHandler(operatorService)
+	operatorHandler := handler.NewOperatorK8sHandler(router)
+	notifier := operatorapi.NewNotifier(cfg.Callback, func(ctx context.Context, app string) (*models.ApplicationStatusResponse, error) {
+		return operatorHandler.ApplicationStatus(ctx, app)
+	})
+	operatorHandler.WithNotifier(notifier)
 
 	gin.SetMode(gin.ReleaseMode)
 
@@ -7,6 +7,7 @@ import (
 	"os"
 
 	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/services/operator-mock/handler"
 	"github.com/boreas/internal/services/operator-mock/service"
 	"github.com/gin-gonic/gin"
@@ -23,6 +24,8 @@ func main() {
 		port        = flag.String("port", "8082", "服务端口")
 		logLevel    = flag.String("log-level", "info", "日志级别")
 		logFormat   = flag.String("log-format", "json", "日志格式")
+		callbackURL = flag.String("callback-url", "", "Master 状态回调地址，为空时不推送")
+		callbackKey = flag.String("callback-secret", "", "状态回调签名密钥")
 	)
 	flag.Parse()
 
@@ -38,6 +41,10 @@ func main() {
 	mockService := service.MockAgent
 
 	mockHandler := handler.NewOperatorMockHandler(mockService)
+	mockHandler.WithNotifier(operatorapi.NewNotifier(operatorapi.CallbackConfig{
+		URL:    *callbackURL,
+		Secret: *callbackKey,
+	}, mockHandler.ApplicationStatus))
 
 	gin.SetMode(gin.ReleaseMode)
 
@@ -1,14 +1,17 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
 	"github.com/boreas/internal/services/operator-pm-agent/config"
 	"github.com/boreas/internal/services/operator-pm-agent/handler"
 	"github.com/boreas/internal/services/operator-pm-agent/service"
@@ -96,6 +99,29 @@ func main() {
 	log.Printf("Work directory: %s", agentWorkDir)
 	log.Printf("Version: %s", version)
 
+	// 向 Operator PM 注册节点并发送心跳
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if cfg.Agent.Registration.OperatorURL != "" {
+		ip := cfg.Agent.IP
+		if ip == "" {
+			if ip, err = service.DetectIP(cfg.Agent.Registration.OperatorURL); err != nil {
+				log.Fatal("Failed to detect agent ip, set agent.ip:", err)
+			}
+		}
+		registrar := service.NewRegistrar(cfg.Agent.Registration.OperatorURL, cfg.Agent.Registration.Token,
+			time.Duration(cfg.Agent.Registration.HeartbeatInterval)*time.Second, models.AgentRegistration{
+				ID:       cfg.GetAgentID(),
+				Hostname: cfg.Agent.Hostname,
+				IP:       ip,
+				Port:     cfg.Server.Port,
+				Labels:   cfg.Agent.Labels,
+				Version:  version,
+			})
+		go registrar.Run(ctx)
+		log.Printf("Registering with operator %s as %s (%s)", cfg.Agent.Registration.OperatorURL, cfg.GetAgentID(), ip)
+	}
+
 	// 优雅关闭
 	go func() {
 		if err := r.Run(serverAddr); err != nil {
@@ -1,12 +1,18 @@
 package main
 
 import (
+	"context"
 	"flag"
 	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/services/operator-pm/config"
 	"github.com/boreas/internal/services/operator-pm/handler"
 	"github.com/boreas/internal/services/operator-pm/service"
@@ -45,9 +51,33 @@ func main() {
 
 	// 初始化服务
 	operatorService := service.NewOperatorPMService(cfg)
+	if err := operatorService.RestoreAssignments(); err != nil {
+		log.Fatal("Failed to restore assignments:", err)
+	}
+	operatorService.StartNodeMonitor(context.Background(), 10*time.Second)
+
+	// 映射文件变化或收到 SIGHUP 时重新加载，校验失败时保留原映射
+	if err := operatorService.WatchMappings(context.Background()); err != nil {
+		log.Printf("Mapping hot reload disabled: %v", err)
+	}
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+	go func() {
+		for range reload {
+			if err := operatorService.ReloadMappings(); err != nil {
+				log.Printf("Failed to reload node mappings, keeping the previous mappings: %v", err)
+			}
+		}
+	}()
 
 	// 初始化处理器
-	operatorHandler := handler.NewOperatorPMHandler(operatorService)
+	notifier := operatorapi.NewNotifier(cfg.Callback, func(ctx context.Context, app string) (*models.ApplicationStatusResponse, error) {
+		return operatorService.GetApplicationStatus(app)
+	})
+	operatorHandler := handler.NewOperatorPMHandler(operatorService).WithNotifier(notifier)
+	if cfg.PM.Registration.Enabled {
+		operatorHandler.WithRegistrationToken(cfg.PM.Registration.Token)
+	}
 
 	// 设置Gin模式
 	gin.SetMode(gin.ReleaseMode)
@@ -12,6 +12,8 @@ type VersionService interface {
 	GetVersionList(ctx context.Context, req *models.ListVersionsRequest) (*models.VersionListResponse, error)
 	GetVersion(ctx context.Context, version string) (*models.Version, error) // 通过版本号查询
 	DeleteVersion(ctx context.Context, version string) error                 // 通过版本号删除
+	// TriggerAutoDeployments 按环境的自动部署规则为版本创建部署，CreateVersion 创建版本后自动调用
+	TriggerAutoDeployments(ctx context.Context, versionID string) ([]*models.Deployment, error)
 }
 
 // ApplicationService 应用服务接口
@@ -26,6 +28,13 @@ type ApplicationService interface {
 	GetApplicationVersionsSummary(ctx context.Context, appName string) (*models.ApplicationVersionsSummaryResponse, error)
 	GetApplicationVersionsDetail(ctx context.Context, appName string) (*models.ApplicationVersionsDetailResponse, error)
 	GetApplicationVersionCoverage(ctx context.Context, appName string, targetVersion string) (*models.VersionCoverageResponse, error)
+	// 运维相关，env 可以是环境 ID 或名称
+	GetApplicationStatus(ctx context.Context, appName, env string) (*models.ApplicationStatusResponse, error) // 优先返回 Operator 推送的缓存状态
+	ScaleApplication(ctx context.Context, appName, env string, req *models.ScaleRequest) error
+	RestartApplication(ctx context.Context, appName, env string, req *models.RestartRequest) error
+	GetApplicationLogs(ctx context.Context, appName, env string, req *models.LogsRequest) (*models.LogsResponse, error)
+	GetApplicationEvents(ctx context.Context, appName, env string) (*models.EventsResponse, error)
+	UndeployApplication(ctx context.Context, appName, env string) error // 删除应用在该环境运行的所有版本
 }
 
 // EnvironmentService 环境服务接口
@@ -35,6 +44,7 @@ type EnvironmentService interface {
 	GetEnvironment(ctx context.Context, id string) (*models.Environment, error)
 	UpdateEnvironment(ctx context.Context, id string, req *models.UpdateEnvironmentRequest) (*models.Environment, error)
 	DeleteEnvironment(ctx context.Context, id string) error
+	ReloadOperators(ctx context.Context) error // 按当前配置重建所有环境的 Operator
 }
 
 // DeploymentService 部署服务接口
@@ -73,7 +83,6 @@ type DeploymentManager interface {
 	CreateVersionFromTag(ctx context.Context, tag *models.GitTag) (*models.Version, error)
 	CreateVersionFromPR(ctx context.Context, pr *models.PullRequest) (*models.Version, error)
 	CreateVersionFromRelease(ctx context.Context, release *models.Release) (*models.Version, error)
-	TriggerAutoDeployments(ctx context.Context, versionID string) ([]*models.Deployment, error)
 	StartDeployment(ctx context.Context, deploymentID string) error
 	CancelDeployment(ctx context.Context, deploymentID string) error
 	UpdateDeploymentStatus(ctx context.Context, deploymentID string, status models.DeploymentStatus, errorMsg string) error
@@ -89,6 +98,21 @@ type Operator interface {
 	// GetApplicationStatus 获取应用状态
 	GetApplicationStatus(ctx context.Context, appName string) (*models.ApplicationStatusResponse, error)
 
+	// Delete 删除应用在该环境的所有版本和资源，应用不存在时返回 operatorapi.ErrAppNotFound
+	Delete(ctx context.Context, appName string) error
+
+	// Scale 调整副本数，Operator 未声明 scale 能力时返回 operatorapi.ErrFeatureNotSupported
+	Scale(ctx context.Context, req *models.ScaleRequest) error
+
+	// Restart 重启应用实例
+	Restart(ctx context.Context, req *models.RestartRequest) error
+
+	// GetLogs 获取应用实例的最近日志
+	GetLogs(ctx context.Context, req *models.LogsRequest) (*models.LogsResponse, error)
+
+	// GetEvents 获取应用最近的事件
+	GetEvents(ctx context.Context, appName string) (*models.EventsResponse, error)
+
 	// HealthCheck 健康检查
 	HealthCheck(ctx context.Context) error
 
@@ -111,12 +135,29 @@ type OperatorManager interface {
 	// ApplyDeployment 在指定环境应用部署
 	ApplyDeployment(ctx context.Context, environmentID string, req *models.ApplyDeploymentRequest) (*models.ApplyDeploymentResponse, error)
 
+	// ScaleApplication、RestartApplication、DeleteApplication 通过环境的 Operator 变更应用，并清除应用的状态缓存
+	ScaleApplication(ctx context.Context, environmentID string, req *models.ScaleRequest) error
+	RestartApplication(ctx context.Context, environmentID string, req *models.RestartRequest) error
+	DeleteApplication(ctx context.Context, environmentID string, appName string) error
+
 	// GetApplicationStatus 获取指定环境中应用的状态
 	GetApplicationStatus(ctx context.Context, environmentID string, appName string) (*models.ApplicationStatusResponse, error)
 
+	// UpdateApplicationStatus 记录 Operator 推送的应用最新状态
+	UpdateApplicationStatus(environmentID string, status *models.ApplicationStatusResponse)
+
 	// HealthCheckAll 检查所有 Operator 的健康状态
 	HealthCheckAll(ctx context.Context) map[string]error
 
+	// GetOperatorHealth 获取指定环境 Operator 最近的健康状态和熔断状态
+	GetOperatorHealth(environmentID string) (*models.OperatorHealth, bool)
+
+	// ListOperatorHealth 列出所有 Operator 的健康状态
+	ListOperatorHealth() []*models.OperatorHealth
+
+	// IsCircuitOpen 指定环境的 Operator 是否处于熔断状态
+	IsCircuitOpen(environmentID string) bool
+
 	// RemoveOperator 移除指定环境的 Operator
 	RemoveOperator(environmentID string)
 
@@ -132,23 +173,3 @@ type Deployer interface {
 	GetDeploymentInfo(ctx context.Context, deploymentID string) (*models.DeploymentInfo, error)
 	HealthCheck(ctx context.Context, deploymentID string) (*models.HealthCheckResult, error)
 }
-
-// KubernetesDeployer Kubernetes部署器接口
-type KubernetesDeployer interface {
-	Deployer
-	ApplyManifest(ctx context.Context, namespace string, manifest []byte) error
-	GetPodStatus(ctx context.Context, namespace, selector string) ([]*models.PodStatus, error)
-	ScaleDeployment(ctx context.Context, namespace, name string, replicas int32) error
-	GetLogs(ctx context.Context, namespace, podName string, lines int) (string, error)
-	DeleteResources(ctx context.Context, namespace string, labels map[string]string) error
-}
-
-// PhysicalDeployer 物理机部署器接口
-type PhysicalDeployer interface {
-	Deployer
-	UploadArtifact(ctx context.Context, hosts []string, artifact *models.Artifact) error
-	ExecuteCommand(ctx context.Context, hosts []string, command string) (*models.CommandResult, error)
-	RestartService(ctx context.Context, hosts []string, serviceName string) error
-	CheckServiceStatus(ctx context.Context, hosts []string, serviceName string) ([]*models.ServiceStatus, error)
-	CleanupOldVersions(ctx context.Context, hosts []string, keepVersions int) error
-}
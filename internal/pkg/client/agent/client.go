@@ -9,12 +9,14 @@ import (
 	"net/http"
 	"time"
 
+	"github.com/boreas/internal/pkg/client/transport"
 	"github.com/boreas/internal/pkg/models"
 )
 
 type Client struct {
 	baseURL    string
 	httpClient *http.Client
+	breaker    *transport.CircuitBreaker
 }
 
 type ClientOption func(*Client)
@@ -31,12 +33,23 @@ func WithTimeout(timeout time.Duration) ClientOption {
 	}
 }
 
+// WithRetryPolicy 设置幂等请求的重试策略
+func WithRetryPolicy(policy transport.RetryPolicy) ClientOption {
+	return func(c *Client) {
+		c.httpClient.Transport = transport.New(nil, policy, c.breaker)
+	}
+}
+
+// NewClient 创建 Agent 客户端，默认对幂等请求重试并按 Agent 熔断
 func NewClient(baseURL string, opts ...ClientOption) *Client {
+	breaker := transport.NewCircuitBreaker(transport.BreakerConfig{})
 	client := &Client{
 		baseURL: baseURL,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: transport.New(nil, transport.RetryPolicy{}, breaker),
 		},
+		breaker: breaker,
 	}
 
 	for _, opt := range opts {
@@ -46,6 +59,11 @@ func NewClient(baseURL string, opts ...ClientOption) *Client {
 	return client
 }
 
+// CircuitBreaker 返回客户端的熔断器
+func (c *Client) CircuitBreaker() *transport.CircuitBreaker {
+	return c.breaker
+}
+
 type Response struct {
 	Code    int             `json:"code"`
 	Message string          `json:"message"`
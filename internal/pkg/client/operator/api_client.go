@@ -0,0 +1,218 @@
+package operator
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/boreas/internal/pkg/client/transport"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+)
+
+// apiClient 按 operatorapi 协议访问远程 Operator，K8sClient 和 PMClient 共用
+type apiClient struct {
+	operatorType string
+	baseURL      string
+	httpClient   *http.Client
+	breaker      *transport.CircuitBreaker
+
+	// 部署目标，非空时通过 operatorapi.HeaderCluster/HeaderNamespace 发送
+	cluster   string
+	namespace string
+
+	mu   sync.Mutex
+	caps *operatorapi.Capabilities // 协商得到的能力，HealthCheck 时失效以感知 Operator 升级
+}
+
+func newAPIClient(operatorType string, ep models.OperatorEndpoint, retry transport.RetryPolicy, breakerCfg transport.BreakerConfig) (*apiClient, error) {
+	breaker := transport.NewCircuitBreaker(breakerCfg)
+	httpClient, err := newHTTPClient(ep, retry, breaker)
+	if err != nil {
+		return nil, err
+	}
+	return &apiClient{
+		operatorType: operatorType,
+		baseURL:      strings.TrimSuffix(ep.URL, "/"),
+		httpClient:   httpClient,
+		breaker:      breaker,
+	}, nil
+}
+
+// CircuitBreaker 返回客户端的熔断器
+func (c *apiClient) CircuitBreaker() *transport.CircuitBreaker {
+	return c.breaker
+}
+
+// Capabilities 获取 Operator 的协议版本和能力，结果会缓存
+// 不支持当前协议版本时返回 operatorapi.ErrUnsupportedVersion
+func (c *apiClient) Capabilities(ctx context.Context) (*operatorapi.Capabilities, error) {
+	c.mu.Lock()
+	caps := c.caps
+	c.mu.Unlock()
+	if caps != nil {
+		return caps, nil
+	}
+
+	caps = &operatorapi.Capabilities{}
+	if err := c.do(ctx, http.MethodGet, operatorapi.PathCapabilities, nil, caps); err != nil {
+		return nil, fmt.Errorf("failed to negotiate capabilities: %w", err)
+	}
+	if !caps.SupportsVersion(operatorapi.Version) {
+		return nil, fmt.Errorf("%w: %s operator supports %v, client uses %s",
+			operatorapi.ErrUnsupportedVersion, c.operatorType, caps.APIVersions, operatorapi.Version)
+	}
+
+	c.mu.Lock()
+	c.caps = caps
+	c.mu.Unlock()
+	return caps, nil
+}
+
+// Apply 应用部署，完整的版本列表原样下发；Operator 未声明多版本能力时拒绝多版本请求
+func (c *apiClient) Apply(ctx context.Context, req *models.ApplyDeploymentRequest) (*models.ApplyDeploymentResponse, error) {
+	if err := operatorapi.ValidateApplyRequest(req); err != nil {
+		return nil, err
+	}
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := operatorapi.CheckFeatures(caps, req); err != nil {
+		return nil, err
+	}
+
+	var resp models.ApplyDeploymentResponse
+	if err := c.do(ctx, http.MethodPost, operatorapi.PathApply, req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetApplicationStatus 获取应用状态，应用不存在时错误满足 errors.Is(err, operatorapi.ErrAppNotFound)
+func (c *apiClient) GetApplicationStatus(ctx context.Context, appName string) (*models.ApplicationStatusResponse, error) {
+	var resp models.ApplicationStatusResponse
+	if err := c.do(ctx, http.MethodGet, operatorapi.PathStatus+url.PathEscape(appName), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Delete 删除应用在该环境的所有版本
+func (c *apiClient) Delete(ctx context.Context, appName string) error {
+	return c.do(ctx, http.MethodDelete, operatorapi.PathApps+url.PathEscape(appName), nil, nil)
+}
+
+// Scale 调整副本数，Operator 未声明 scale 能力时直接返回 operatorapi.ErrFeatureNotSupported
+func (c *apiClient) Scale(ctx context.Context, req *models.ScaleRequest) error {
+	caps, err := c.Capabilities(ctx)
+	if err != nil {
+		return err
+	}
+	if !caps.Supports(operatorapi.FeatureScale) {
+		return fmt.Errorf("%w: %s operator cannot scale", operatorapi.ErrFeatureNotSupported, caps.Type)
+	}
+	return c.do(ctx, http.MethodPost, operatorapi.PathScale, req, nil)
+}
+
+// Restart 重启应用实例
+func (c *apiClient) Restart(ctx context.Context, req *models.RestartRequest) error {
+	return c.do(ctx, http.MethodPost, operatorapi.PathRestart, req, nil)
+}
+
+// GetLogs 获取应用实例的最近日志
+func (c *apiClient) GetLogs(ctx context.Context, req *models.LogsRequest) (*models.LogsResponse, error) {
+	query := url.Values{}
+	if req.Version != "" {
+		query.Set("version", req.Version)
+	}
+	if req.Node != "" {
+		query.Set("node", req.Node)
+	}
+	if req.Lines > 0 {
+		query.Set("lines", strconv.Itoa(req.Lines))
+	}
+	path := operatorapi.PathLogs + url.PathEscape(req.App)
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var resp models.LogsResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetEvents 获取应用最近的事件
+func (c *apiClient) GetEvents(ctx context.Context, appName string) (*models.EventsResponse, error) {
+	var resp models.EventsResponse
+	if err := c.do(ctx, http.MethodGet, operatorapi.PathEvents+url.PathEscape(appName), nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// HealthCheck 健康检查
+func (c *apiClient) HealthCheck(ctx context.Context) error {
+	c.mu.Lock()
+	c.caps = nil
+	c.mu.Unlock()
+
+	if err := c.do(ctx, http.MethodGet, operatorapi.PathHealth, nil, nil); err != nil {
+		var apiErr *operatorapi.Error
+		if errors.As(err, &apiErr) {
+			return fmt.Errorf("health check failed with status: %d", apiErr.StatusCode)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetType 获取 Operator 类型
+func (c *apiClient) GetType() string {
+	return c.operatorType
+}
+
+// do 发送协议请求并解析统一响应信封
+func (c *apiClient) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(bs)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	httpReq.Header.Set(operatorapi.HeaderAPIVersion, operatorapi.Version)
+	if c.cluster != "" {
+		httpReq.Header.Set(operatorapi.HeaderCluster, c.cluster)
+	}
+	if c.namespace != "" {
+		httpReq.Header.Set(operatorapi.HeaderNamespace, c.namespace)
+	}
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return fmt.Errorf("failed to send request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return operatorapi.DecodeResponse(resp, out)
+}
@@ -0,0 +1,68 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"github.com/boreas/internal/pkg/utils"
+)
+
+func TestAPIClient_NegotiatesCapabilities(t *testing.T) {
+	var applied models.ApplyDeploymentRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get(operatorapi.HeaderAPIVersion) != operatorapi.Version {
+			t.Errorf("missing api version header on %s", r.URL.Path)
+		}
+		var resp utils.Response
+		switch r.URL.Path {
+		case operatorapi.PathCapabilities:
+			resp.Data = operatorapi.NewCapabilities("kubernetes", operatorapi.FeatureVersionStatus)
+		case operatorapi.PathApply:
+			_ = json.NewDecoder(r.Body).Decode(&applied)
+			resp.Data = models.ApplyDeploymentResponse{App: applied.App, Success: true}
+		case operatorapi.PathStatus + "demo":
+			resp.Data = models.ApplicationStatusResponse{App: "demo", Versions: []models.VersionStatus{{Version: "v1"}}}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			resp = utils.Response{Code: http.StatusNotFound, Message: "not found"}
+		}
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	defer srv.Close()
+
+	client := NewK8sClient(srv.URL)
+	ctx := context.Background()
+	pkg := models.DeploymentPackage{Type: "docker", Image: "demo:v1"}
+
+	_, err := client.Apply(ctx, &models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 0.5, Package: pkg},
+		{Version: "v2", Percent: 0.5, Package: pkg},
+	}})
+	if !errors.Is(err, operatorapi.ErrFeatureNotSupported) {
+		t.Fatalf("multi-version apply error = %v, want ErrFeatureNotSupported", err)
+	}
+
+	resp, err := client.Apply(ctx, &models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 1, Package: pkg},
+	}})
+	if err != nil || !resp.Success {
+		t.Fatalf("Apply = %+v, %v", resp, err)
+	}
+	if len(applied.Versions) != 1 || applied.Versions[0].Percent != 1 {
+		t.Fatalf("operator received %+v", applied)
+	}
+
+	status, err := client.GetApplicationStatus(ctx, "demo")
+	if err != nil || len(status.Versions) != 1 || status.Versions[0].Version != "v1" {
+		t.Fatalf("GetApplicationStatus = %+v, %v", status, err)
+	}
+	if _, err := client.GetApplicationStatus(ctx, "missing"); !errors.Is(err, operatorapi.ErrAppNotFound) {
+		t.Fatalf("missing app error = %v, want ErrAppNotFound", err)
+	}
+}
@@ -2,9 +2,14 @@ package operator
 
 import (
 	"fmt"
+	"sync/atomic"
+	"time"
 
 	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/client/transport"
+	"github.com/boreas/internal/pkg/logger"
 	"github.com/boreas/internal/pkg/models"
+	"go.uber.org/zap"
 )
 
 // Config Operator 配置
@@ -17,34 +22,107 @@ type Config struct {
 
 	// Mock Operator 配置
 	UseMock bool `mapstructure:"use_mock"`
+
+	// 传输层配置：幂等请求的重试策略和每个 Operator 的熔断器
+	Retry   transport.RetryPolicy
+	Breaker transport.BreakerConfig
+
+	// 应用状态缓存有效期，见 Manager.SetStatusCacheTTL
+	StatusCacheTTL time.Duration
 }
 
 // CreateOperatorFromEnvironment 根据环境配置创建对应的 Operator 客户端
+// Operator 地址、Token、TLS 和超时优先读取 Environment.Config，未配置地址时使用全局配置
 func CreateOperatorFromEnvironment(env *models.Environment, config *Config) (interfaces.Operator, error) {
+	envCfg, err := env.GetEnvironmentConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	if config.UseMock {
+		return NewMockClient(), nil
+	}
+
+	ep := envCfg.Operator
 	switch env.Type {
-	case "kubernetes":
-		if config.UseMock {
-			return NewMockClient(), nil
+	case models.EnvironmentTypeKubernetes:
+		if ep.URL == "" {
+			ep.URL = config.K8SOperatorURL
 		}
-		if config.K8SOperatorURL == "" {
+		if envCfg.Mode == models.K8sModeCRD {
+			return createK8sCRClient(envCfg, ep, config)
+		}
+		if ep.URL == "" {
 			return nil, fmt.Errorf("k8s operator URL not configured")
 		}
-		return NewK8sClient(config.K8SOperatorURL), nil
+		client, err := NewK8sClientWithEndpoint(ep, config.Retry, config.Breaker)
+		if err != nil {
+			return nil, err
+		}
+		return client.WithTarget(envCfg.Cluster, envCfg.Namespace), nil
 
-	case "physical":
-		if config.UseMock {
-			return NewMockClient(), nil
+	case models.EnvironmentTypePhysical:
+		if ep.URL == "" {
+			ep.URL = config.PMOperatorURL
 		}
-		if config.PMOperatorURL == "" {
+		if ep.URL == "" {
 			return nil, fmt.Errorf("pm operator URL not configured")
 		}
-		return NewPMClient(config.PMOperatorURL), nil
+		client, err := NewPMClientWithEndpoint(ep, config.Retry, config.Breaker)
+		if err != nil {
+			return nil, err
+		}
+		return client, nil
 
 	default:
 		return nil, fmt.Errorf("unsupported environment type: %s", env.Type)
 	}
 }
 
+// createK8sCRClient 创建 crd 模式的客户端，配置了 Operator 地址时重启、日志和事件走 HTTP 接口
+func createK8sCRClient(envCfg *models.EnvironmentConfig, ep models.OperatorEndpoint, config *Config) (interfaces.Operator, error) {
+	client, err := NewK8sCRClient(envCfg.Kubeconfig, envCfg.Cluster, envCfg.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if ep.URL != "" {
+		operator, err := NewK8sClientWithEndpoint(ep, config.Retry, config.Breaker)
+		if err != nil {
+			return nil, err
+		}
+		client.WithOperator(operator.WithTarget(envCfg.Cluster, envCfg.Namespace))
+	}
+	return client, nil
+}
+
+// Factory 按当前配置为环境创建 Operator 客户端
+// 配置可以在运行时通过 SetConfig 替换，之后创建的客户端使用新配置
+type Factory struct {
+	config atomic.Pointer[Config]
+}
+
+// NewFactory 创建 Operator 工厂
+func NewFactory(config *Config) *Factory {
+	f := &Factory{}
+	f.SetConfig(config)
+	return f
+}
+
+// SetConfig 替换工厂使用的配置
+func (f *Factory) SetConfig(config *Config) {
+	f.config.Store(config)
+}
+
+// Config 返回当前配置
+func (f *Factory) Config() *Config {
+	return f.config.Load()
+}
+
+// Create 根据环境创建 Operator 客户端
+func (f *Factory) Create(env *models.Environment) (interfaces.Operator, error) {
+	return CreateOperatorFromEnvironment(env, f.Config())
+}
+
 // CreateOperatorByType 根据类型直接创建 Operator 客户端
 func CreateOperatorByType(envType string, baseURL string, useMock bool) (interfaces.Operator, error) {
 	if useMock {
@@ -69,14 +147,23 @@ func CreateOperatorByType(envType string, baseURL string, useMock bool) (interfa
 	}
 }
 
-// InitializeOperators 初始化所有环境的 Operator 客户端
+// InitializeOperators 初始化所有激活环境的 Operator 客户端，未激活的环境不注册
+// 配置无效的环境记录警告后跳过，不影响其他环境，修正配置后通过更新环境或重新加载注册
 func InitializeOperators(environments []*models.Environment, config *Config) (*Manager, error) {
 	manager := NewManager()
+	manager.SetStatusCacheTTL(config.StatusCacheTTL)
 
 	for _, env := range environments {
+		if !env.IsActive {
+			continue
+		}
 		operator, err := CreateOperatorFromEnvironment(env, config)
 		if err != nil {
-			return nil, fmt.Errorf("failed to create operator for environment %s: %w", env.Name, err)
+			logger.GetLogger().Warn("Skipping environment with invalid operator config",
+				zap.String("environment", env.Name),
+				zap.Error(err),
+			)
+			continue
 		}
 
 		manager.RegisterOperator(env.ID, operator)
@@ -0,0 +1,84 @@
+package operator
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+func newTestEnvironment(t *testing.T, envType string, config map[string]string) *models.Environment {
+	t.Helper()
+	bs, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &models.Environment{ID: "env-1", Name: "test", Type: envType, Config: bs}
+}
+
+func TestCreateOperatorFromEnvironment_PerEnvironmentEndpoint(t *testing.T) {
+	var gotAuth string
+	envServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer envServer.Close()
+
+	global := &Config{K8SOperatorURL: "http://127.0.0.1:1"}
+
+	env := newTestEnvironment(t, "kubernetes", map[string]string{
+		"operator_url":     envServer.URL,
+		"operator_token":   "secret-token",
+		"operator_timeout": "2s",
+	})
+	op, err := CreateOperatorFromEnvironment(env, global)
+	if err != nil {
+		t.Fatalf("CreateOperatorFromEnvironment: %v", err)
+	}
+	if err := op.HealthCheck(context.Background()); err != nil {
+		t.Fatalf("HealthCheck against per-environment URL: %v", err)
+	}
+	if gotAuth != "Bearer secret-token" {
+		t.Fatalf("Authorization = %q", gotAuth)
+	}
+
+	fallback, err := CreateOperatorFromEnvironment(newTestEnvironment(t, "kubernetes", nil), global)
+	if err != nil {
+		t.Fatalf("fallback: %v", err)
+	}
+	if c := fallback.(*K8sClient); c.baseURL != global.K8SOperatorURL {
+		t.Fatalf("fallback baseURL = %s", c.baseURL)
+	}
+
+	invalid := []map[string]string{
+		{"operator_url": "ftp://example.com"},
+		{"operator_timeout": "soon"},
+		{"operator_tls_cert": "/tmp/cert.pem"},
+		{"mode": "grpc"},
+	}
+	for _, config := range invalid {
+		if _, err := CreateOperatorFromEnvironment(newTestEnvironment(t, "kubernetes", config), global); err == nil {
+			t.Errorf("expected error for config %v", config)
+		}
+	}
+}
+
+func TestInitializeOperators_SkipsInvalidEnvironments(t *testing.T) {
+	legacy := newTestEnvironment(t, "kubernetes", map[string]string{"host": "10.0.0.1", "port": "8080"})
+	legacy.ID, legacy.IsActive = "env-legacy", true
+	invalid := newTestEnvironment(t, "kubernetes", map[string]string{"operator_timeout": "soon"})
+	invalid.ID, invalid.IsActive = "env-invalid", true
+	inactive := newTestEnvironment(t, "physical", nil)
+	inactive.ID = "env-inactive"
+
+	manager, err := InitializeOperators([]*models.Environment{legacy, invalid, inactive}, &Config{UseMock: true})
+	if err != nil {
+		t.Fatalf("InitializeOperators: %v", err)
+	}
+	if ids := manager.ListOperators(); len(ids) != 1 || ids[0] != "env-legacy" {
+		t.Fatalf("operators = %v, want only env-legacy", ids)
+	}
+}
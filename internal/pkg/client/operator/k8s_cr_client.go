@@ -0,0 +1,218 @@
+package operator
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/util/retry"
+)
+
+// K8sCRClient 以 BoreasApplication 资源部署应用（kubernetes 环境的 crd 模式）
+// Apply/Delete/Scale 修改资源，状态读取控制器写回的 .status；
+// 重启、日志和事件仍需调用 K8s Operator 的 HTTP 接口，未配置 Operator 地址时不支持
+type K8sCRClient struct {
+	dynamic   dynamic.Interface
+	namespace string
+	operator  *K8sClient
+}
+
+// NewK8sCRClient 使用 kubeconfig 的上下文（为空时为 current-context）创建客户端，kubeconfig 为空时使用集群内配置
+func NewK8sCRClient(kubeconfig, context, namespace string) (*K8sCRClient, error) {
+	var config *rest.Config
+	var err error
+	if kubeconfig == "" {
+		config, err = rest.InClusterConfig()
+	} else {
+		config, err = clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+			&clientcmd.ConfigOverrides{CurrentContext: context},
+		).ClientConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubernetes config: %w", err)
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return NewK8sCRClientWithDynamic(dyn, namespace), nil
+}
+
+// NewK8sCRClientWithDynamic 使用已有的 dynamic client 创建客户端（测试中传入 fake client）
+func NewK8sCRClientWithDynamic(dyn dynamic.Interface, namespace string) *K8sCRClient {
+	if namespace == "" {
+		namespace = "default"
+	}
+	return &K8sCRClient{dynamic: dyn, namespace: namespace}
+}
+
+// WithOperator 设置用于重启、日志和事件的 K8s Operator HTTP 客户端
+func (c *K8sCRClient) WithOperator(operator *K8sClient) *K8sCRClient {
+	c.operator = operator
+	return c
+}
+
+// Apply 创建或更新与应用同名的 BoreasApplication，返回时控制器可能尚未调和
+func (c *K8sCRClient) Apply(ctx context.Context, req *models.ApplyDeploymentRequest) (*models.ApplyDeploymentResponse, error) {
+	if err := operatorapi.ValidateApplyRequest(req); err != nil {
+		return nil, err
+	}
+	spec := &operatorapi.ApplicationSpec{App: req.App, Versions: req.Versions}
+	resources := c.resources()
+
+	var generation int64
+	err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		existing, err := resources.Get(ctx, req.App, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			obj, err := operatorapi.NewApplicationObject(req.App, c.namespace, spec)
+			if err != nil {
+				return err
+			}
+			created, err := resources.Create(ctx, obj, metav1.CreateOptions{})
+			if err != nil {
+				return err
+			}
+			generation = created.GetGeneration()
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := operatorapi.SetApplicationSpec(existing, spec); err != nil {
+			return err
+		}
+		updated, err := resources.Update(ctx, existing, metav1.UpdateOptions{})
+		if err != nil {
+			return err
+		}
+		generation = updated.GetGeneration()
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write BoreasApplication %s/%s: %w", c.namespace, req.App, err)
+	}
+	return &models.ApplyDeploymentResponse{
+		App:     req.App,
+		Success: true,
+		Message: fmt.Sprintf("BoreasApplication %s/%s submitted (generation %d)", c.namespace, req.App, generation),
+	}, nil
+}
+
+// GetApplicationStatus 读取控制器写回的状态，资源不存在时返回 operatorapi.ErrAppNotFound
+func (c *K8sCRClient) GetApplicationStatus(ctx context.Context, appName string) (*models.ApplicationStatusResponse, error) {
+	obj, err := c.resources().Get(ctx, appName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil, fmt.Errorf("%w: BoreasApplication %s/%s", operatorapi.ErrAppNotFound, c.namespace, appName)
+		}
+		return nil, fmt.Errorf("failed to get BoreasApplication %s/%s: %w", c.namespace, appName, err)
+	}
+	status, err := operatorapi.ApplicationStatusOf(obj)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &models.ApplicationStatusResponse{App: appName, Healthy: status.Healthy, Versions: status.Versions}
+	switch {
+	case status.ObservedGeneration < obj.GetGeneration():
+		resp.Healthy.Msg = fmt.Sprintf("Waiting for controller to reconcile generation %d", obj.GetGeneration())
+	case status.Phase == operatorapi.PhaseFailed:
+		resp.Healthy.Msg = status.Message
+	}
+	if resp.Versions == nil {
+		resp.Versions = []models.VersionStatus{}
+	}
+	return resp, nil
+}
+
+// Delete 删除 BoreasApplication，控制器通过 finalizer 删除应用的所有对象
+func (c *K8sCRClient) Delete(ctx context.Context, appName string) error {
+	err := c.resources().Delete(ctx, appName, metav1.DeleteOptions{})
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("%w: BoreasApplication %s/%s", operatorapi.ErrAppNotFound, c.namespace, appName)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to delete BoreasApplication %s/%s: %w", c.namespace, appName, err)
+	}
+	return nil
+}
+
+// Scale 修改所有版本部署包的副本数；资源中的副本数按 percent 分配到各版本，不支持只调整一个版本
+func (c *K8sCRClient) Scale(ctx context.Context, req *models.ScaleRequest) error {
+	if req.Version != "" {
+		return fmt.Errorf("%w: scaling a single version in crd mode, adjust percent instead", operatorapi.ErrFeatureNotSupported)
+	}
+	if req.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative")
+	}
+	resources := c.resources()
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		obj, err := resources.Get(ctx, req.App, metav1.GetOptions{})
+		if err != nil {
+			if apierrors.IsNotFound(err) {
+				return fmt.Errorf("%w: BoreasApplication %s/%s", operatorapi.ErrAppNotFound, c.namespace, req.App)
+			}
+			return err
+		}
+		spec, err := operatorapi.ApplicationSpecOf(obj)
+		if err != nil {
+			return err
+		}
+		for i := range spec.Versions {
+			spec.Versions[i].Package.Replicas = req.Replicas
+		}
+		if err := operatorapi.SetApplicationSpec(obj, spec); err != nil {
+			return err
+		}
+		_, err = resources.Update(ctx, obj, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// Restart 通过 K8s Operator 重启应用实例
+func (c *K8sCRClient) Restart(ctx context.Context, req *models.RestartRequest) error {
+	if c.operator == nil {
+		return fmt.Errorf("%w: restart requires operator_url in crd mode", operatorapi.ErrFeatureNotSupported)
+	}
+	return c.operator.Restart(ctx, req)
+}
+
+// GetLogs 通过 K8s Operator 获取日志
+func (c *K8sCRClient) GetLogs(ctx context.Context, req *models.LogsRequest) (*models.LogsResponse, error) {
+	if c.operator == nil {
+		return nil, fmt.Errorf("%w: logs require operator_url in crd mode", operatorapi.ErrFeatureNotSupported)
+	}
+	return c.operator.GetLogs(ctx, req)
+}
+
+// GetEvents 通过 K8s Operator 获取事件
+func (c *K8sCRClient) GetEvents(ctx context.Context, appName string) (*models.EventsResponse, error) {
+	if c.operator == nil {
+		return nil, fmt.Errorf("%w: events require operator_url in crd mode", operatorapi.ErrFeatureNotSupported)
+	}
+	return c.operator.GetEvents(ctx, appName)
+}
+
+// HealthCheck 检查能否列出命名空间中的 BoreasApplication（集群可达且已安装 CRD）
+func (c *K8sCRClient) HealthCheck(ctx context.Context) error {
+	if _, err := c.resources().List(ctx, metav1.ListOptions{Limit: 1}); err != nil {
+		return fmt.Errorf("failed to list BoreasApplications: %w", err)
+	}
+	return nil
+}
+
+// GetType 获取 Operator 类型
+func (c *K8sCRClient) GetType() string {
+	return models.EnvironmentTypeKubernetes
+}
+
+func (c *K8sCRClient) resources() dynamic.ResourceInterface {
+	return c.dynamic.Resource(operatorapi.ApplicationResource).Namespace(c.namespace)
+}
@@ -0,0 +1,77 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestK8sCRClient_WritesBoreasApplication(t *testing.T) {
+	ctx := context.Background()
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{operatorapi.ApplicationResource: operatorapi.CRDKind + "List"})
+	client := NewK8sCRClientWithDynamic(dyn, "team-a")
+	resources := dyn.Resource(operatorapi.ApplicationResource).Namespace("team-a")
+
+	pkg := models.DeploymentPackage{Type: "docker", Image: "demo:v1", Replicas: 2}
+	req := &models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v1", Percent: 1, Package: pkg}}}
+	if _, err := client.Apply(ctx, req); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	req.Versions = append(req.Versions, models.VersionDeployment{Version: "v2", Percent: 0, Package: pkg})
+	if _, err := client.Apply(ctx, req); err != nil {
+		t.Fatalf("Apply update: %v", err)
+	}
+	obj, err := resources.Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("BoreasApplication: %v", err)
+	}
+	spec, _ := operatorapi.ApplicationSpecOf(obj)
+	if len(spec.Versions) != 2 || spec.Versions[0].Package.Image != "demo:v1" {
+		t.Fatalf("spec = %+v", spec)
+	}
+
+	// 状态来自控制器写回的 .status
+	_ = operatorapi.SetApplicationStatus(obj, &operatorapi.ApplicationCRStatus{
+		Phase:    operatorapi.PhaseReady,
+		Healthy:  models.HealthInfo{Level: 100, Msg: "All replicas ready"},
+		Versions: []models.VersionStatus{{Version: "v1", Healthy: models.HealthInfo{Level: 100}}},
+	})
+	obj, _ = resources.UpdateStatus(ctx, obj, metav1.UpdateOptions{})
+	status, err := client.GetApplicationStatus(ctx, "demo")
+	if err != nil {
+		t.Fatalf("GetApplicationStatus: %v", err)
+	}
+	if status.Healthy.Level != 100 || len(status.Versions) != 1 {
+		t.Errorf("status = %+v", status)
+	}
+
+	if err := client.Scale(ctx, &models.ScaleRequest{App: "demo", Replicas: 6}); err != nil {
+		t.Fatalf("Scale: %v", err)
+	}
+	obj, _ = resources.Get(ctx, "demo", metav1.GetOptions{})
+	spec, _ = operatorapi.ApplicationSpecOf(obj)
+	if spec.Versions[0].Package.Replicas != 6 || spec.Versions[1].Package.Replicas != 6 {
+		t.Errorf("replicas after scale = %+v", spec.Versions)
+	}
+	if err := client.Scale(ctx, &models.ScaleRequest{App: "demo", Version: "v1", Replicas: 1}); !errors.Is(err, operatorapi.ErrFeatureNotSupported) {
+		t.Errorf("Scale single version error = %v", err)
+	}
+	if _, err := client.GetLogs(ctx, &models.LogsRequest{App: "demo"}); !errors.Is(err, operatorapi.ErrFeatureNotSupported) {
+		t.Errorf("GetLogs without operator error = %v", err)
+	}
+
+	if err := client.Delete(ctx, "demo"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := client.GetApplicationStatus(ctx, "demo"); !errors.Is(err, operatorapi.ErrAppNotFound) {
+		t.Errorf("status after delete error = %v", err)
+	}
+}
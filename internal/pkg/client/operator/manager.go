@@ -3,9 +3,12 @@ package operator
 import (
 	"context"
 	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/client/transport"
 	"github.com/boreas/internal/pkg/models"
 )
 
@@ -15,22 +18,54 @@ var _ interfaces.OperatorManager = (*Manager)(nil)
 // Manager Operator 管理器
 // 负责管理所有类型的 Operator 客户端，并根据环境类型选择合适的 Operator
 type Manager struct {
-	operators map[string]interfaces.Operator // key: environment_id
+	operators map[string]interfaces.Operator    // key: environment_id
+	health    map[string]*models.OperatorHealth // key: environment_id，最近一次健康检查结果
 	mu        sync.RWMutex
+
+	// 应用状态缓存，Operator 推送或查询得到的状态在 statusTTL 内直接返回，减少轮询
+	statusTTL time.Duration
+	statusMu  sync.RWMutex
+	statuses  map[statusKey]*cachedStatus
+}
+
+type statusKey struct {
+	environmentID string
+	app           string
+}
+
+type cachedStatus struct {
+	status    *models.ApplicationStatusResponse
+	updatedAt time.Time
+}
+
+// circuitBreakerProvider 带熔断器的 Operator 客户端
+type circuitBreakerProvider interface {
+	CircuitBreaker() *transport.CircuitBreaker
 }
 
 // NewManager 创建 Operator 管理器
 func NewManager() *Manager {
 	return &Manager{
 		operators: make(map[string]interfaces.Operator),
+		health:    make(map[string]*models.OperatorHealth),
+		statuses:  make(map[statusKey]*cachedStatus),
 	}
 }
 
-// RegisterOperator 注册 Operator
+// SetStatusCacheTTL 设置应用状态缓存的有效期，<=0 时不使用缓存
+func (m *Manager) SetStatusCacheTTL(ttl time.Duration) {
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+	m.statusTTL = ttl
+}
+
+// RegisterOperator 注册 Operator，替换时清除旧的健康状态
 func (m *Manager) RegisterOperator(environmentID string, operator interfaces.Operator) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	m.operators[environmentID] = operator
+	delete(m.health, environmentID)
+	m.invalidateStatuses(environmentID, "")
 }
 
 // GetOperator 获取指定环境的 Operator
@@ -58,37 +93,230 @@ func (m *Manager) ApplyDeployment(ctx context.Context, environmentID string, req
 		return nil, err
 	}
 
+	m.invalidateStatuses(environmentID, req.App)
 	return operator.Apply(ctx, req)
 }
 
-// GetApplicationStatus 获取指定环境中应用的状态
+// ScaleApplication 调整指定环境中应用的副本数
+func (m *Manager) ScaleApplication(ctx context.Context, environmentID string, req *models.ScaleRequest) error {
+	operator, err := m.GetOperator(environmentID)
+	if err != nil {
+		return err
+	}
+
+	// 操作完成后再清除缓存，避免操作期间的查询把变更前的状态重新写入缓存
+	defer m.invalidateStatuses(environmentID, req.App)
+	return operator.Scale(ctx, req)
+}
+
+// RestartApplication 重启指定环境中应用的实例
+func (m *Manager) RestartApplication(ctx context.Context, environmentID string, req *models.RestartRequest) error {
+	operator, err := m.GetOperator(environmentID)
+	if err != nil {
+		return err
+	}
+
+	defer m.invalidateStatuses(environmentID, req.App)
+	return operator.Restart(ctx, req)
+}
+
+// DeleteApplication 删除指定环境中应用运行的所有版本
+func (m *Manager) DeleteApplication(ctx context.Context, environmentID string, appName string) error {
+	operator, err := m.GetOperator(environmentID)
+	if err != nil {
+		return err
+	}
+
+	defer m.invalidateStatuses(environmentID, appName)
+	return operator.Delete(ctx, appName)
+}
+
+// GetApplicationStatus 获取指定环境中应用的状态，缓存未过期时直接返回缓存
 func (m *Manager) GetApplicationStatus(ctx context.Context, environmentID string, appName string) (*models.ApplicationStatusResponse, error) {
 	operator, err := m.GetOperator(environmentID)
 	if err != nil {
 		return nil, err
 	}
 
-	return operator.GetApplicationStatus(ctx, appName)
+	if status, ok := m.cachedStatus(environmentID, appName); ok {
+		return status, nil
+	}
+
+	status, err := operator.GetApplicationStatus(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	m.UpdateApplicationStatus(environmentID, status)
+	return status, nil
+}
+
+// UpdateApplicationStatus 记录应用的最新状态，Operator 推送状态回调时调用
+func (m *Manager) UpdateApplicationStatus(environmentID string, status *models.ApplicationStatusResponse) {
+	if status == nil || status.App == "" {
+		return
+	}
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+	m.statuses[statusKey{environmentID, status.App}] = &cachedStatus{
+		status:    status,
+		updatedAt: time.Now(),
+	}
+}
+
+func (m *Manager) cachedStatus(environmentID, appName string) (*models.ApplicationStatusResponse, bool) {
+	m.statusMu.RLock()
+	defer m.statusMu.RUnlock()
+	c, ok := m.statuses[statusKey{environmentID, appName}]
+	if !ok || time.Since(c.updatedAt) >= m.statusTTL {
+		return nil, false
+	}
+	return c.status, true
+}
+
+// invalidateStatuses 清除环境中指定应用的状态缓存，appName 为空时清除整个环境
+func (m *Manager) invalidateStatuses(environmentID, appName string) {
+	m.statusMu.Lock()
+	defer m.statusMu.Unlock()
+	for k := range m.statuses {
+		if k.environmentID == environmentID && (appName == "" || k.app == appName) {
+			delete(m.statuses, k)
+		}
+	}
 }
 
-// HealthCheckAll 检查所有 Operator 的健康状态
+// HealthCheckAll 并发检查所有 Operator 的健康状态并记录结果
 func (m *Manager) HealthCheckAll(ctx context.Context) map[string]error {
 	m.mu.RLock()
-	defer m.mu.RUnlock()
-
-	results := make(map[string]error)
+	operators := make(map[string]interfaces.Operator, len(m.operators))
 	for envID, operator := range m.operators {
-		results[envID] = operator.HealthCheck(ctx)
+		operators[envID] = operator
+	}
+	m.mu.RUnlock()
+
+	var (
+		wg      sync.WaitGroup
+		resMu   sync.Mutex
+		results = make(map[string]error, len(operators))
+	)
+	for envID, operator := range operators {
+		wg.Add(1)
+		go func(envID string, operator interfaces.Operator) {
+			defer wg.Done()
+			err := operator.HealthCheck(ctx)
+			resMu.Lock()
+			results[envID] = err
+			resMu.Unlock()
+			m.recordHealth(envID, operator, err)
+		}(envID, operator)
 	}
+	wg.Wait()
 
 	return results
 }
 
+func (m *Manager) recordHealth(environmentID string, operator interfaces.Operator, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// 检查期间 Operator 已被替换或移除时丢弃结果
+	if m.operators[environmentID] != operator {
+		return
+	}
+
+	h, ok := m.health[environmentID]
+	if !ok {
+		h = &models.OperatorHealth{EnvironmentID: environmentID}
+		m.health[environmentID] = h
+	}
+	h.Type = operator.GetType()
+	h.LastChecked = time.Now()
+	h.Healthy = err == nil
+	if err != nil {
+		h.ConsecutiveFailures++
+		h.LastError = err.Error()
+	} else {
+		h.ConsecutiveFailures = 0
+		h.LastError = ""
+	}
+}
+
+// StartHealthPolling 在后台按 interval 定期执行 HealthCheckAll，ctx 取消时停止
+func (m *Manager) StartHealthPolling(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		interval = 15 * time.Second
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			checkCtx, cancel := context.WithTimeout(ctx, interval)
+			m.HealthCheckAll(checkCtx)
+			cancel()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// GetOperatorHealth 获取指定环境 Operator 的健康状态，熔断状态为实时值
+func (m *Manager) GetOperatorHealth(environmentID string) (*models.OperatorHealth, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.operatorHealthLocked(environmentID)
+}
+
+// ListOperatorHealth 列出所有 Operator 的健康状态，按环境 ID 排序
+func (m *Manager) ListOperatorHealth() []*models.OperatorHealth {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	list := make([]*models.OperatorHealth, 0, len(m.operators))
+	for envID := range m.operators {
+		h, _ := m.operatorHealthLocked(envID)
+		list = append(list, h)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].EnvironmentID < list[j].EnvironmentID
+	})
+	return list
+}
+
+// IsCircuitOpen 指定环境的 Operator 熔断器是否处于打开状态
+func (m *Manager) IsCircuitOpen(environmentID string) bool {
+	h, ok := m.GetOperatorHealth(environmentID)
+	return ok && h.CircuitState == string(transport.CircuitOpen)
+}
+
+func (m *Manager) operatorHealthLocked(environmentID string) (*models.OperatorHealth, bool) {
+	operator, ok := m.operators[environmentID]
+	if !ok {
+		return nil, false
+	}
+
+	h := models.OperatorHealth{
+		EnvironmentID: environmentID,
+		Type:          operator.GetType(),
+	}
+	if recorded, ok := m.health[environmentID]; ok {
+		h = *recorded
+	}
+	if p, ok := operator.(circuitBreakerProvider); ok && p.CircuitBreaker() != nil {
+		h.CircuitState = string(p.CircuitBreaker().State())
+	}
+	return &h, true
+}
+
 // RemoveOperator 移除指定环境的 Operator
 func (m *Manager) RemoveOperator(environmentID string) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 	delete(m.operators, environmentID)
+	delete(m.health, environmentID)
+	m.invalidateStatuses(environmentID, "")
 }
 
 // ListOperators 列出所有已注册的 Operator
@@ -0,0 +1,39 @@
+package operator
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+func TestManager_MutationsInvalidateStatusCache(t *testing.T) {
+	ctx := context.Background()
+	client := NewMockClient()
+	manager := NewManager()
+	manager.SetStatusCacheTTL(time.Hour)
+	manager.RegisterOperator("env-1", client)
+
+	if _, err := client.Apply(ctx, &models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v1", Percent: 1}}}); err != nil {
+		t.Fatal(err)
+	}
+	status, err := manager.GetApplicationStatus(ctx, "env-1", "demo")
+	if err != nil || len(status.Versions[0].Nodes) != 2 {
+		t.Fatalf("initial status = %+v, %v", status, err)
+	}
+
+	if err := manager.ScaleApplication(ctx, "env-1", &models.ScaleRequest{App: "demo", Replicas: 5}); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := manager.GetApplicationStatus(ctx, "env-1", "demo"); len(status.Versions[0].Nodes) != 5 {
+		t.Fatalf("status after scale has %d nodes, want 5", len(status.Versions[0].Nodes))
+	}
+
+	if err := manager.DeleteApplication(ctx, "env-1", "demo"); err != nil {
+		t.Fatal(err)
+	}
+	if status, _ := manager.GetApplicationStatus(ctx, "env-1", "demo"); len(status.Versions) != 0 {
+		t.Fatalf("status after delete = %+v, want no versions", status)
+	}
+}
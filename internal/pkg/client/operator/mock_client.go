@@ -4,14 +4,18 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"sync"
 	"time"
 
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 )
 
 // MockClient Mock Operator 客户端（用于测试和演示）
 type MockClient struct {
+	mu          sync.Mutex
 	deployments map[string]*mockDeployment
+	events      *operatorapi.EventLog
 }
 
 type mockDeployment struct {
@@ -24,6 +28,7 @@ type mockDeployment struct {
 func NewMockClient() *MockClient {
 	return &MockClient{
 		deployments: make(map[string]*mockDeployment),
+		events:      operatorapi.NewEventLog(0),
 	}
 }
 
@@ -65,11 +70,14 @@ func (c *MockClient) Apply(ctx context.Context, req *models.ApplyDeploymentReque
 	}
 
 	// 保存模拟的部署状态
+	c.mu.Lock()
 	c.deployments[req.App] = &mockDeployment{
 		app:       req.App,
 		versions:  versions,
 		updatedAt: time.Now(),
 	}
+	c.events.Record(req.App, models.EventTypeNormal, "Applied", req.App, fmt.Sprintf("Applied %d version(s)", len(versions)))
+	c.mu.Unlock()
 
 	return &models.ApplyDeploymentResponse{
 		App:     req.App,
@@ -80,6 +88,9 @@ func (c *MockClient) Apply(ctx context.Context, req *models.ApplyDeploymentReque
 
 // GetApplicationStatus 获取应用状态（模拟）
 func (c *MockClient) GetApplicationStatus(ctx context.Context, appName string) (*models.ApplicationStatusResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
 	deployment, ok := c.deployments[appName]
 	if !ok {
 		// 返回默认的模拟状态
@@ -111,6 +122,95 @@ func (c *MockClient) GetApplicationStatus(ctx context.Context, appName string) (
 	}, nil
 }
 
+// Delete 删除应用（模拟）
+func (c *MockClient) Delete(ctx context.Context, appName string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.deployments[appName]; !ok {
+		return fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, appName)
+	}
+	delete(c.deployments, appName)
+	c.events.Record(appName, models.EventTypeNormal, "Deleted", appName, "Application deleted")
+	return nil
+}
+
+// Scale 调整每个版本的模拟节点数
+func (c *MockClient) Scale(ctx context.Context, req *models.ScaleRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deployment, ok := c.deployments[req.App]
+	if !ok {
+		return fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, req.App)
+	}
+	for i := range deployment.versions {
+		v := &deployment.versions[i]
+		if req.Version != "" && v.Version != req.Version {
+			continue
+		}
+		nodes := make([]models.NodeStatus, 0, req.Replicas)
+		for n := 0; n < req.Replicas; n++ {
+			nodes = append(nodes, models.NodeStatus{
+				Node:    fmt.Sprintf("%s-%s-%d", req.App, v.Version, n),
+				Healthy: v.Healthy,
+			})
+		}
+		v.Nodes = nodes
+	}
+	deployment.updatedAt = time.Now()
+	c.events.Record(req.App, models.EventTypeNormal, "Scaled", req.App, fmt.Sprintf("Scaled to %d replicas", req.Replicas))
+	return nil
+}
+
+// Restart 重启应用（模拟）
+func (c *MockClient) Restart(ctx context.Context, req *models.RestartRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deployment, ok := c.deployments[req.App]
+	if !ok {
+		return fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, req.App)
+	}
+	deployment.updatedAt = time.Now()
+	c.events.Record(req.App, models.EventTypeNormal, "Restarted", req.App, "Application restarted")
+	return nil
+}
+
+// GetLogs 返回模拟日志
+func (c *MockClient) GetLogs(ctx context.Context, req *models.LogsRequest) (*models.LogsResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deployment, ok := c.deployments[req.App]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, req.App)
+	}
+
+	resp := &models.LogsResponse{App: req.App, Instances: []models.InstanceLogs{}}
+	for _, v := range deployment.versions {
+		if req.Version != "" && v.Version != req.Version {
+			continue
+		}
+		for _, node := range v.Nodes {
+			if req.Node != "" && node.Node != req.Node {
+				continue
+			}
+			resp.Instances = append(resp.Instances, models.InstanceLogs{
+				Node:    node.Node,
+				Version: v.Version,
+				Lines:   []string{fmt.Sprintf("%s %s %s started (mock)", deployment.updatedAt.Format(time.RFC3339), req.App, v.Version)},
+			})
+		}
+	}
+	return resp, nil
+}
+
+// GetEvents 返回记录的模拟事件
+func (c *MockClient) GetEvents(ctx context.Context, appName string) (*models.EventsResponse, error) {
+	return &models.EventsResponse{App: appName, Events: c.events.List(appName)}, nil
+}
+
 // HealthCheck 健康检查（模拟）
 func (c *MockClient) HealthCheck(ctx context.Context) error {
 	// Mock 客户端总是健康
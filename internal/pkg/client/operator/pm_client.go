@@ -1,102 +1,102 @@
 package operator
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"time"
 
+	"github.com/boreas/internal/pkg/client/transport"
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 )
 
+// defaultPollInterval 异步 Apply 查询操作进度的默认间隔
+const defaultPollInterval = 2 * time.Second
+
 // PMClient Physical Machine Operator 客户端
 type PMClient struct {
-	baseURL    string
-	httpClient *http.Client
+	*apiClient
+	pollInterval time.Duration
 }
 
-// NewPMClient 创建 PM Operator 客户端
+// NewPMClient 创建 PM Operator 客户端，使用默认的重试和熔断配置
 func NewPMClient(baseURL string) *PMClient {
-	return &PMClient{
-		baseURL: baseURL,
-		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
-	}
+	client, _ := NewPMClientWithEndpoint(models.OperatorEndpoint{URL: baseURL}, transport.RetryPolicy{}, transport.BreakerConfig{})
+	return client
 }
 
-// Apply 应用部署
-func (c *PMClient) Apply(ctx context.Context, req *models.ApplyDeploymentRequest) (*models.ApplyDeploymentResponse, error) {
-	// PM 环境支持多版本灰度部署，直接使用请求
-	body, err := json.Marshal(req)
-	if err != nil {
-		return nil, fmt.Errorf("failed to marshal request: %w", err)
-	}
-
-	httpReq, err := http.NewRequestWithContext(ctx, "POST", c.baseURL+"/v1/apply", bytes.NewBuffer(body))
+// NewPMClientWithEndpoint 按连接配置（地址、Token、TLS、超时）创建 PM Operator 客户端
+// 每个客户端有独立的熔断器
+func NewPMClientWithEndpoint(ep models.OperatorEndpoint, retry transport.RetryPolicy, breakerCfg transport.BreakerConfig) (*PMClient, error) {
+	c, err := newAPIClient(models.EnvironmentTypePhysical, ep, retry, breakerCfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+		return nil, err
 	}
-	httpReq.Header.Set("Content-Type", "application/json")
-
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
-	}
-	defer resp.Body.Close()
+	return &PMClient{apiClient: c, pollInterval: defaultPollInterval}, nil
+}
 
-	var applyResp models.ApplyDeploymentResponse
-	if err := json.NewDecoder(resp.Body).Decode(&applyResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+// WithPollInterval 设置异步 Apply 查询操作进度的间隔
+func (c *PMClient) WithPollInterval(interval time.Duration) *PMClient {
+	if interval > 0 {
+		c.pollInterval = interval
 	}
-
-	return &applyResp, nil
+	return c
 }
 
-// GetApplicationStatus 获取应用状态
-func (c *PMClient) GetApplicationStatus(ctx context.Context, appName string) (*models.ApplicationStatusResponse, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/status/"+appName, nil)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
+// Apply 应用部署；Operator 声明 async_apply 时异步下发并轮询操作直到结束，不在下发期间占用连接
+func (c *PMClient) Apply(ctx context.Context, req *models.ApplyDeploymentRequest) (*models.ApplyDeploymentResponse, error) {
+	if err := operatorapi.ValidateApplyRequest(req); err != nil {
+		return nil, err
 	}
-
-	resp, err := c.httpClient.Do(httpReq)
+	caps, err := c.Capabilities(ctx)
 	if err != nil {
-		return nil, fmt.Errorf("failed to send request: %w", err)
+		return nil, err
 	}
-	defer resp.Body.Close()
-
-	var statusResp models.ApplicationStatusResponse
-	if err := json.NewDecoder(resp.Body).Decode(&statusResp); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if !caps.Supports(operatorapi.FeatureAsyncApply) {
+		return c.apiClient.Apply(ctx, req)
 	}
-
-	return &statusResp, nil
-}
-
-// HealthCheck 健康检查
-func (c *PMClient) HealthCheck(ctx context.Context) error {
-	httpReq, err := http.NewRequestWithContext(ctx, "GET", c.baseURL+"/v1/health", nil)
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	if err := operatorapi.CheckFeatures(caps, req); err != nil {
+		return nil, err
 	}
 
-	resp, err := c.httpClient.Do(httpReq)
-	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+	var op operatorapi.Operation
+	if err := c.do(ctx, http.MethodPost, operatorapi.PathApply+"?"+operatorapi.QueryAsync+"=true", req, &op); err != nil {
+		return nil, err
 	}
-	defer resp.Body.Close()
+	return c.WaitOperation(ctx, op.ID)
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("health check failed with status: %d", resp.StatusCode)
+// GetOperation 查询异步 Apply 的进度和结果
+func (c *PMClient) GetOperation(ctx context.Context, id string) (*operatorapi.Operation, error) {
+	var op operatorapi.Operation
+	if err := c.do(ctx, http.MethodGet, operatorapi.PathOperations+url.PathEscape(id), nil, &op); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return &op, nil
 }
 
-// GetType 获取 Operator 类型
-func (c *PMClient) GetType() string {
-	return "physical"
+// WaitOperation 按间隔查询操作直到结束，返回 Apply 结果；ctx 结束时返回错误，Operator 上的下发不受影响
+func (c *PMClient) WaitOperation(ctx context.Context, id string) (*models.ApplyDeploymentResponse, error) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for {
+		op, err := c.GetOperation(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get operation %s: %w", id, err)
+		}
+		if op.State != models.OperationStateRunning {
+			if op.Result == nil {
+				return nil, fmt.Errorf("operation %s finished without result: %s", id, op.Message)
+			}
+			return op.Result, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("operation %s is still running: %w", id, ctx.Err())
+		case <-ticker.C:
+		}
+	}
 }
@@ -0,0 +1,75 @@
+package operator
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/boreas/internal/pkg/client/transport"
+	"github.com/boreas/internal/pkg/models"
+)
+
+// defaultOperatorTimeout Operator 请求的默认超时
+const defaultOperatorTimeout = 30 * time.Second
+
+// newHTTPClient 根据 Operator 连接配置创建 HTTP 客户端
+// 请求经过共享传输层（幂等请求重试 + 熔断），配置了 Token 时每个请求带上 Authorization: Bearer <token>
+func newHTTPClient(ep models.OperatorEndpoint, retry transport.RetryPolicy, breaker *transport.CircuitBreaker) (*http.Client, error) {
+	base := http.DefaultTransport.(*http.Transport).Clone()
+
+	if ep.TLSCAFile != "" || ep.TLSCertFile != "" || ep.TLSServerName != "" || ep.TLSInsecureSkipVerify {
+		tlsConfig := &tls.Config{
+			ServerName:         ep.TLSServerName,
+			InsecureSkipVerify: ep.TLSInsecureSkipVerify,
+		}
+		if ep.TLSCAFile != "" {
+			caPEM, err := os.ReadFile(ep.TLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read CA file: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caPEM) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", ep.TLSCAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if ep.TLSCertFile != "" {
+			cert, err := tls.LoadX509KeyPair(ep.TLSCertFile, ep.TLSKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load client certificate: %w", err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+		base.TLSClientConfig = tlsConfig
+	}
+
+	timeout := ep.Timeout
+	if timeout <= 0 {
+		timeout = defaultOperatorTimeout
+	}
+
+	var rt http.RoundTripper = transport.New(base, retry, breaker)
+	if ep.Token != "" {
+		rt = &tokenTransport{token: ep.Token, base: rt}
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: rt,
+	}, nil
+}
+
+// tokenTransport 为请求添加 Bearer Token
+type tokenTransport struct {
+	token string
+	base  http.RoundTripper
+}
+
+func (t *tokenTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.base.RoundTrip(req)
+}
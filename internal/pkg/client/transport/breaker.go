@@ -0,0 +1,122 @@
+package transport
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen 熔断器打开时请求被直接拒绝
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState 熔断器状态
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"    // 正常放行
+	CircuitOpen     CircuitState = "open"      // 拒绝请求，等待冷却
+	CircuitHalfOpen CircuitState = "half_open" // 冷却结束，放行一个探测请求
+)
+
+// BreakerConfig 熔断器配置
+type BreakerConfig struct {
+	FailureThreshold int           // 连续失败多少次后打开，<=0 时为 5
+	OpenTimeout      time.Duration // 打开后多久进入半开状态，<=0 时为 30s
+}
+
+// CircuitBreaker 按连续失败次数打开的熔断器
+type CircuitBreaker struct {
+	config BreakerConfig
+
+	mu          sync.Mutex
+	state       CircuitState
+	failures    int
+	openedAt    time.Time
+	probing     bool
+	lastFailure error
+}
+
+// NewCircuitBreaker 创建熔断器
+func NewCircuitBreaker(config BreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = 5
+	}
+	if config.OpenTimeout <= 0 {
+		config.OpenTimeout = 30 * time.Second
+	}
+	return &CircuitBreaker{
+		config: config,
+		state:  CircuitClosed,
+	}
+}
+
+// Allow 判断是否放行请求，半开状态下同一时间只放行一个探测请求
+func (b *CircuitBreaker) Allow() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if time.Since(b.openedAt) < b.config.OpenTimeout {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+		b.probing = true
+		return nil
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+		b.probing = true
+		return nil
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess 记录成功，关闭熔断器
+func (b *CircuitBreaker) RecordSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.state = CircuitClosed
+	b.failures = 0
+	b.probing = false
+	b.lastFailure = nil
+}
+
+// RecordFailure 记录失败，达到阈值或半开探测失败时打开熔断器
+func (b *CircuitBreaker) RecordFailure(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	b.lastFailure = err
+	if b.state == CircuitHalfOpen || b.failures >= b.config.FailureThreshold {
+		b.state = CircuitOpen
+		b.openedAt = time.Now()
+	}
+	b.probing = false
+}
+
+// releaseProbe 探测请求被调用方取消时释放探测名额，不改变状态
+func (b *CircuitBreaker) releaseProbe() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.probing = false
+}
+
+// State 返回当前状态
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.config.OpenTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// Failures 返回连续失败次数和最近一次失败原因
+func (b *CircuitBreaker) Failures() (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures, b.lastFailure
+}
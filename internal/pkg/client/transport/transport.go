@@ -0,0 +1,151 @@
+// Package transport 提供 Operator/Agent 客户端共用的 HTTP 传输层：
+// 幂等请求的抖动退避重试，以及按目标服务的熔断器
+package transport
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// RetryPolicy 重试策略
+type RetryPolicy struct {
+	MaxAttempts int           // 总尝试次数（含首次），<=0 时为 3
+	BaseDelay   time.Duration // 首次重试的基础等待，<=0 时为 200ms
+	MaxDelay    time.Duration // 单次等待上限，<=0 时为 5s
+}
+
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.BaseDelay <= 0 {
+		p.BaseDelay = 200 * time.Millisecond
+	}
+	if p.MaxDelay <= 0 {
+		p.MaxDelay = 5 * time.Second
+	}
+	return p
+}
+
+// backoff 第 attempt 次重试前的等待时间，使用 full jitter：[0, min(MaxDelay, BaseDelay*2^attempt))
+func (p RetryPolicy) backoff(attempt int) time.Duration {
+	d := p.BaseDelay << uint(attempt)
+	if d <= 0 || d > p.MaxDelay {
+		d = p.MaxDelay
+	}
+	return time.Duration(rand.Int63n(int64(d)) + 1)
+}
+
+// Transport 带重试和熔断的 http.RoundTripper
+// 只有幂等方法（GET/HEAD/OPTIONS/PUT/DELETE）会重试；网络错误和 5xx 计入熔断器失败
+type Transport struct {
+	Base    http.RoundTripper
+	Retry   RetryPolicy
+	Breaker *CircuitBreaker // 为 nil 时不熔断
+}
+
+// New 创建传输层，base 为 nil 时使用 http.DefaultTransport
+func New(base http.RoundTripper, retry RetryPolicy, breaker *CircuitBreaker) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &Transport{
+		Base:    base,
+		Retry:   retry.withDefaults(),
+		Breaker: breaker,
+	}
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+func isRetryableStatus(code int) bool {
+	return code == http.StatusTooManyRequests || code == http.StatusBadGateway ||
+		code == http.StatusServiceUnavailable || code == http.StatusGatewayTimeout
+}
+
+// RoundTrip 实现 http.RoundTripper
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	attempts := 1
+	if isIdempotent(req.Method) && (req.Body == nil || req.GetBody != nil) {
+		attempts = t.Retry.MaxAttempts
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			if err := sleep(req.Context(), t.Retry.backoff(attempt-1)); err != nil {
+				return nil, err
+			}
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				req = req.Clone(req.Context())
+				req.Body = body
+			}
+		}
+
+		if t.Breaker != nil {
+			if err := t.Breaker.Allow(); err != nil {
+				return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.Host, err)
+			}
+		}
+
+		resp, err := t.Base.RoundTrip(req)
+		if err != nil {
+			// 调用方取消或超时不计入熔断
+			if req.Context().Err() != nil {
+				if t.Breaker != nil {
+					t.Breaker.releaseProbe()
+				}
+				return nil, err
+			}
+			t.recordFailure(err)
+			lastErr = err
+			continue
+		}
+
+		if resp.StatusCode >= 500 {
+			t.recordFailure(fmt.Errorf("status %d", resp.StatusCode))
+		} else if t.Breaker != nil {
+			t.Breaker.RecordSuccess()
+		}
+
+		if attempt < attempts-1 && isRetryableStatus(resp.StatusCode) {
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("unexpected status %d", resp.StatusCode)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+func (t *Transport) recordFailure(err error) {
+	if t.Breaker != nil {
+		t.Breaker.RecordFailure(err)
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
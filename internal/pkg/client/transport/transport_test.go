@@ -0,0 +1,93 @@
+package transport
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTransport_RetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &http.Client{Transport: New(nil, RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond}, nil)}
+
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || atomic.LoadInt32(&calls) != 3 {
+		t.Fatalf("status %d after %d calls", resp.StatusCode, calls)
+	}
+
+	atomic.StoreInt32(&calls, 0)
+	resp, err = client.Post(server.URL, "application/json", strings.NewReader("{}"))
+	if err != nil {
+		t.Fatalf("POST: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusServiceUnavailable || atomic.LoadInt32(&calls) != 1 {
+		t.Fatalf("POST should not be retried: status %d after %d calls", resp.StatusCode, calls)
+	}
+}
+
+func TestTransport_CircuitBreaker(t *testing.T) {
+	var calls int32
+	healthy := int32(0)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		if atomic.LoadInt32(&healthy) == 0 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	breaker := NewCircuitBreaker(BreakerConfig{FailureThreshold: 2, OpenTimeout: 50 * time.Millisecond})
+	client := &http.Client{Transport: New(nil, RetryPolicy{MaxAttempts: 1}, breaker)}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		if err != nil {
+			t.Fatalf("GET %d: %v", i, err)
+		}
+		resp.Body.Close()
+	}
+	if breaker.State() != CircuitOpen {
+		t.Fatalf("state = %s, want open", breaker.State())
+	}
+
+	if _, err := client.Get(server.URL); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got %v", err)
+	}
+	if atomic.LoadInt32(&calls) != 2 {
+		t.Fatalf("open circuit should not reach server, calls = %d", calls)
+	}
+
+	atomic.StoreInt32(&healthy, 1)
+	time.Sleep(60 * time.Millisecond)
+	if breaker.State() != CircuitHalfOpen {
+		t.Fatalf("state = %s, want half_open", breaker.State())
+	}
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("probe: %v", err)
+	}
+	resp.Body.Close()
+	if breaker.State() != CircuitClosed {
+		t.Fatalf("state = %s, want closed after successful probe", breaker.State())
+	}
+}
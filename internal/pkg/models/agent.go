@@ -40,6 +40,7 @@ type AppStatusResponse struct {
 	App     string     `json:"app"`
 	Version string     `json:"version"`
 	Healthy HealthInfo `json:"healthy"`
+	Status  string     `json:"status,omitempty"` // running, stopped, error
 }
 
 // AgentConfig Agent配置
@@ -52,9 +53,67 @@ type AgentConfig struct {
 	Updated  time.Time         `json:"updated"`
 }
 
+// AgentRegistration Agent 启动时向 Operator PM 注册的信息，心跳时也会携带，Operator 重启后据此恢复节点
+type AgentRegistration struct {
+	ID       string            `json:"id" binding:"required"` // 节点名
+	Hostname string            `json:"hostname,omitempty"`
+	IP       string            `json:"ip" binding:"required"`
+	Port     int               `json:"port,omitempty"` // Agent 服务端口，为 0 时使用 Operator 配置的 pm.agent.port
+	Labels   map[string]string `json:"labels,omitempty"`
+	Version  string            `json:"version,omitempty"` // Agent 版本
+}
+
+// 节点状态
+const (
+	AgentNodeOnline  = "online"  // 最近一次心跳未超时
+	AgentNodeOffline = "offline" // 心跳超时
+	AgentNodeStatic  = "static"  // 只在 node-to-ip.yaml 中配置、未注册的节点
+)
+
+// AgentNode Operator PM 节点清单中的节点
+type AgentNode struct {
+	AgentRegistration
+	Status       string     `json:"status"`             // 见 AgentNode*
+	Cordoned     bool       `json:"cordoned,omitempty"` // 维护状态，不再接收新的部署
+	RegisteredAt *time.Time `json:"registered_at,omitempty"`
+	LastSeen     *time.Time `json:"last_seen,omitempty"` // 最近一次注册或心跳的时间
+}
+
+// AppAssignment 通过 API 设置的应用节点：Nodes 指定节点名，Selector 选择标签全部匹配的在线节点，两者取并集
+// 设置后代替 app-to-nodes.yaml 中该应用的节点列表
+type AppAssignment struct {
+	App      string            `json:"app"`
+	Nodes    []string          `json:"nodes,omitempty"`
+	Selector map[string]string `json:"selector,omitempty"`
+}
+
+// NodeMaintenanceResponse 节点 cordon/drain/uncordon 的结果
+type NodeMaintenanceResponse struct {
+	Node     string       `json:"node"`
+	Cordoned bool         `json:"cordoned"`
+	Message  string       `json:"message"`
+	Apps     []DrainedApp `json:"apps,omitempty"` // drain 时节点上的应用及迁移结果
+}
+
+// DrainedApp drain 时一个应用的迁移结果：先在 Target 上部署相同版本，成功后停止并删除节点上的实例
+type DrainedApp struct {
+	App     string `json:"app"`
+	Version string `json:"version"`
+	Target  string `json:"target,omitempty"` // 迁移到的节点
+	Stopped bool   `json:"stopped"`          // 节点上的实例是否已停止
+	Error   string `json:"error,omitempty"`
+}
+
+// K8s Operator 支持的清单类部署包类型
+const (
+	PackageTypeManifest  = "manifest"  // 原始 YAML 清单
+	PackageTypeHelm      = "helm"      // Helm Chart，在 Operator 本地渲染
+	PackageTypeKustomize = "kustomize" // Kustomize overlay
+)
+
 // DeploymentPackage 部署包信息
 type DeploymentPackage struct {
-	Type        string            `json:"type"` // docker, binary, script
+	Type        string            `json:"type"` // docker, binary, script, manifest, helm, kustomize
 	Replicas    int               `json:"replicas,omitempty"`
 	Image       string            `json:"image,omitempty"`
 	Command     []string          `json:"command,omitempty"`
@@ -63,10 +122,66 @@ type DeploymentPackage struct {
 	Volumes     []VolumeMount     `json:"volumes,omitempty"`
 	Ports       []PortMapping     `json:"ports,omitempty"`
 	Resources   ResourceLimits    `json:"resources,omitempty"`
+
+	HealthCheck      *HealthCheckConfig `json:"health_check,omitempty"`       // 存活/就绪检查
+	ImagePullSecrets []string           `json:"image_pull_secrets,omitempty"` // 镜像拉取凭证（K8s Secret 名称）
+
+	// 应用级的访问与伸缩配置（K8s），多个版本时取第一个配置了该项的版本
+	Service     *ServiceExposure   `json:"service,omitempty"`
+	Ingress     *IngressExposure   `json:"ingress,omitempty"`
+	Autoscaling *AutoscalingConfig `json:"autoscaling,omitempty"`
+
+	// 清单类部署包（K8s）
+	Manifests     string                 `json:"manifests,omitempty"`     // manifest：多文档 YAML
+	Chart         string                 `json:"chart,omitempty"`         // helm：Chart 包路径或 URL
+	Values        map[string]interface{} `json:"values,omitempty"`        // helm：values
+	Kustomization string                 `json:"kustomization,omitempty"` // kustomize：overlay 目录或远程地址
+}
+
+// ServiceExposure Service 配置
+type ServiceExposure struct {
+	Type  string        `json:"type,omitempty"`  // ClusterIP（默认）, NodePort, LoadBalancer
+	Ports []ServicePort `json:"ports,omitempty"` // 为空时按部署包的 ports 生成
 }
 
+// ServicePort Service 端口
+type ServicePort struct {
+	Name       string `json:"name,omitempty"`
+	Port       int    `json:"port"`
+	TargetPort int    `json:"target_port,omitempty"` // 默认与 port 相同
+	NodePort   int    `json:"node_port,omitempty"`
+	Protocol   string `json:"protocol,omitempty"` // tcp, udp
+}
+
+// IngressExposure Ingress 配置，后端为应用的 Service
+type IngressExposure struct {
+	Host      string `json:"host"`
+	Path      string `json:"path,omitempty"`       // 默认 /
+	Port      int    `json:"port,omitempty"`       // Service 端口，默认第一个
+	TLSSecret string `json:"tls_secret,omitempty"` // 为空时不启用 TLS
+	ClassName string `json:"class_name,omitempty"`
+}
+
+// AutoscalingConfig 水平自动伸缩配置
+type AutoscalingConfig struct {
+	MinReplicas      int `json:"min_replicas"`
+	MaxReplicas      int `json:"max_replicas"`
+	TargetCPUPercent int `json:"target_cpu_percent"` // 目标 CPU 使用率（%）
+}
+
+// 卷类型
+const (
+	VolumeTypeHostPath  = "host_path" // 宿主机目录，Type 为空时的默认值
+	VolumeTypeConfigMap = "config_map"
+	VolumeTypeSecret    = "secret"
+	VolumeTypeEmptyDir  = "empty_dir"
+	VolumeTypePVC       = "pvc"
+)
+
 // VolumeMount 卷挂载
 type VolumeMount struct {
+	Type          string `json:"type,omitempty"`   // host_path, config_map, secret, empty_dir, pvc
+	Source        string `json:"source,omitempty"` // ConfigMap/Secret/PVC 名称
 	HostPath      string `json:"host_path"`
 	ContainerPath string `json:"container_path"`
 	ReadOnly      bool   `json:"read_only"`
@@ -74,6 +189,8 @@ type VolumeMount struct {
 
 // PortMapping 端口映射
 type PortMapping struct {
+	// HostPort 访问应用使用的端口，为 0 时与 ContainerPort 相同：
+	// 物理机上为主机端口；K8s 中为按部署包端口生成的 Service 的端口（不设置 Pod 的 hostPort），配置了 service.ports 时不能设置
 	HostPort      int    `json:"host_port"`
 	ContainerPort int    `json:"container_port"`
 	Protocol      string `json:"protocol"` // tcp, udp
@@ -81,9 +198,11 @@ type PortMapping struct {
 
 // ResourceLimits 资源限制
 type ResourceLimits struct {
-	CPULimit    string `json:"cpu_limit,omitempty"`
-	MemoryLimit string `json:"memory_limit,omitempty"`
-	CPUSet      string `json:"cpu_set,omitempty"`
+	CPURequest    string `json:"cpu_request,omitempty"`
+	CPULimit      string `json:"cpu_limit,omitempty"`
+	MemoryRequest string `json:"memory_request,omitempty"`
+	MemoryLimit   string `json:"memory_limit,omitempty"`
+	CPUSet        string `json:"cpu_set,omitempty"`
 }
 
 // ContainerInfo 容器信息
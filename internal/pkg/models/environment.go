@@ -0,0 +1,281 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// 环境类型
+const (
+	EnvironmentTypeKubernetes = "kubernetes"
+	EnvironmentTypePhysical   = "physical"
+)
+
+// Environment.Config 中的 Operator 连接配置键
+const (
+	EnvConfigOperatorURL           = "operator_url"             // Operator 地址，为空时使用全局配置
+	EnvConfigOperatorToken         = "operator_token"           // Bearer Token
+	EnvConfigOperatorTimeout       = "operator_timeout"         // 请求超时，如 30s
+	EnvConfigOperatorTLSCA         = "operator_tls_ca"          // CA 证书文件
+	EnvConfigOperatorTLSCert       = "operator_tls_cert"        // 客户端证书文件
+	EnvConfigOperatorTLSKey        = "operator_tls_key"         // 客户端私钥文件
+	EnvConfigOperatorTLSServerName = "operator_tls_server_name" // 校验证书时使用的服务名
+	EnvConfigOperatorTLSInsecure   = "operator_tls_insecure"    // 跳过证书校验，仅用于测试
+	EnvConfigAutoDeploy            = "auto_deploy"              // 自动部署规则，见 AutoDeployRule
+	EnvConfigCallbackSecret        = "callback_secret"          // 校验 Operator 状态回调签名的密钥，为空时使用全局配置
+)
+
+// RedactedConfigValue API 响应中敏感配置的占位值；更新环境时原样提交表示保留已保存的值
+const RedactedConfigValue = "***"
+
+// secretEnvironmentConfigKeys 不在 API 响应中返回明文的配置键
+var secretEnvironmentConfigKeys = []string{
+	EnvConfigOperatorToken,
+	EnvConfigCallbackSecret,
+}
+
+// kubernetes 环境的部署方式（配置键 mode）
+const (
+	K8sModeHTTP = "http" // 调用 K8s Operator 的 /v1/apply 等接口（默认）
+	K8sModeCRD  = "crd"  // 写入 BoreasApplication 资源，由以控制器模式运行的 K8s Operator 调和
+)
+
+// 各环境类型允许的配置键（除通用键外）
+var environmentConfigSchema = map[string][]string{
+	EnvironmentTypeKubernetes: {"namespace", "cluster", "mode", "kubeconfig"},
+	EnvironmentTypePhysical:   {"datacenter"},
+}
+
+var commonEnvironmentConfigKeys = []string{
+	EnvConfigOperatorURL,
+	EnvConfigOperatorToken,
+	EnvConfigOperatorTimeout,
+	EnvConfigOperatorTLSCA,
+	EnvConfigOperatorTLSCert,
+	EnvConfigOperatorTLSKey,
+	EnvConfigOperatorTLSServerName,
+	EnvConfigOperatorTLSInsecure,
+	EnvConfigAutoDeploy,
+	EnvConfigCallbackSecret,
+}
+
+// OperatorEndpoint 环境对应 Operator 的连接配置
+type OperatorEndpoint struct {
+	URL     string
+	Token   string
+	Timeout time.Duration // 0 表示使用默认值
+
+	TLSCAFile             string
+	TLSCertFile           string
+	TLSKeyFile            string
+	TLSServerName         string
+	TLSInsecureSkipVerify bool
+}
+
+// EnvironmentConfig 解析并校验后的环境配置
+type EnvironmentConfig struct {
+	Operator OperatorEndpoint
+
+	// kubernetes
+	Namespace  string
+	Cluster    string // kubeconfig 上下文
+	Mode       string // K8sModeHTTP 或 K8sModeCRD
+	Kubeconfig string // crd 模式下 Master 访问集群使用的 kubeconfig，为空时使用集群内配置
+
+	// physical
+	Datacenter string
+
+	AutoDeploy []AutoDeployRule
+
+	CallbackSecret string
+}
+
+// ValidateEnvironmentConfig 校验创建或更新环境时提交的配置：在 ParseEnvironmentConfig 的基础上拒绝不属于该类型的配置键
+func ValidateEnvironmentConfig(envType string, config map[string]string) (*EnvironmentConfig, error) {
+	typeKeys, ok := environmentConfigSchema[envType]
+	if !ok {
+		return nil, fmt.Errorf("unsupported environment type: %s", envType)
+	}
+
+	allowed := make(map[string]bool)
+	for _, k := range commonEnvironmentConfigKeys {
+		allowed[k] = true
+	}
+	for _, k := range typeKeys {
+		allowed[k] = true
+	}
+	var unknown []string
+	for k := range config {
+		if !allowed[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unsupported config keys for %s environment: %v", envType, unknown)
+	}
+	return ParseEnvironmentConfig(envType, config)
+}
+
+// ParseEnvironmentConfig 按环境类型的 schema 解析配置
+// 未知的环境类型和格式错误的值返回错误；不属于该类型的配置键被忽略，以便加载升级前保存的环境
+func ParseEnvironmentConfig(envType string, config map[string]string) (*EnvironmentConfig, error) {
+	if _, ok := environmentConfigSchema[envType]; !ok {
+		return nil, fmt.Errorf("unsupported environment type: %s", envType)
+	}
+
+	cfg := &EnvironmentConfig{
+		Operator: OperatorEndpoint{
+			URL:           config[EnvConfigOperatorURL],
+			Token:         config[EnvConfigOperatorToken],
+			TLSCAFile:     config[EnvConfigOperatorTLSCA],
+			TLSCertFile:   config[EnvConfigOperatorTLSCert],
+			TLSKeyFile:    config[EnvConfigOperatorTLSKey],
+			TLSServerName: config[EnvConfigOperatorTLSServerName],
+		},
+		Namespace:      config["namespace"],
+		Cluster:        config["cluster"],
+		Mode:           config["mode"],
+		Kubeconfig:     config["kubeconfig"],
+		Datacenter:     config["datacenter"],
+		CallbackSecret: config[EnvConfigCallbackSecret],
+	}
+
+	if cfg.Operator.URL != "" {
+		u, err := url.Parse(cfg.Operator.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("invalid %s: %q", EnvConfigOperatorURL, cfg.Operator.URL)
+		}
+	}
+	switch cfg.Mode {
+	case "":
+		if envType == EnvironmentTypeKubernetes {
+			cfg.Mode = K8sModeHTTP
+		}
+	case K8sModeHTTP, K8sModeCRD:
+	default:
+		return nil, fmt.Errorf("invalid mode: %q", cfg.Mode)
+	}
+	if s := config[EnvConfigOperatorTimeout]; s != "" {
+		d, err := time.ParseDuration(s)
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("invalid %s: %q", EnvConfigOperatorTimeout, s)
+		}
+		cfg.Operator.Timeout = d
+	}
+	if s := config[EnvConfigOperatorTLSInsecure]; s != "" {
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %q", EnvConfigOperatorTLSInsecure, s)
+		}
+		cfg.Operator.TLSInsecureSkipVerify = b
+	}
+	if (cfg.Operator.TLSCertFile == "") != (cfg.Operator.TLSKeyFile == "") {
+		return nil, fmt.Errorf("%s and %s must be set together", EnvConfigOperatorTLSCert, EnvConfigOperatorTLSKey)
+	}
+	if s := config[EnvConfigAutoDeploy]; s != "" {
+		if err := json.Unmarshal([]byte(s), &cfg.AutoDeploy); err != nil {
+			return nil, fmt.Errorf("invalid %s: %w", EnvConfigAutoDeploy, err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// GetEnvironmentConfig 解析环境配置
+func (e *Environment) GetEnvironmentConfig() (*EnvironmentConfig, error) {
+	config, err := e.configMap()
+	if err != nil {
+		return nil, err
+	}
+	return ParseEnvironmentConfig(e.Type, config)
+}
+
+// ValidateConfig 按 ValidateEnvironmentConfig 严格校验环境配置，用于创建和更新环境
+func (e *Environment) ValidateConfig() error {
+	config, err := e.configMap()
+	if err != nil {
+		return err
+	}
+	_, err = ValidateEnvironmentConfig(e.Type, config)
+	return err
+}
+
+// Redacted 返回敏感配置替换为 RedactedConfigValue 的副本，用于 API 响应
+// 配置无法解析时副本不包含配置，避免返回明文
+func (e *Environment) Redacted() *Environment {
+	redacted := *e
+	config, err := e.configMap()
+	if err != nil {
+		redacted.Config = nil
+		return &redacted
+	}
+	changed := false
+	for _, k := range secretEnvironmentConfigKeys {
+		if v, ok := config[k]; ok && v != "" {
+			config[k] = RedactedConfigValue
+			changed = true
+		}
+	}
+	if changed {
+		redacted.Config, _ = json.Marshal(config)
+	}
+	return &redacted
+}
+
+// Redacted 返回关联环境的敏感配置已替换的副本，用于 API 响应
+func (a *Application) Redacted() *Application {
+	redacted := *a
+	if a.Environments != nil {
+		redacted.Environments = make([]Environment, len(a.Environments))
+		for i := range a.Environments {
+			redacted.Environments[i] = *a.Environments[i].Redacted()
+		}
+	}
+	return &redacted
+}
+
+// RestoreRedactedConfig 将更新请求中值为 RedactedConfigValue 的敏感配置还原为环境已保存的值
+// 已保存的配置中没有该键时删除占位值，避免把占位值保存为密钥
+func (e *Environment) RestoreRedactedConfig(config map[string]string) error {
+	stored, err := e.configMap()
+	if err != nil {
+		return err
+	}
+	for _, k := range secretEnvironmentConfigKeys {
+		if config[k] != RedactedConfigValue {
+			continue
+		}
+		if v, ok := stored[k]; ok {
+			config[k] = v
+		} else {
+			delete(config, k)
+		}
+	}
+	return nil
+}
+
+func (e *Environment) configMap() (map[string]string, error) {
+	var config map[string]string
+	if len(e.Config) > 0 {
+		if err := json.Unmarshal(e.Config, &config); err != nil {
+			return nil, fmt.Errorf("invalid environment config: %w", err)
+		}
+	}
+	return config, nil
+}
+
+// OperatorHealth Operator 健康状态
+type OperatorHealth struct {
+	EnvironmentID       string    `json:"environment_id"`
+	Type                string    `json:"type"`
+	Healthy             bool      `json:"healthy"`
+	CircuitState        string    `json:"circuit_state,omitempty"` // closed/open/half_open，不支持熔断的 Operator 为空
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	LastError           string    `json:"last_error,omitempty"`
+	LastChecked         time.Time `json:"last_checked"`
+}
@@ -2,6 +2,8 @@ package models
 
 import (
 	"encoding/json"
+	"path"
+	"strings"
 	"time"
 
 	"gorm.io/datatypes"
@@ -26,6 +28,8 @@ type Version struct {
 	CreatedAt   time.Time      `json:"created_at" gorm:"autoCreateTime"`
 	Description string         `json:"description"`
 	AppBuilds   datatypes.JSON `json:"app_builds,omitempty" gorm:"type:jsonb"`
+
+	DeploymentsTriggered []DeploymentReference `json:"deployments_triggered,omitempty" gorm:"-"` // 创建时由自动部署规则触发的部署
 }
 
 // GetGitInfo 获取 Git 信息
@@ -57,8 +61,51 @@ func (v *Version) SetAppBuilds(builds []AppBuild) error {
 // AppBuild 应用构建信息
 type AppBuild struct {
 	AppID       string `json:"app_id"`
-	AppName     string `json:"app_name"`     // 应用名称（唯一标识）
-	DockerImage string `json:"docker_image"` // Docker 镜像地址
+	AppName     string `json:"app_name"`         // 应用名称（唯一标识）
+	DockerImage string `json:"docker_image"`     // Docker 镜像地址
+	Digest      string `json:"digest,omitempty"` // 推送后镜像仓库返回的内容摘要，如 sha256:...
+}
+
+// ImageRef 返回部署使用的镜像引用
+// 有摘要时固定为 repo@sha256:...，避免可变 tag 被覆盖后部署到不同内容
+func (b AppBuild) ImageRef() string {
+	if b.Digest == "" {
+		return b.DockerImage
+	}
+	repo := b.DockerImage
+	if i := strings.LastIndex(repo, "@"); i >= 0 {
+		repo = repo[:i]
+	}
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo = repo[:i]
+	}
+	return repo + "@" + b.Digest
+}
+
+// BuildLogEntryType 构建日志条目类型
+type BuildLogEntryType string
+
+const (
+	BuildLogEntryStep   BuildLogEntryType = "step"   // Dockerfile 步骤开始，如 Step 2/5 : RUN make
+	BuildLogEntryOutput BuildLogEntryType = "output" // 步骤输出
+	BuildLogEntryError  BuildLogEntryType = "error"  // 构建/推送错误
+	BuildLogEntryStatus BuildLogEntryType = "status" // 构建结束状态，Message 为 success/failed/cancelled
+)
+
+// BuildLogEntry 结构化构建日志条目
+type BuildLogEntry struct {
+	Time    time.Time         `json:"time"`
+	Type    BuildLogEntryType `json:"type"`
+	Step    int               `json:"step,omitempty"` // 所属 Dockerfile 步骤序号，从 1 开始
+	Message string            `json:"message"`
+}
+
+// BuildLogResponse 构建日志响应
+type BuildLogResponse struct {
+	Version string          `json:"version"`
+	AppName string          `json:"app_name"`
+	Running bool            `json:"running"`
+	Entries []BuildLogEntry `json:"entries"`
 }
 
 // Application 应用信息
@@ -87,6 +134,7 @@ type BuildConfig struct {
 	Dockerfile string             `json:"dockerfile"`
 	BuildArgs  map[string]*string `json:"build_args"`
 	Context    string             `json:"context"`
+	Registry   string             `json:"registry,omitempty"` // 目标镜像仓库，为空时使用全局 docker_registry
 }
 
 func (a *Application) GetBuildConfig() *BuildConfig {
@@ -100,6 +148,33 @@ func (a *Application) GetBuildConfig() *BuildConfig {
 	return &ret
 }
 
+// PathConfig 应用在 monorepo 中的路径映射配置
+// Include/Exclude/Shared 均为相对仓库根目录的 glob，支持 ** 匹配任意层级目录
+type PathConfig struct {
+	Root    string   `json:"root"`    // 应用根目录（构建上下文所在目录），默认为应用名
+	Include []string `json:"include"` // 属于该应用的文件，默认为 <root>/**
+	Exclude []string `json:"exclude"` // 从 Include 中排除的文件
+	Shared  []string `json:"shared"`  // 依赖的共享库路径，变更时该应用也视为受影响
+}
+
+// GetPathConfig 获取路径映射配置（未配置时按应用名推导默认值）
+func (a *Application) GetPathConfig() *PathConfig {
+	var ret = PathConfig{}
+	var config map[string]string
+	b, _ := a.Config.MarshalJSON()
+	_ = json.Unmarshal(b, &config)
+	if s, ok := config["paths"]; ok {
+		_ = json.Unmarshal([]byte(s), &ret)
+	}
+	if ret.Root == "" {
+		ret.Root = a.Name
+	}
+	if len(ret.Include) == 0 {
+		ret.Include = []string{path.Join(ret.Root, "**")}
+	}
+	return &ret
+}
+
 // Environment 环境信息
 type Environment struct {
 	ID        string         `json:"id" gorm:"primaryKey"`
@@ -111,6 +186,33 @@ type Environment struct {
 	UpdatedAt time.Time      `json:"updated_at" gorm:"autoUpdateTime"`
 }
 
+// AutoDeployRule 环境的自动部署规则
+// 版本创建时依次匹配，命中的规则会在该环境创建部署
+type AutoDeployRule struct {
+	Repositories   []string      `json:"repositories"`    // 匹配的仓库地址，为空表示任意仓库
+	Apps           []string      `json:"apps"`            // 需要部署的应用名称（按顺序部署），为空表示版本中的所有应用
+	TagPatterns    []string      `json:"tag_patterns"`    // 匹配的 tag glob，如 v*.*.*，为空表示任意 tag
+	Strategy       []DeploySteps `json:"strategy"`        // 部署策略，为空时使用默认策略
+	ManualApproval bool          `json:"manual_approval"` // 是否需要人工审批
+	AutoStart      bool          `json:"auto_start"`      // 创建后是否自动开始部署
+}
+
+// GetAutoDeployRules 获取环境的自动部署规则
+// 规则以 JSON 数组字符串保存在 config["auto_deploy"] 中
+func (e *Environment) GetAutoDeployRules() ([]AutoDeployRule, error) {
+	var config map[string]string
+	_ = json.Unmarshal(e.Config, &config)
+	s, ok := config["auto_deploy"]
+	if !ok || s == "" {
+		return nil, nil
+	}
+	var rules []AutoDeployRule
+	if err := json.Unmarshal([]byte(s), &rules); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
 // DeploymentStatus 部署状态
 // DeploymentStatus 部署状态（面向前端）
 type DeploymentStatus string
@@ -329,9 +431,11 @@ type DeployTaskPayload struct {
 
 // HealthCheckConfig 健康检查配置
 type HealthCheckConfig struct {
-	Endpoint string `json:"endpoint"`
-	Interval int    `json:"interval"` // 秒
-	Timeout  int    `json:"timeout"`  // 秒
+	Endpoint     string `json:"endpoint"`                // HTTP 路径，如 /healthz；为空时检查 TCP 端口
+	Port         int    `json:"port,omitempty"`          // 检查端口，默认第一个容器端口
+	Interval     int    `json:"interval"`                // 秒
+	Timeout      int    `json:"timeout"`                 // 秒
+	InitialDelay int    `json:"initial_delay,omitempty"` // 启动后首次检查前的等待（秒）
 }
 
 // DeployTaskResult Deploy 任务结果
@@ -493,10 +597,13 @@ type ApplicationVersionsSummaryResponse struct {
 
 // VersionInstance 版本实例信息
 type VersionInstance struct {
-	NodeName      string     `json:"node_name"`       // 节点名称
-	Healthy       HealthInfo `json:"healthy"`         // 健康度 (0-100)
-	Status        string     `json:"status"`          // 实例状态
-	LastUpdatedAt time.Time  `json:"last_updated_at"` // 最后更新时间
+	NodeName      string     `json:"node_name"`          // 节点名称
+	Instance      string     `json:"instance,omitempty"` // 实例名称（K8s 为 Pod 名称）
+	Healthy       HealthInfo `json:"healthy"`            // 健康度 (0-100)
+	Status        string     `json:"status"`             // 实例状态
+	Restarts      int        `json:"restarts,omitempty"` // 重启次数
+	Cordoned      bool       `json:"cordoned,omitempty"` // 节点处于维护状态，不计入覆盖率
+	LastUpdatedAt time.Time  `json:"last_updated_at"`    // 最后更新时间
 }
 
 // EnvironmentVersionDetail 环境下的版本详细信息
@@ -639,8 +746,11 @@ type WebhookResponse struct {
 	Message              string                `json:"message"`
 	VersionCreated       bool                  `json:"version_created,omitempty"`
 	VersionID            string                `json:"version_id,omitempty"`
+	BuildID              string                `json:"build_id,omitempty"` // 未创建版本时按该 ID 查询构建日志
 	AutoTag              string                `json:"auto_tag,omitempty"`
 	DeploymentsTriggered []DeploymentReference `json:"deployments_triggered,omitempty"`
+	AppsBuilt            []AppBuild            `json:"apps_built,omitempty"`
+	Errors               []string              `json:"errors,omitempty"`
 }
 
 // DeploymentReference 部署引用
@@ -708,6 +818,10 @@ type DeploymentStatusInfo struct {
 type ApplyDeploymentRequest struct {
 	App      string              `json:"app" binding:"required"`
 	Versions []VersionDeployment `json:"versions" binding:"required"`
+
+	// 部署目标（K8s 的 kubeconfig 上下文和命名空间），为空时使用请求头或 Operator 的默认值
+	Cluster   string `json:"cluster,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
 }
 
 // VersionDeployment 版本部署信息
@@ -719,11 +833,58 @@ type VersionDeployment struct {
 
 // ApplyDeploymentResponse 应用部署响应（Operator PM API）
 type ApplyDeploymentResponse struct {
-	App     string `json:"app"`
-	Message string `json:"message"`
-	Success bool   `json:"success"`
+	App     string           `json:"app"`
+	Message string           `json:"message"`
+	Success bool             `json:"success"`
+	Nodes   []NodeAssignment `json:"nodes,omitempty"` // 物理机 Operator 返回每个节点分配的版本和下发结果
+
+	OperationID string `json:"operation_id,omitempty"` // 物理机 Operator 的操作 ID，可通过 /v1/operations/:id 查询
+}
+
+// NodeAssignment 节点分配的版本
+type NodeAssignment struct {
+	Node     string `json:"node"`
+	Version  string `json:"version"`            // stop 时为节点上原来运行的版本
+	Action   string `json:"action,omitempty"`   // 为空时部署 Version，见 AssignmentAction*
+	Status   string `json:"status,omitempty"`   // 见 AssignmentStatus*
+	Error    string `json:"error,omitempty"`    // failed/skipped 的原因
+	Attempts int    `json:"attempts,omitempty"` // 下发尝试次数（含重试）
+}
+
+// 节点分配的操作
+const (
+	AssignmentActionStop = "stop" // 节点不再分配版本，停止并删除节点上的应用
+)
+
+// 节点下发结果
+const (
+	AssignmentStatusPending  = "pending"  // 等待下发
+	AssignmentStatusApplying = "applying" // 下发中（含重试）
+	AssignmentStatusApplied  = "applied"
+	AssignmentStatusFailed   = "failed"
+	AssignmentStatusSkipped  = "skipped" // 部署超时前未开始下发
+)
+
+// Operation 后台执行的操作（物理机 Operator 的异步 Apply），Nodes 为每个节点的实时进度
+type Operation struct {
+	ID         string                   `json:"id"`
+	Type       string                   `json:"type"` // apply、drain（节点维护时迁移应用）
+	App        string                   `json:"app"`
+	State      string                   `json:"state"` // 见 OperationState*
+	Message    string                   `json:"message,omitempty"`
+	Nodes      []NodeAssignment         `json:"nodes"`
+	Result     *ApplyDeploymentResponse `json:"result,omitempty"` // 结束后的 Apply 结果
+	CreatedAt  time.Time                `json:"created_at"`
+	FinishedAt *time.Time               `json:"finished_at,omitempty"`
 }
 
+// 操作状态
+const (
+	OperationStateRunning   = "running"
+	OperationStateSucceeded = "succeeded" // 所有节点下发成功
+	OperationStateFailed    = "failed"    // 有节点失败或跳过，见 Result.Nodes
+)
+
 // ApplicationStatusResponse 应用状态响应（Operator PM API）
 type ApplicationStatusResponse struct {
 	App      string          `json:"app"`
@@ -740,12 +901,81 @@ type VersionStatus struct {
 
 // NodeStatus 节点状态
 type NodeStatus struct {
-	Node    string     `json:"node"`
-	Healthy HealthInfo `json:"healthy"`
+	Node     string     `json:"node"`
+	Healthy  HealthInfo `json:"healthy"`
+	Instance string     `json:"instance,omitempty"` // 实例名称（K8s 为 Pod 名称）
+	Status   string     `json:"status,omitempty"`   // 实例状态，见 InstanceStatus*
+	Restarts int        `json:"restarts,omitempty"` // 重启次数
+	Cordoned bool       `json:"cordoned,omitempty"` // 节点处于维护状态（物理机 Operator），不再接收新的部署，不计入覆盖率
 }
 
+// 实例状态
+const (
+	InstanceStatusReady       = "ready"
+	InstanceStatusNotReady    = "not_ready"
+	InstanceStatusPending     = "pending"
+	InstanceStatusFailed      = "failed"
+	InstanceStatusTerminating = "terminating"
+)
+
 // HealthInfo 健康状态信息
 type HealthInfo struct {
 	Level int    `json:"level"`         // 0-100
 	Msg   string `json:"msg,omitempty"` // 健康状态描述信息
 }
+
+// ScaleRequest 扩缩容请求
+type ScaleRequest struct {
+	App      string `json:"app"`
+	Version  string `json:"version,omitempty"` // 为空时对应用的所有版本生效
+	Replicas int    `json:"replicas" binding:"min=0"`
+}
+
+// RestartRequest 重启请求
+type RestartRequest struct {
+	App     string `json:"app"`
+	Version string `json:"version,omitempty"` // 为空时重启应用的所有版本
+}
+
+// LogsRequest 日志查询请求
+type LogsRequest struct {
+	App     string `json:"app" form:"-"`
+	Version string `json:"version,omitempty" form:"version"` // 只查询该版本的实例
+	Node    string `json:"node,omitempty" form:"node"`       // 只查询该节点/Pod
+	Lines   int    `json:"lines,omitempty" form:"lines"`     // 每个实例返回最后多少行，<=0 时为 100
+}
+
+// InstanceLogs 单个实例（Pod/机器）的日志
+type InstanceLogs struct {
+	Node    string   `json:"node"`
+	Version string   `json:"version,omitempty"`
+	Lines   []string `json:"lines"`
+	Error   string   `json:"error,omitempty"` // 该实例日志获取失败的原因
+}
+
+// LogsResponse 日志查询响应
+type LogsResponse struct {
+	App       string         `json:"app"`
+	Instances []InstanceLogs `json:"instances"`
+}
+
+// 事件类型
+const (
+	EventTypeNormal  = "Normal"
+	EventTypeWarning = "Warning"
+)
+
+// OperatorEvent Operator 上报的应用事件
+type OperatorEvent struct {
+	Time    time.Time `json:"time"`
+	Type    string    `json:"type"`   // Normal/Warning
+	Reason  string    `json:"reason"` // 简短原因，如 Scaled、Restarted、FailedApply
+	Object  string    `json:"object"` // 关联对象，如 Deployment/Pod 名或节点名
+	Message string    `json:"message"`
+}
+
+// EventsResponse 事件查询响应
+type EventsResponse struct {
+	App    string          `json:"app"`
+	Events []OperatorEvent `json:"events"`
+}
@@ -0,0 +1,279 @@
+// Package operatorapi 定义 Master 与各 Operator（kubernetes/physical/mock）之间的 HTTP 协议
+//
+// 客户端（internal/pkg/client/operator）和服务端（internal/services/operator-*）共用这里的
+// 路径、请求/响应类型、统一响应信封和能力协商，协议说明见 docs/api/operator-protocol.md
+package operatorapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/utils"
+	"github.com/gin-gonic/gin"
+)
+
+// Version 当前协议版本
+const Version = "v1"
+
+// SupportedVersions 服务端支持的协议版本
+var SupportedVersions = []string{Version}
+
+// HeaderAPIVersion 客户端声明所使用协议版本的请求头
+const HeaderAPIVersion = "X-Boreas-Operator-Api"
+
+// 部署目标请求头：同一个 Operator 管理多个集群/命名空间时选择目标，为空时使用 Operator 的默认值
+const (
+	HeaderCluster   = "X-Boreas-Cluster"
+	HeaderNamespace = "X-Boreas-Namespace"
+)
+
+// 协议路径
+const (
+	PathHealth       = "/" + Version + "/health"
+	PathReady        = "/" + Version + "/ready"
+	PathCapabilities = "/" + Version + "/capabilities"
+	PathApply        = "/" + Version + "/apply"
+	PathStatus       = "/" + Version + "/status/" // 后接应用名
+	PathApps         = "/" + Version + "/apps/"   // DELETE，后接应用名
+	PathScale        = "/" + Version + "/scale"
+	PathRestart      = "/" + Version + "/restart"
+	PathLogs         = "/" + Version + "/logs/"       // 后接应用名
+	PathEvents       = "/" + Version + "/events/"     // 后接应用名
+	PathOperations   = "/" + Version + "/operations/" // 后接操作 ID
+)
+
+// QueryAsync Apply 请求的查询参数，为 true 且 Operator 声明 async_apply 时立即返回 202 和操作（Operation），
+// 客户端通过 PathOperations 查询进度和结果
+const QueryAsync = "async"
+
+// Feature Operator 可选能力
+type Feature string
+
+const (
+	// FeatureMultiVersion 同一应用的多个版本可同时运行并按 Percent 分配（灰度）
+	// 不支持时 Apply 只接受一个版本
+	FeatureMultiVersion Feature = "multi_version"
+	// FeatureVersionStatus 状态按实际运行的版本分组上报
+	FeatureVersionStatus Feature = "version_status"
+	// FeatureNodeStatus 状态中包含每个版本的节点（Pod/机器）明细
+	FeatureNodeStatus Feature = "node_status"
+	// FeatureScale 支持调整副本数（物理机的实例数由节点映射决定，不支持）
+	FeatureScale Feature = "scale"
+	// FeatureAsyncApply 支持异步 Apply（?async=true）和 GET /v1/operations/:id
+	FeatureAsyncApply Feature = "async_apply"
+)
+
+// Capabilities GET /v1/capabilities 的响应
+type Capabilities struct {
+	Type        string    `json:"type"`         // kubernetes/physical/mock
+	APIVersions []string  `json:"api_versions"` // 支持的协议版本
+	Features    []Feature `json:"features"`     // 支持的可选能力
+}
+
+// SupportsVersion 是否支持指定协议版本
+func (c *Capabilities) SupportsVersion(version string) bool {
+	for _, v := range c.APIVersions {
+		if v == version {
+			return true
+		}
+	}
+	return false
+}
+
+// Supports 是否支持指定能力
+func (c *Capabilities) Supports(feature Feature) bool {
+	for _, f := range c.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// 协议使用的请求/响应类型
+type (
+	ApplyRequest      = models.ApplyDeploymentRequest
+	ApplyResponse     = models.ApplyDeploymentResponse
+	StatusResponse    = models.ApplicationStatusResponse
+	Operation         = models.Operation
+	VersionDeployment = models.VersionDeployment
+)
+
+// ActionResponse 删除、扩缩容、重启等操作的响应
+type ActionResponse struct {
+	App     string `json:"app"`
+	Message string `json:"message"`
+}
+
+// DefaultLogLines 日志查询未指定行数时返回的行数
+const DefaultLogLines = 100
+
+// Response 统一响应信封，与 utils.Response 的 JSON 结构一致
+// 成功时 code 为 0、data 为具体响应；失败时 code 为 HTTP 状态码、message 为原因
+type Response struct {
+	Code    int             `json:"code"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+var (
+	// ErrAppNotFound Operator 上没有该应用（状态查询返回 404）
+	ErrAppNotFound = errors.New("application not found")
+	// ErrUnsupportedVersion Operator 不支持客户端使用的协议版本
+	ErrUnsupportedVersion = errors.New("unsupported operator api version")
+	// ErrFeatureNotSupported 请求需要 Operator 未声明的能力
+	ErrFeatureNotSupported = errors.New("feature not supported by operator")
+	// ErrInvalidPackage 部署包中有 Operator 不支持或不合法的字段
+	ErrInvalidPackage = errors.New("invalid deployment package")
+	// ErrUnknownTarget 请求的集群或命名空间不存在或不允许使用
+	ErrUnknownTarget = errors.New("unknown deployment target")
+	// ErrPlacement 无法按请求的比例和放置策略把版本分配到节点（如节点不足、固定节点未配置）
+	ErrPlacement = errors.New("cannot place versions on nodes")
+	// ErrOperationNotFound 操作不存在或已过期
+	ErrOperationNotFound = errors.New("operation not found")
+	// ErrOperationInProgress 应用已有进行中的 Apply
+	ErrOperationInProgress = errors.New("another operation is in progress")
+	// ErrNodeNotFound 节点既未配置也未注册（物理机 Operator 的节点维护接口）
+	ErrNodeNotFound = errors.New("node not found")
+	// ErrNodeConflict 注册的节点已在 node-to-ip.yaml 中配置，且不允许注册覆盖（物理机 Operator 的 Agent 注册接口）
+	ErrNodeConflict = errors.New("node is statically configured")
+)
+
+// Error Operator 返回的错误响应
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("operator returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Is 404 视为 ErrAppNotFound
+func (e *Error) Is(target error) bool {
+	return target == ErrAppNotFound && e.StatusCode == http.StatusNotFound
+}
+
+// DecodeResponse 解析信封，非 2xx 或 code 非 0 时返回 *Error，否则把 data 解码到 out（out 可为 nil）
+func DecodeResponse(resp *http.Response, out interface{}) error {
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	success := resp.StatusCode >= 200 && resp.StatusCode < 300
+	if success && len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+
+	var envelope Response
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		if !success {
+			return &Error{StatusCode: resp.StatusCode, Message: string(body)}
+		}
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+
+	if !success || envelope.Code != 0 {
+		code := resp.StatusCode
+		if success {
+			code = envelope.Code
+		}
+		return &Error{StatusCode: code, Message: envelope.Message}
+	}
+
+	if out == nil || len(envelope.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(envelope.Data, out); err != nil {
+		return fmt.Errorf("failed to decode response data: %w", err)
+	}
+	return nil
+}
+
+// ValidateApplyRequest 校验 Apply 请求：应用名必填，至少一个版本，版本不重复，Percent 在 [0,1] 且总和不超过 1
+func ValidateApplyRequest(req *ApplyRequest) error {
+	if req.App == "" {
+		return fmt.Errorf("app is required")
+	}
+	if len(req.Versions) == 0 {
+		return fmt.Errorf("at least one version is required")
+	}
+
+	seen := make(map[string]bool, len(req.Versions))
+	var total float64
+	for _, v := range req.Versions {
+		if v.Version == "" {
+			return fmt.Errorf("version is required")
+		}
+		if seen[v.Version] {
+			return fmt.Errorf("duplicate version %s", v.Version)
+		}
+		seen[v.Version] = true
+		if v.Percent < 0 || v.Percent > 1 {
+			return fmt.Errorf("percent of version %s must be between 0 and 1", v.Version)
+		}
+		total += v.Percent
+	}
+	if total > 1.0001 {
+		return fmt.Errorf("total percent %.2f exceeds 1", total)
+	}
+	return nil
+}
+
+// CheckFeatures 校验请求是否只用到了 Operator 声明的能力
+func CheckFeatures(caps *Capabilities, req *ApplyRequest) error {
+	if len(req.Versions) > 1 && !caps.Supports(FeatureMultiVersion) {
+		return fmt.Errorf("%w: %s operator cannot run %d versions at once", ErrFeatureNotSupported, caps.Type, len(req.Versions))
+	}
+	return nil
+}
+
+// RequireVersion 服务端中间件：请求头声明了不支持的协议版本时返回 400
+// 未带请求头的请求按当前版本处理
+func RequireVersion() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		version := c.GetHeader(HeaderAPIVersion)
+		if version == "" {
+			c.Next()
+			return
+		}
+		for _, v := range SupportedVersions {
+			if v == version {
+				c.Next()
+				return
+			}
+		}
+		utils.BadRequest(c, fmt.Sprintf("unsupported operator api version %q, supported: %v", version, SupportedVersions))
+		c.Abort()
+	}
+}
+
+// NewCapabilities 创建服务端能力声明
+func NewCapabilities(operatorType string, features ...Feature) *Capabilities {
+	return &Capabilities{
+		Type:        operatorType,
+		APIVersions: SupportedVersions,
+		Features:    features,
+	}
+}
+
+// StatusCode 服务端把业务错误映射为 HTTP 状态码
+func StatusCode(err error) int {
+	switch {
+	case errors.Is(err, ErrAppNotFound), errors.Is(err, ErrOperationNotFound), errors.Is(err, ErrNodeNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, ErrOperationInProgress), errors.Is(err, ErrNodeConflict):
+		return http.StatusConflict
+	case errors.Is(err, ErrFeatureNotSupported), errors.Is(err, ErrInvalidPackage), errors.Is(err, ErrUnknownTarget),
+		errors.Is(err, ErrPlacement):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
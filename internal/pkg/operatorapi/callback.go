@@ -0,0 +1,293 @@
+package operatorapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/boreas/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// CallbackEvent Operator 推送给 Master 的状态事件类型
+type CallbackEvent string
+
+const (
+	// CallbackApplyFinished Apply 已下发完成
+	CallbackApplyFinished CallbackEvent = "apply_finished"
+	// CallbackReplicaReady 有版本的健康度上升（实例就绪）
+	CallbackReplicaReady CallbackEvent = "replica_ready"
+	// CallbackInstanceUnhealthy 有版本的健康度下降（实例异常）
+	CallbackInstanceUnhealthy CallbackEvent = "instance_unhealthy"
+	// CallbackRolloutFailed 滚动更新失败（如 K8s 的 ProgressDeadlineExceeded）
+	CallbackRolloutFailed CallbackEvent = "rollout_failed"
+)
+
+// 回调签名请求头
+// 签名为 "sha256=" + hex(HMAC-SHA256(secret, timestamp + "." + body))，timestamp 为 Unix 秒
+const (
+	HeaderCallbackTimestamp = "X-Boreas-Timestamp"
+	HeaderCallbackSignature = "X-Boreas-Signature"
+)
+
+// CallbackMaxSkew 回调时间戳与 Master 时间允许的最大偏差，超出视为重放
+const CallbackMaxSkew = 5 * time.Minute
+
+// ErrInvalidSignature 回调签名缺失、错误或已过期
+var ErrInvalidSignature = errors.New("invalid callback signature")
+
+// StatusCallback Operator 推送给 Master 的状态回调
+type StatusCallback struct {
+	App     string          `json:"app"`
+	Event   CallbackEvent   `json:"event"`
+	Message string          `json:"message,omitempty"`
+	Time    time.Time       `json:"time"`
+	Status  *StatusResponse `json:"status,omitempty"` // 事件发生后应用的最新状态
+}
+
+// SignCallback 计算回调签名
+func SignCallback(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyCallback 校验回调签名和时间戳，失败时返回 ErrInvalidSignature
+func VerifyCallback(secret, timestamp, signature string, body []byte, now time.Time) error {
+	if secret == "" || signature == "" {
+		return ErrInvalidSignature
+	}
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid timestamp", ErrInvalidSignature)
+	}
+	if skew := now.Sub(time.Unix(ts, 0)); skew > CallbackMaxSkew || skew < -CallbackMaxSkew {
+		return fmt.Errorf("%w: timestamp expired", ErrInvalidSignature)
+	}
+	if !hmac.Equal([]byte(signature), []byte(SignCallback(secret, ts, body))) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// CallbackConfig Operator 的回调配置
+type CallbackConfig struct {
+	URL      string `mapstructure:"url"`      // Master 回调地址，如 http://master:8080/api/v1/operators/<环境>/callback，为空时不推送
+	Secret   string `mapstructure:"secret"`   // 签名密钥，与 Master 中该环境的 callback_secret 一致
+	Interval int    `mapstructure:"interval"` // Apply 后跟踪状态变化的间隔（秒）
+	Timeout  int    `mapstructure:"timeout"`  // Apply 后跟踪状态的最长时间（秒）
+}
+
+// StatusFunc 查询应用当前状态，Notifier 用它跟踪 Apply 后的状态变化
+type StatusFunc func(ctx context.Context, app string) (*StatusResponse, error)
+
+// Notifier 向 Master 推送签名的状态回调
+// Apply 完成后推送 apply_finished，并在后台按间隔查询状态，健康度变化时推送 replica_ready/instance_unhealthy，
+// 所有版本都完全健康或超时后停止跟踪。nil 或未配置 URL 的 Notifier 不做任何事
+type Notifier struct {
+	cfg        CallbackConfig
+	status     StatusFunc
+	httpClient *http.Client
+
+	mu       sync.Mutex
+	tracking map[string]*tracker // key: app
+}
+
+// tracker 一次 Apply 后的状态跟踪
+type tracker struct {
+	cancel context.CancelFunc
+}
+
+// NewNotifier 创建回调推送器，cfg.URL 为空时返回 nil
+func NewNotifier(cfg CallbackConfig, status StatusFunc) *Notifier {
+	if cfg.URL == "" {
+		return nil
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = 5
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = 600
+	}
+	return &Notifier{
+		cfg:        cfg,
+		status:     status,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		tracking:   make(map[string]*tracker),
+	}
+}
+
+// Notify 推送一条回调
+func (n *Notifier) Notify(ctx context.Context, cb *StatusCallback) error {
+	if n == nil {
+		return nil
+	}
+	if cb.Time.IsZero() {
+		cb.Time = time.Now()
+	}
+	body, err := json.Marshal(cb)
+	if err != nil {
+		return fmt.Errorf("failed to marshal callback: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.cfg.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create callback request: %w", err)
+	}
+	ts := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderAPIVersion, Version)
+	req.Header.Set(HeaderCallbackTimestamp, strconv.FormatInt(ts, 10))
+	req.Header.Set(HeaderCallbackSignature, SignCallback(n.cfg.Secret, ts, body))
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send callback: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return DecodeResponse(resp, nil)
+}
+
+// ApplyFinished 在后台推送 apply_finished 并开始跟踪应用状态，同一应用的上一次跟踪会被取消
+func (n *Notifier) ApplyFinished(app, message string) {
+	if n == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(n.cfg.Timeout)*time.Second)
+	t := &tracker{cancel: cancel}
+	n.mu.Lock()
+	if prev, ok := n.tracking[app]; ok {
+		prev.cancel()
+	}
+	n.tracking[app] = t
+	n.mu.Unlock()
+
+	go n.track(ctx, t, app, message)
+}
+
+// Stop 停止所有跟踪
+func (n *Notifier) Stop() {
+	if n == nil {
+		return
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	for app, t := range n.tracking {
+		t.cancel()
+		delete(n.tracking, app)
+	}
+}
+
+func (n *Notifier) track(ctx context.Context, t *tracker, app, message string) {
+	defer func() {
+		t.cancel()
+		n.mu.Lock()
+		// 只清理自己的记录，已被新的 Apply 替换时保留
+		if n.tracking[app] == t {
+			delete(n.tracking, app)
+		}
+		n.mu.Unlock()
+	}()
+
+	last := n.currentStatus(ctx, app)
+	n.send(ctx, &StatusCallback{App: app, Event: CallbackApplyFinished, Message: message, Status: last})
+	if n.status == nil {
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(n.cfg.Interval) * time.Second)
+	defer ticker.Stop()
+	for !fullyHealthy(last) {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		status, err := n.status(ctx, app)
+		if err != nil {
+			if errors.Is(err, ErrAppNotFound) {
+				return
+			}
+			continue
+		}
+		if event, ok := classifyChange(last, status); ok {
+			n.send(ctx, &StatusCallback{App: app, Event: event, Status: status})
+		}
+		last = status
+	}
+}
+
+func (n *Notifier) currentStatus(ctx context.Context, app string) *StatusResponse {
+	if n.status == nil {
+		return nil
+	}
+	status, err := n.status(ctx, app)
+	if err != nil {
+		return nil
+	}
+	return status
+}
+
+func (n *Notifier) send(ctx context.Context, cb *StatusCallback) {
+	if err := n.Notify(ctx, cb); err != nil {
+		logger.GetLogger().Warn("Failed to push status callback",
+			zap.String("app", cb.App),
+			zap.String("event", string(cb.Event)),
+			zap.Error(err))
+	}
+}
+
+// classifyChange 比较前后两次状态：任一版本健康度下降视为 instance_unhealthy，否则有上升视为 replica_ready
+func classifyChange(prev, cur *StatusResponse) (CallbackEvent, bool) {
+	if cur == nil {
+		return "", false
+	}
+	prevLevels := make(map[string]int)
+	if prev != nil {
+		for _, v := range prev.Versions {
+			prevLevels[v.Version] = v.Healthy.Level
+		}
+	}
+
+	var rose bool
+	for _, v := range cur.Versions {
+		before, ok := prevLevels[v.Version]
+		switch {
+		case ok && v.Healthy.Level < before:
+			return CallbackInstanceUnhealthy, true
+		case v.Healthy.Level > before:
+			rose = true
+		}
+	}
+	if rose {
+		return CallbackReplicaReady, true
+	}
+	return "", false
+}
+
+// fullyHealthy 所有版本的健康度都为 100
+func fullyHealthy(status *StatusResponse) bool {
+	if status == nil || len(status.Versions) == 0 {
+		return false
+	}
+	for _, v := range status.Versions {
+		if v.Healthy.Level < 100 {
+			return false
+		}
+	}
+	return true
+}
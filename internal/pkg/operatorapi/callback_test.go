@@ -0,0 +1,85 @@
+package operatorapi
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+func TestVerifyCallback(t *testing.T) {
+	body := []byte(`{"app":"demo"}`)
+	now := time.Now()
+
+	cases := []struct {
+		name      string
+		secret    string
+		timestamp int64
+		body      []byte
+		ok        bool
+	}{
+		{"valid", "secret", now.Unix(), body, true},
+		{"wrong secret", "other", now.Unix(), body, false},
+		{"tampered body", "secret", now.Unix(), []byte(`{"app":"evil"}`), false},
+		{"expired", "secret", now.Add(-2 * CallbackMaxSkew).Unix(), body, false},
+	}
+	for _, c := range cases {
+		sig := SignCallback("secret", c.timestamp, body)
+		err := VerifyCallback(c.secret, strconv.FormatInt(c.timestamp, 10), sig, c.body, now)
+		if (err == nil) != c.ok {
+			t.Errorf("%s: err = %v", c.name, err)
+		}
+	}
+}
+
+func TestNotifier_PushesSignedEvents(t *testing.T) {
+	events := make(chan StatusCallback, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if err := VerifyCallback("secret", r.Header.Get(HeaderCallbackTimestamp), r.Header.Get(HeaderCallbackSignature), body, time.Now()); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var cb StatusCallback
+		_ = json.Unmarshal(body, &cb)
+		events <- cb
+	}))
+	defer srv.Close()
+
+	levels := []int{50, 50, 100}
+	calls := 0
+	status := func(ctx context.Context, app string) (*StatusResponse, error) {
+		level := levels[len(levels)-1]
+		if calls < len(levels) {
+			level = levels[calls]
+		}
+		calls++
+		return &StatusResponse{App: app, Versions: []models.VersionStatus{{Version: "v1", Healthy: models.HealthInfo{Level: level}}}}, nil
+	}
+
+	n := NewNotifier(CallbackConfig{URL: srv.URL, Secret: "secret", Interval: 1, Timeout: 10}, status)
+	defer n.Stop()
+	n.ApplyFinished("demo", "applied")
+
+	want := []CallbackEvent{CallbackApplyFinished, CallbackReplicaReady}
+	for _, w := range want {
+		select {
+		case cb := <-events:
+			if cb.Event != w || cb.App != "demo" {
+				t.Fatalf("got %s for %s, want %s", cb.Event, cb.App, w)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", w)
+		}
+	}
+
+	if NewNotifier(CallbackConfig{}, status) != nil {
+		t.Fatal("notifier without URL should be nil")
+	}
+}
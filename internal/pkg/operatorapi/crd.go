@@ -0,0 +1,123 @@
+package operatorapi
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/boreas/internal/pkg/models"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// BoreasApplication 自定义资源：K8s Operator 以控制器模式运行时，Master 写入该资源代替调用 /v1/apply，
+// Operator 持续调和资源与集群中的对象，并把状态写回 .status。CRD 定义见 deployments/k8s/boreasapplication-crd.yaml
+const (
+	CRDGroup    = "boreas.io"
+	CRDVersion  = "v1alpha1"
+	CRDKind     = "BoreasApplication"
+	CRDResource = "boreasapplications"
+
+	// CRDFinalizer 删除资源前由控制器清理应用的所有对象
+	CRDFinalizer = "boreas.io/cleanup"
+)
+
+// ApplicationResource BoreasApplication 的 GroupVersionResource
+var ApplicationResource = schema.GroupVersionResource{Group: CRDGroup, Version: CRDVersion, Resource: CRDResource}
+
+// 调和阶段
+const (
+	PhaseProgressing = "Progressing" // 已下发，实例尚未全部就绪
+	PhaseReady       = "Ready"       // 所有实例就绪
+	PhaseFailed      = "Failed"      // 下发失败，见 message
+)
+
+// ApplicationSpec BoreasApplication 的 .spec，与 Apply 请求的版本列表相同
+type ApplicationSpec struct {
+	App      string                     `json:"app,omitempty"` // 应用名，为空时使用资源名
+	Versions []models.VersionDeployment `json:"versions"`
+}
+
+// ApplicationCRStatus BoreasApplication 的 .status
+type ApplicationCRStatus struct {
+	ObservedGeneration int64                  `json:"observedGeneration,omitempty"` // 最近一次调和的 .metadata.generation
+	Phase              string                 `json:"phase,omitempty"`
+	Message            string                 `json:"message,omitempty"`
+	Healthy            models.HealthInfo      `json:"healthy"`
+	Versions           []models.VersionStatus `json:"versions,omitempty"`
+}
+
+// NewApplicationObject 创建 BoreasApplication 对象
+func NewApplicationObject(name, namespace string, spec *ApplicationSpec) (*unstructured.Unstructured, error) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion(CRDGroup + "/" + CRDVersion)
+	obj.SetKind(CRDKind)
+	obj.SetName(name)
+	obj.SetNamespace(namespace)
+	if err := SetApplicationSpec(obj, spec); err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// ApplicationSpecOf 读取资源的 .spec，App 为空时使用资源名
+func ApplicationSpecOf(obj *unstructured.Unstructured) (*ApplicationSpec, error) {
+	spec := &ApplicationSpec{}
+	if err := fromField(obj, "spec", spec); err != nil {
+		return nil, err
+	}
+	if spec.App == "" {
+		spec.App = obj.GetName()
+	}
+	return spec, nil
+}
+
+// SetApplicationSpec 写入资源的 .spec
+func SetApplicationSpec(obj *unstructured.Unstructured, spec *ApplicationSpec) error {
+	return toField(obj, "spec", spec)
+}
+
+// ApplicationStatusOf 读取资源的 .status，没有状态时返回零值
+func ApplicationStatusOf(obj *unstructured.Unstructured) (*ApplicationCRStatus, error) {
+	status := &ApplicationCRStatus{}
+	if err := fromField(obj, "status", status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// SetApplicationStatus 写入资源的 .status
+func SetApplicationStatus(obj *unstructured.Unstructured, status *ApplicationCRStatus) error {
+	return toField(obj, "status", status)
+}
+
+// fromField 和 toField 通过 JSON 在结构体与 unstructured 字段间转换，数值类型与 API Server 返回的一致
+func fromField(obj *unstructured.Unstructured, field string, out interface{}) error {
+	value, ok := obj.Object[field]
+	if !ok || value == nil {
+		return nil
+	}
+	data, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("invalid %s of %s: %w", field, obj.GetName(), err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("invalid %s of %s: %w", field, obj.GetName(), err)
+	}
+	return nil
+}
+
+func toField(obj *unstructured.Unstructured, field string, in interface{}) error {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", field, err)
+	}
+	var value map[string]interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return fmt.Errorf("failed to marshal %s: %w", field, err)
+	}
+	if obj.Object == nil {
+		obj.Object = map[string]interface{}{}
+	}
+	obj.Object[field] = value
+	return nil
+}
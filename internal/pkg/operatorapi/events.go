@@ -0,0 +1,52 @@
+package operatorapi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+// EventLog 按应用保存最近事件的内存环形缓冲，供没有原生事件源的 Operator（physical/mock）使用
+type EventLog struct {
+	max int
+
+	mu     sync.Mutex
+	events map[string][]models.OperatorEvent
+}
+
+// NewEventLog 创建事件缓冲，max 为每个应用保留的事件数，<=0 时为 100
+func NewEventLog(max int) *EventLog {
+	if max <= 0 {
+		max = 100
+	}
+	return &EventLog{
+		max:    max,
+		events: make(map[string][]models.OperatorEvent),
+	}
+}
+
+// Record 记录一条事件
+func (l *EventLog) Record(app, eventType, reason, object, message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	events := append(l.events[app], models.OperatorEvent{
+		Time:    time.Now(),
+		Type:    eventType,
+		Reason:  reason,
+		Object:  object,
+		Message: message,
+	})
+	if len(events) > l.max {
+		events = events[len(events)-l.max:]
+	}
+	l.events[app] = events
+}
+
+// List 返回应用的事件（按时间先后）
+func (l *EventLog) List(app string) []models.OperatorEvent {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]models.OperatorEvent{}, l.events[app]...)
+}
@@ -0,0 +1,301 @@
+// Package operatorapitest 提供 Operator 协议的一致性测试，任何 Operator 实现都应通过
+//
+// 用法：在 Operator 的测试中用 httptest 启动服务，然后调用 Run：
+//
+//	srv := httptest.NewServer(router)
+//	defer srv.Close()
+//	operatorapitest.Run(t, operatorapitest.Target{BaseURL: srv.URL, App: "demo", Package: pkg})
+package operatorapitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+)
+
+// Target 被测 Operator
+type Target struct {
+	BaseURL string                   // Operator 地址
+	App     string                   // 可在该 Operator 上部署的应用
+	Package models.DeploymentPackage // 该 Operator 能接受的部署包
+	// UnknownApp 不存在的应用名，默认 "conformance-unknown-app"
+	UnknownApp string
+}
+
+// Run 依次执行全部一致性检查，可选能力只在 Operator 声明时检查
+// 检查会在 Operator 上部署、重启并最终删除 target.App
+func Run(t *testing.T, target Target) {
+	t.Helper()
+	if target.UnknownApp == "" {
+		target.UnknownApp = "conformance-unknown-app"
+	}
+	c := &checker{target: target, client: &http.Client{}}
+
+	var caps operatorapi.Capabilities
+	t.Run("capabilities", func(t *testing.T) {
+		resp := c.do(t, http.MethodGet, operatorapi.PathCapabilities, nil, nil)
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, &caps)
+		if caps.Type == "" {
+			t.Error("capabilities.type is empty")
+		}
+		if !caps.SupportsVersion(operatorapi.Version) {
+			t.Errorf("capabilities.api_versions %v does not include %s", caps.APIVersions, operatorapi.Version)
+		}
+	})
+
+	t.Run("health", func(t *testing.T) {
+		resp := c.do(t, http.MethodGet, operatorapi.PathHealth, nil, nil)
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, nil)
+	})
+
+	t.Run("rejects unsupported api version", func(t *testing.T) {
+		header := http.Header{operatorapi.HeaderAPIVersion: []string{"v0"}}
+		resp := c.do(t, http.MethodGet, operatorapi.PathCapabilities, nil, header)
+		expectStatus(t, resp, http.StatusBadRequest)
+		expectError(t, resp)
+	})
+
+	t.Run("rejects invalid apply", func(t *testing.T) {
+		invalid := []interface{}{
+			map[string]interface{}{"versions": []interface{}{}},
+			operatorapi.ApplyRequest{App: target.App},
+			operatorapi.ApplyRequest{App: target.App, Versions: []operatorapi.VersionDeployment{
+				{Version: "v1", Percent: 1.5, Package: target.Package},
+			}},
+		}
+		for _, body := range invalid {
+			resp := c.do(t, http.MethodPost, operatorapi.PathApply, body, nil)
+			expectStatus(t, resp, http.StatusBadRequest)
+			expectError(t, resp)
+		}
+	})
+
+	t.Run("apply and status", func(t *testing.T) {
+		req := operatorapi.ApplyRequest{App: target.App, Versions: []operatorapi.VersionDeployment{
+			{Version: "conformance-v1", Percent: 1, Package: target.Package},
+		}}
+		var applied operatorapi.ApplyResponse
+		resp := c.do(t, http.MethodPost, operatorapi.PathApply, req, nil)
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, &applied)
+		if applied.App != target.App || !applied.Success {
+			t.Fatalf("apply response = %+v", applied)
+		}
+
+		status := c.status(t, target.App)
+		if caps.Supports(operatorapi.FeatureVersionStatus) {
+			expectVersions(t, status, "conformance-v1")
+		}
+	})
+
+	t.Run("multiple versions", func(t *testing.T) {
+		req := operatorapi.ApplyRequest{App: target.App, Versions: []operatorapi.VersionDeployment{
+			{Version: "conformance-v1", Percent: 0.5, Package: target.Package},
+			{Version: "conformance-v2", Percent: 0.5, Package: target.Package},
+		}}
+		resp := c.do(t, http.MethodPost, operatorapi.PathApply, req, nil)
+		if !caps.Supports(operatorapi.FeatureMultiVersion) {
+			// 未声明多版本能力的 Operator 必须显式拒绝，而不是只部署其中一个版本
+			expectStatus(t, resp, http.StatusBadRequest)
+			expectError(t, resp)
+			return
+		}
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, nil)
+
+		status := c.status(t, target.App)
+		if caps.Supports(operatorapi.FeatureVersionStatus) {
+			expectVersions(t, status, "conformance-v1", "conformance-v2")
+		}
+	})
+
+	t.Run("restart", func(t *testing.T) {
+		resp := c.do(t, http.MethodPost, operatorapi.PathRestart, models.RestartRequest{App: target.App}, nil)
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, nil)
+
+		resp = c.do(t, http.MethodPost, operatorapi.PathRestart, models.RestartRequest{}, nil)
+		expectStatus(t, resp, http.StatusBadRequest)
+		expectError(t, resp)
+	})
+
+	t.Run("scale", func(t *testing.T) {
+		resp := c.do(t, http.MethodPost, operatorapi.PathScale, models.ScaleRequest{App: target.App, Replicas: 2}, nil)
+		if !caps.Supports(operatorapi.FeatureScale) {
+			expectStatus(t, resp, http.StatusBadRequest)
+			expectError(t, resp)
+			return
+		}
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, nil)
+	})
+
+	t.Run("logs", func(t *testing.T) {
+		var logs models.LogsResponse
+		resp := c.do(t, http.MethodGet, operatorapi.PathLogs+target.App+"?lines=10", nil, nil)
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, &logs)
+		if logs.App != target.App {
+			t.Errorf("logs.app = %q, want %q", logs.App, target.App)
+		}
+		for _, instance := range logs.Instances {
+			if len(instance.Lines) > 10 {
+				t.Errorf("instance %s returned %d lines, want at most 10", instance.Node, len(instance.Lines))
+			}
+		}
+	})
+
+	t.Run("events", func(t *testing.T) {
+		var events models.EventsResponse
+		resp := c.do(t, http.MethodGet, operatorapi.PathEvents+target.App, nil, nil)
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, &events)
+		if events.App != target.App {
+			t.Errorf("events.app = %q, want %q", events.App, target.App)
+		}
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		resp := c.do(t, http.MethodDelete, operatorapi.PathApps+target.App, nil, nil)
+		expectStatus(t, resp, http.StatusOK)
+		expectEnvelope(t, resp, nil)
+
+		if caps.Supports(operatorapi.FeatureVersionStatus) {
+			// 删除后应用不再有任何运行中的版本
+			resp := c.do(t, http.MethodGet, operatorapi.PathStatus+target.App, nil, nil)
+			if resp.StatusCode != http.StatusNotFound {
+				var status operatorapi.StatusResponse
+				expectEnvelope(t, resp, &status)
+				if len(status.Versions) != 0 {
+					t.Errorf("status after delete = %+v, want 404 or no versions", status)
+				}
+			}
+		}
+
+		resp = c.do(t, http.MethodDelete, operatorapi.PathApps+target.UnknownApp, nil, nil)
+		expectStatus(t, resp, http.StatusNotFound)
+		expectError(t, resp)
+	})
+
+	t.Run("status of unknown app", func(t *testing.T) {
+		resp := c.do(t, http.MethodGet, operatorapi.PathStatus+target.UnknownApp, nil, nil)
+		expectStatus(t, resp, http.StatusNotFound)
+		expectError(t, resp)
+	})
+}
+
+type checker struct {
+	target Target
+	client *http.Client
+}
+
+// response 已读取 Body 的响应，便于多次解析
+type response struct {
+	*http.Response
+	body []byte
+}
+
+func (c *checker) do(t *testing.T, method, path string, body interface{}, header http.Header) *response {
+	t.Helper()
+	var reader *bytes.Reader
+	if body != nil {
+		bs, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("marshal request: %v", err)
+		}
+		reader = bytes.NewReader(bs)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+
+	req, err := http.NewRequest(method, strings.TrimSuffix(c.target.BaseURL, "/")+path, reader)
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	var buf bytes.Buffer
+	if _, err := buf.ReadFrom(resp.Body); err != nil {
+		t.Fatalf("read response: %v", err)
+	}
+	return &response{Response: resp, body: buf.Bytes()}
+}
+
+func (c *checker) status(t *testing.T, app string) *operatorapi.StatusResponse {
+	t.Helper()
+	var status operatorapi.StatusResponse
+	resp := c.do(t, http.MethodGet, operatorapi.PathStatus+app, nil, nil)
+	expectStatus(t, resp, http.StatusOK)
+	expectEnvelope(t, resp, &status)
+	if status.App != app {
+		t.Errorf("status.app = %q, want %q", status.App, app)
+	}
+	if status.Healthy.Level < 0 || status.Healthy.Level > 100 {
+		t.Errorf("status.healthy.level = %d, want 0-100", status.Healthy.Level)
+	}
+	return &status
+}
+
+// reader 返回 Body 可重新读取的响应副本
+func (r *response) reader() *http.Response {
+	clone := *r.Response
+	clone.Body = io.NopCloser(bytes.NewReader(r.body))
+	return &clone
+}
+
+func expectStatus(t *testing.T, resp *response, want int) {
+	t.Helper()
+	if resp.StatusCode != want {
+		t.Fatalf("%s %s: status %d, want %d, body: %s", resp.Request.Method, resp.Request.URL.Path, resp.StatusCode, want, resp.body)
+	}
+}
+
+func expectEnvelope(t *testing.T, resp *response, out interface{}) {
+	t.Helper()
+	if err := operatorapi.DecodeResponse(resp.reader(), out); err != nil {
+		t.Fatalf("%s: %v, body: %s", resp.Request.URL.Path, err, resp.body)
+	}
+}
+
+func expectError(t *testing.T, resp *response) {
+	t.Helper()
+	var envelope operatorapi.Response
+	if err := json.Unmarshal(resp.body, &envelope); err != nil {
+		t.Fatalf("%s: error response is not an envelope: %s", resp.Request.URL.Path, resp.body)
+	}
+	if envelope.Code == 0 || envelope.Message == "" {
+		t.Errorf("%s: error envelope = %+v, want non-zero code and message", resp.Request.URL.Path, envelope)
+	}
+}
+
+func expectVersions(t *testing.T, status *operatorapi.StatusResponse, want ...string) {
+	t.Helper()
+	got := make(map[string]bool, len(status.Versions))
+	for _, v := range status.Versions {
+		got[v.Version] = true
+	}
+	for _, v := range want {
+		if !got[v] {
+			t.Errorf("status.versions = %+v, missing %s", status.Versions, v)
+		}
+	}
+}
@@ -32,6 +32,15 @@ func Created(c *gin.Context, data interface{}) {
 	})
 }
 
+// Accepted 已接受、在后台处理的响应
+func Accepted(c *gin.Context, data interface{}) {
+	c.JSON(http.StatusAccepted, Response{
+		Code:    0,
+		Message: "accepted",
+		Data:    data,
+	})
+}
+
 // Error 错误响应
 func Error(c *gin.Context, code int, message string) {
 	c.JSON(code, Response{
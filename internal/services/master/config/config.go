@@ -66,9 +66,23 @@ type K8sConfig struct {
 
 // TriggerConfig 触发器配置
 type TriggerConfig struct {
-	WebhookSecret  string `mapstructure:"webhook_secret"`
-	WorkDir        string `mapstructure:"work_dir"`
-	DockerRegistry string `mapstructure:"docker_registry"`
+	WebhookSecret    string `mapstructure:"webhook_secret"`
+	WorkDir          string `mapstructure:"work_dir"`
+	DockerRegistry   string `mapstructure:"docker_registry"`
+	BuildConcurrency int    `mapstructure:"build_concurrency"` // 并行构建的应用数
+	BuildTimeout     int    `mapstructure:"build_timeout"`     // 单次 tag 构建的总超时（秒）
+	AppBuildTimeout  int    `mapstructure:"app_build_timeout"` // 单个应用构建+推送的超时（秒）
+
+	Registries   []RegistryAuthConfig `mapstructure:"registries"`    // 按仓库主机配置的推送凭证
+	DockerConfig string               `mapstructure:"docker_config"` // Docker config.json 路径，支持 credHelpers/credsStore
+}
+
+// RegistryAuthConfig 镜像仓库凭证配置
+type RegistryAuthConfig struct {
+	Host          string `mapstructure:"host"`
+	Username      string `mapstructure:"username"`
+	Password      string `mapstructure:"password"`
+	IdentityToken string `mapstructure:"identity_token"`
 }
 
 // OperatorConfig Operator 配置
@@ -76,6 +90,14 @@ type OperatorConfig struct {
 	K8SOperatorURL string `mapstructure:"k8s_operator_url"`
 	PMOperatorURL  string `mapstructure:"pm_operator_url"`
 	UseMock        bool   `mapstructure:"use_mock"`
+
+	RetryMaxAttempts        int `mapstructure:"retry_max_attempts"`        // 幂等请求的最大尝试次数
+	CircuitFailureThreshold int `mapstructure:"circuit_failure_threshold"` // 连续失败多少次后熔断
+	CircuitOpenTimeout      int `mapstructure:"circuit_open_timeout"`      // 熔断后多久尝试恢复（秒）
+	HealthCheckInterval     int `mapstructure:"health_check_interval"`     // 后台健康检查间隔（秒）
+
+	CallbackSecret string `mapstructure:"callback_secret"`  // 校验 Operator 状态回调签名的默认密钥，环境可用 callback_secret 覆盖
+	StatusCacheTTL int    `mapstructure:"status_cache_ttl"` // 应用状态缓存有效期（秒）
 }
 
 // Load 加载配置
@@ -187,11 +209,21 @@ func setDefaults() {
 	viper.SetDefault("trigger.webhook_secret", "")
 	viper.SetDefault("trigger.work_dir", "/var/lib/boreas")
 	viper.SetDefault("trigger.docker_registry", "")
+	viper.SetDefault("trigger.build_concurrency", 4)
+	viper.SetDefault("trigger.build_timeout", 3600)
+	viper.SetDefault("trigger.app_build_timeout", 1200)
+	viper.SetDefault("trigger.docker_config", "")
 
 	// Operator配置
 	viper.SetDefault("operator.k8s_operator_url", "http://localhost:8081")
 	viper.SetDefault("operator.pm_operator_url", "http://localhost:8082")
 	viper.SetDefault("operator.use_mock", false)
+	viper.SetDefault("operator.retry_max_attempts", 3)
+	viper.SetDefault("operator.circuit_failure_threshold", 5)
+	viper.SetDefault("operator.circuit_open_timeout", 30)
+	viper.SetDefault("operator.health_check_interval", 15)
+	viper.SetDefault("operator.callback_secret", "")
+	viper.SetDefault("operator.status_cache_ttl", 10)
 }
 
 // overrideFromEnv 从环境变量覆盖配置
@@ -283,6 +315,11 @@ func overrideFromEnv(cfg *Config) {
 	if registry := os.Getenv("MASTER_TRIGGER_DOCKER_REGISTRY"); registry != "" {
 		cfg.Trigger.DockerRegistry = registry
 	}
+	if concurrency := os.Getenv("MASTER_TRIGGER_BUILD_CONCURRENCY"); concurrency != "" {
+		if c, err := strconv.Atoi(concurrency); err == nil {
+			cfg.Trigger.BuildConcurrency = c
+		}
+	}
 }
 
 // GetDSN 获取数据库连接字符串
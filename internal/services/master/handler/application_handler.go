@@ -1,12 +1,16 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/boreas/internal/interfaces"
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/pkg/utils"
+	"github.com/boreas/internal/services/master/service"
 	"github.com/gin-gonic/gin"
 )
 
@@ -35,7 +39,7 @@ func (h *applicationHandler) CreateApplication(c *gin.Context) {
 		return
 	}
 
-	utils.Created(c, application)
+	utils.Created(c, application.Redacted())
 }
 
 // GetApplicationList 获取应用列表
@@ -64,7 +68,12 @@ func (h *applicationHandler) GetApplicationList(c *gin.Context) {
 		return
 	}
 
-	utils.Success(c, response)
+	redacted := *response
+	redacted.Applications = make([]*models.Application, len(response.Applications))
+	for i, app := range response.Applications {
+		redacted.Applications[i] = app.Redacted()
+	}
+	utils.Success(c, &redacted)
 }
 
 // GetApplication 获取应用详情（按应用名称查询）
@@ -82,7 +91,7 @@ func (h *applicationHandler) GetApplication(c *gin.Context) {
 		return
 	}
 
-	utils.Success(c, application)
+	utils.Success(c, application.Redacted())
 }
 
 // UpdateApplication 更新应用
@@ -105,7 +114,7 @@ func (h *applicationHandler) UpdateApplication(c *gin.Context) {
 		return
 	}
 
-	utils.Success(c, application)
+	utils.Success(c, application.Redacted())
 }
 
 // DeleteApplication 删除应用
@@ -181,3 +190,105 @@ func (h *applicationHandler) GetApplicationVersionCoverage(c *gin.Context) {
 
 	utils.Success(c, response)
 }
+
+// GetApplicationStatus 获取应用在指定环境的状态
+func (h *applicationHandler) GetApplicationStatus(c *gin.Context) {
+	response, err := h.applicationService.GetApplicationStatus(c.Request.Context(), c.Param("name"), c.Param("env"))
+	if err != nil {
+		h.handleOperationError(c, "GET_APPLICATION_STATUS_FAILED", err)
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// ScaleApplication 调整应用在指定环境的副本数
+func (h *applicationHandler) ScaleApplication(c *gin.Context) {
+	var req models.ScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ValidationError(c, err)
+		return
+	}
+
+	name := c.Param("name")
+	if err := h.applicationService.ScaleApplication(c.Request.Context(), name, c.Param("env"), &req); err != nil {
+		h.handleOperationError(c, "APPLICATION_SCALE_FAILED", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": fmt.Sprintf("Application %s scaled to %d replicas", name, req.Replicas)})
+}
+
+// RestartApplication 重启应用在指定环境的实例，version 为空时重启所有版本
+func (h *applicationHandler) RestartApplication(c *gin.Context) {
+	var req models.RestartRequest
+	// 请求体可选
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ValidationError(c, err)
+			return
+		}
+	}
+
+	name := c.Param("name")
+	if err := h.applicationService.RestartApplication(c.Request.Context(), name, c.Param("env"), &req); err != nil {
+		h.handleOperationError(c, "APPLICATION_RESTART_FAILED", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": fmt.Sprintf("Application %s restart triggered", name)})
+}
+
+// GetApplicationLogs 获取应用在指定环境的实例日志，支持 version、node、lines 查询参数
+func (h *applicationHandler) GetApplicationLogs(c *gin.Context) {
+	var req models.LogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.ValidationError(c, err)
+		return
+	}
+
+	response, err := h.applicationService.GetApplicationLogs(c.Request.Context(), c.Param("name"), c.Param("env"), &req)
+	if err != nil {
+		h.handleOperationError(c, "GET_APPLICATION_LOGS_FAILED", err)
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// GetApplicationEvents 获取应用在指定环境的事件
+func (h *applicationHandler) GetApplicationEvents(c *gin.Context) {
+	response, err := h.applicationService.GetApplicationEvents(c.Request.Context(), c.Param("name"), c.Param("env"))
+	if err != nil {
+		h.handleOperationError(c, "GET_APPLICATION_EVENTS_FAILED", err)
+		return
+	}
+
+	utils.Success(c, response)
+}
+
+// UndeployApplication 删除应用在指定环境运行的所有版本
+func (h *applicationHandler) UndeployApplication(c *gin.Context) {
+	// 与 DELETE /applications/:id 共用路由参数名，这里的 id 是应用名称
+	name := c.Param("id")
+	if err := h.applicationService.UndeployApplication(c.Request.Context(), name, c.Param("env")); err != nil {
+		h.handleOperationError(c, "APPLICATION_UNDEPLOY_FAILED", err)
+		return
+	}
+
+	utils.Success(c, gin.H{"message": fmt.Sprintf("Application %s removed from environment %s", name, c.Param("env"))})
+}
+
+// handleOperationError 把运维操作的错误映射为 HTTP 状态码
+func (h *applicationHandler) handleOperationError(c *gin.Context, code string, err error) {
+	switch {
+	case errors.Is(err, service.ErrApplicationNotFound), errors.Is(err, operatorapi.ErrAppNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "APPLICATION_NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, service.ErrEnvironmentNotAssociated):
+		utils.ErrorResponse(c, http.StatusNotFound, "ENVIRONMENT_NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, operatorapi.ErrFeatureNotSupported):
+		utils.ErrorResponse(c, http.StatusBadRequest, "OPERATION_NOT_SUPPORTED", err.Error(), nil)
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, code, err.Error(), nil)
+	}
+}
@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/utils"
+	"github.com/boreas/internal/services/master/service"
+	"github.com/gin-gonic/gin"
+)
+
+type buildLogHandler struct {
+	store *service.BuildLogStore
+}
+
+// NewBuildLogHandler 创建构建日志处理器
+func NewBuildLogHandler(store *service.BuildLogStore) *buildLogHandler {
+	return &buildLogHandler{
+		store: store,
+	}
+}
+
+// GetBuildLogs 获取应用在指定版本的构建日志，version 为版本 ID；
+// 版本创建前（构建进行中或所有应用构建失败）日志以本次触发的构建 ID（build-<uuid>）作为 version 提供
+// follow=true 时以 application/x-ndjson 逐行输出，构建进行中会持续推送新日志直到构建结束
+func (h *buildLogHandler) GetBuildLogs(c *gin.Context) {
+	version := c.Param("version")
+	app := c.Param("app")
+	if version == "" || app == "" {
+		utils.BadRequest(c, "version and app are required")
+		return
+	}
+
+	if c.Query("follow") != "true" {
+		entries, running, err := h.store.Read(version, app)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+		utils.Success(c, models.BuildLogResponse{
+			Version: version,
+			AppName: app,
+			Running: running,
+			Entries: entries,
+		})
+		return
+	}
+
+	history, ch, cancel, err := h.store.Subscribe(version, app)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	defer cancel()
+
+	c.Header("Content-Type", "application/x-ndjson")
+	c.Header("Cache-Control", "no-cache")
+	c.Status(http.StatusOK)
+
+	enc := json.NewEncoder(c.Writer)
+	for _, entry := range history {
+		if err := enc.Encode(entry); err != nil {
+			return
+		}
+	}
+	c.Writer.Flush()
+	if ch == nil {
+		return
+	}
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case entry, ok := <-ch:
+			if !ok {
+				return false
+			}
+			return enc.Encode(entry) == nil
+		case <-c.Request.Context().Done():
+			return false
+		}
+	})
+}
+
+func (h *buildLogHandler) handleError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrBuildLogNotFound):
+		utils.ErrorResponse(c, http.StatusNotFound, "BUILD_LOG_NOT_FOUND", err.Error(), nil)
+	case errors.Is(err, service.ErrInvalidBuildLogName):
+		utils.BadRequest(c, err.Error())
+	default:
+		utils.ErrorResponse(c, http.StatusInternalServerError, "BUILD_LOG_READ_FAILED", err.Error(), nil)
+	}
+}
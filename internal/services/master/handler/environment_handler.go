@@ -35,7 +35,7 @@ func (h *environmentHandler) CreateEnvironment(c *gin.Context) {
 		return
 	}
 
-	utils.Created(c, environment)
+	utils.Created(c, environment.Redacted())
 }
 
 // GetEnvironmentList 获取环境列表
@@ -71,10 +71,16 @@ func (h *environmentHandler) GetEnvironmentList(c *gin.Context) {
 		return
 	}
 
-	utils.Success(c, response)
+	redacted := *response
+	redacted.Environments = make([]*models.Environment, len(response.Environments))
+	for i, env := range response.Environments {
+		redacted.Environments[i] = env.Redacted()
+	}
+	utils.Success(c, &redacted)
 }
 
 // GetEnvironment 获取环境详情
+// 与其他环境接口一样，响应中的敏感配置（如 operator_token）替换为 models.RedactedConfigValue
 func (h *environmentHandler) GetEnvironment(c *gin.Context) {
 	id := c.Param("id")
 	if id == "" {
@@ -88,7 +94,7 @@ func (h *environmentHandler) GetEnvironment(c *gin.Context) {
 		return
 	}
 
-	utils.Success(c, environment)
+	utils.Success(c, environment.Redacted())
 }
 
 // UpdateEnvironment 更新环境
@@ -111,7 +117,7 @@ func (h *environmentHandler) UpdateEnvironment(c *gin.Context) {
 		return
 	}
 
-	utils.Success(c, environment)
+	utils.Success(c, environment.Redacted())
 }
 
 // DeleteEnvironment 删除环境
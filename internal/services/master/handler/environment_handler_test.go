@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/services/master/service"
+	"github.com/gin-gonic/gin"
+)
+
+type memEnvRepo struct {
+	envs map[string]*models.Environment
+}
+
+func (r *memEnvRepo) Create(ctx context.Context, env *models.Environment) error {
+	r.envs[env.ID] = env
+	return nil
+}
+
+func (r *memEnvRepo) GetByID(ctx context.Context, id string) (*models.Environment, error) {
+	env, ok := r.envs[id]
+	if !ok {
+		return nil, fmt.Errorf("environment %s not found", id)
+	}
+	return env, nil
+}
+
+func (r *memEnvRepo) List(ctx context.Context, filter *models.EnvironmentFilter) ([]*models.Environment, int, error) {
+	var envs []*models.Environment
+	for _, env := range r.envs {
+		envs = append(envs, env)
+	}
+	return envs, len(envs), nil
+}
+
+func (r *memEnvRepo) Update(ctx context.Context, env *models.Environment) error {
+	r.envs[env.ID] = env
+	return nil
+}
+
+func (r *memEnvRepo) Delete(ctx context.Context, id string) error {
+	delete(r.envs, id)
+	return nil
+}
+
+func TestEnvironmentHandlerRedactsOperatorToken(t *testing.T) {
+	repo := &memEnvRepo{envs: map[string]*models.Environment{
+		"env-1": {
+			ID:       "env-1",
+			Name:     "staging",
+			Type:     models.EnvironmentTypeKubernetes,
+			Config:   []byte(`{"namespace":"staging","operator_token":"s3cret"}`),
+			IsActive: true,
+		},
+	}}
+	h := NewEnvironmentHandler(service.NewEnvironmentService(repo, nil, nil))
+
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/environments", h.GetEnvironmentList)
+	r.GET("/environments/:id", h.GetEnvironment)
+	r.PUT("/environments/:id", h.UpdateEnvironment)
+
+	do := func(method, path string, body interface{}) string {
+		var buf bytes.Buffer
+		if body != nil {
+			if err := json.NewEncoder(&buf).Encode(body); err != nil {
+				t.Fatal(err)
+			}
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(method, path, &buf))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s %s: status %d: %s", method, path, w.Code, w.Body.String())
+		}
+		return w.Body.String()
+	}
+
+	for _, path := range []string{"/environments", "/environments/env-1"} {
+		body := do(http.MethodGet, path, nil)
+		if strings.Contains(body, "s3cret") {
+			t.Errorf("GET %s leaks operator_token: %s", path, body)
+		}
+		if !strings.Contains(body, models.RedactedConfigValue) {
+			t.Errorf("GET %s: expected redacted operator_token, got %s", path, body)
+		}
+	}
+
+	// 原样提交占位值时保留已保存的 Token
+	body := do(http.MethodPut, "/environments/env-1", models.UpdateEnvironmentRequest{
+		Config: map[string]string{"namespace": "staging-2", "operator_token": models.RedactedConfigValue},
+	})
+	if strings.Contains(body, "s3cret") {
+		t.Errorf("PUT leaks operator_token: %s", body)
+	}
+	cfg, err := repo.envs["env-1"].GetEnvironmentConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Operator.Token != "s3cret" || cfg.Namespace != "staging-2" {
+		t.Errorf("stored config = token %q namespace %q, want s3cret staging-2", cfg.Operator.Token, cfg.Namespace)
+	}
+
+	// 提交新值时替换 Token
+	do(http.MethodPut, "/environments/env-1", models.UpdateEnvironmentRequest{
+		Config: map[string]string{"namespace": "staging-2", "operator_token": "rotated"},
+	})
+	if cfg, _ := repo.envs["env-1"].GetEnvironmentConfig(); cfg.Operator.Token != "rotated" {
+		t.Errorf("stored token = %q, want rotated", cfg.Operator.Token)
+	}
+}
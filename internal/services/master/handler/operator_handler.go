@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"github.com/boreas/internal/pkg/utils"
+	"github.com/boreas/internal/services/master/service"
+	"github.com/gin-gonic/gin"
+)
+
+type operatorHandler struct {
+	operators interfaces.OperatorManager
+	callbacks *service.OperatorCallbackService
+}
+
+// NewOperatorHandler 创建 Operator 处理器
+func NewOperatorHandler(operators interfaces.OperatorManager, callbacks *service.OperatorCallbackService) *operatorHandler {
+	return &operatorHandler{
+		operators: operators,
+		callbacks: callbacks,
+	}
+}
+
+// ListOperators 列出所有环境 Operator 的健康状态和熔断状态
+func (h *operatorHandler) ListOperators(c *gin.Context) {
+	utils.Success(c, gin.H{
+		"operators": h.operators.ListOperatorHealth(),
+	})
+}
+
+// Callback 接收 Operator 推送的签名状态回调，:env 为环境 ID 或名称
+func (h *operatorHandler) Callback(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.BadRequest(c, "failed to read request body")
+		return
+	}
+
+	cb, err := h.callbacks.HandleCallback(c.Request.Context(), c.Param("env"),
+		c.GetHeader(operatorapi.HeaderCallbackTimestamp),
+		c.GetHeader(operatorapi.HeaderCallbackSignature),
+		body)
+	if err != nil {
+		switch {
+		case errors.Is(err, operatorapi.ErrInvalidSignature):
+			utils.ErrorResponse(c, http.StatusUnauthorized, "INVALID_SIGNATURE", err.Error(), nil)
+		case errors.Is(err, service.ErrEnvironmentNotFound):
+			utils.ErrorResponse(c, http.StatusNotFound, "ENVIRONMENT_NOT_FOUND", err.Error(), nil)
+		case errors.Is(err, service.ErrInvalidCallback):
+			utils.BadRequest(c, err.Error())
+		default:
+			utils.ErrorResponse(c, http.StatusInternalServerError, "CALLBACK_FAILED", err.Error(), nil)
+		}
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: cb.App, Message: "Callback accepted"})
+}
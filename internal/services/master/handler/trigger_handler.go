@@ -10,6 +10,7 @@ import (
 	"net/http"
 	"strings"
 
+	"github.com/boreas/internal/pkg/models"
 	"github.com/boreas/internal/services/master/service"
 )
 
@@ -88,7 +89,15 @@ func (h *WebhookHandler) HandleGitHubWebhook(w http.ResponseWriter, r *http.Requ
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(result)
+	json.NewEncoder(w).Encode(&models.WebhookResponse{
+		Message:              result.Message,
+		VersionCreated:       result.TriggerCreated,
+		VersionID:            result.TriggerID,
+		BuildID:              result.BuildID,
+		DeploymentsTriggered: result.DeploymentsTriggered,
+		AppsBuilt:            result.AppsBuilt,
+		Errors:               result.Errors,
+	})
 }
 
 func (h *WebhookHandler) verifySignature(payload []byte, signature string) bool {
@@ -3,6 +3,7 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"time"
 
@@ -12,6 +13,13 @@ import (
 	"github.com/google/uuid"
 )
 
+var (
+	// ErrApplicationNotFound 按名称找不到应用
+	ErrApplicationNotFound = errors.New("application not found")
+	// ErrEnvironmentNotAssociated 应用未关联指定环境
+	ErrEnvironmentNotAssociated = errors.New("environment not associated with application")
+)
+
 type applicationService struct {
 	appRepo         interfaces.ApplicationRepository
 	versionRepo     interfaces.VersionRepository
@@ -235,7 +243,7 @@ func (s *applicationService) GetApplicationVersionsSummary(ctx context.Context,
 			}
 
 			// 统计实例数
-			instanceCount := len(versionStatus.Nodes)
+			instanceCount := activeNodeCount(versionStatus.Nodes)
 			stats.TotalInstances += instanceCount
 			stats.EnvironmentCount++
 
@@ -322,10 +330,10 @@ func (s *applicationService) GetApplicationVersionsDetail(ctx context.Context, a
 			continue
 		}
 
-		// 计算该环境的总实例数（所有版本的节点数之和）
+		// 计算该环境的总实例数（所有版本的节点数之和，不含维护中的节点）
 		totalInstances := 0
 		for _, versionStatus := range appStatus.Versions {
-			totalInstances += len(versionStatus.Nodes)
+			totalInstances += activeNodeCount(versionStatus.Nodes)
 		}
 
 		// 转换 VersionStatus 为 EnvironmentVersionDetail
@@ -335,10 +343,17 @@ func (s *applicationService) GetApplicationVersionsDetail(ctx context.Context, a
 			instances := make([]models.VersionInstance, 0, len(versionStatus.Nodes))
 			healthSum := 0
 			for _, nodeStatus := range versionStatus.Nodes {
+				status := nodeStatus.Status
+				if status == "" {
+					status = "running" // Operator 未上报实例状态时的默认值
+				}
 				instances = append(instances, models.VersionInstance{
 					NodeName:      nodeStatus.Node,
+					Instance:      nodeStatus.Instance,
 					Healthy:       nodeStatus.Healthy, // 直接使用 HealthInfo
-					Status:        "running",          // 默认状态
+					Status:        status,
+					Restarts:      nodeStatus.Restarts,
+					Cordoned:      nodeStatus.Cordoned,
 					LastUpdatedAt: time.Now(),
 				})
 				healthSum += nodeStatus.Healthy.Level
@@ -350,10 +365,10 @@ func (s *applicationService) GetApplicationVersionsDetail(ctx context.Context, a
 				versionHealthLevel = healthSum / len(instances)
 			}
 
-			// 计算该版本在此环境的覆盖率：该版本节点数 / 总节点数 * 100
+			// 计算该版本在此环境的覆盖率：该版本节点数 / 总节点数 * 100（不含维护中的节点）
 			coverage := 0
 			if totalInstances > 0 {
-				coverage = len(versionStatus.Nodes) * 100 / totalInstances
+				coverage = activeNodeCount(versionStatus.Nodes) * 100 / totalInstances
 			}
 
 			// 查询版本信息以获取 git tag 和 commit
@@ -457,10 +472,10 @@ func (s *applicationService) GetApplicationVersionCoverage(ctx context.Context,
 // calculateEnvironmentCoverage 计算单个环境的版本覆盖情况
 // 基于创建时间比较：如果运行版本的创建时间 >= 目标版本的创建时间，则认为被覆盖
 func (s *applicationService) calculateEnvironmentCoverage(ctx context.Context, env models.Environment, appStatus *models.ApplicationStatusResponse, targetVersionInfo *models.Version) models.EnvironmentVersionCoverage {
-	// 统计总实例数
+	// 统计总实例数，维护中（cordoned）的节点不计入
 	totalInstances := 0
 	for _, versionStatus := range appStatus.Versions {
-		totalInstances += len(versionStatus.Nodes)
+		totalInstances += activeNodeCount(versionStatus.Nodes)
 	}
 
 	// 统计覆盖实例数和版本分布
@@ -470,7 +485,7 @@ func (s *applicationService) calculateEnvironmentCoverage(ctx context.Context, e
 	versionDistribution := make([]models.VersionInstanceCount, 0, len(appStatus.Versions))
 
 	for _, versionStatus := range appStatus.Versions {
-		instanceCount := len(versionStatus.Nodes)
+		instanceCount := activeNodeCount(versionStatus.Nodes)
 
 		// 查询该版本信息以获取创建时间
 		versionInfo, err := s.versionRepo.GetByVersion(ctx, versionStatus.Version)
@@ -516,3 +531,95 @@ func (s *applicationService) calculateEnvironmentCoverage(ctx context.Context, e
 		VersionDistribution: versionDistribution,
 	}
 }
+
+// activeNodeCount 版本的节点数，不含处于维护状态（cordoned）的节点
+func activeNodeCount(nodes []models.NodeStatus) int {
+	count := 0
+	for _, node := range nodes {
+		if !node.Cordoned {
+			count++
+		}
+	}
+	return count
+}
+
+// GetApplicationStatus 获取应用在指定环境的状态，缓存有效时不访问 Operator
+func (s *applicationService) GetApplicationStatus(ctx context.Context, appName, env string) (*models.ApplicationStatusResponse, error) {
+	environmentID, err := s.environmentID(ctx, appName, env)
+	if err != nil {
+		return nil, err
+	}
+	return s.operatorManager.GetApplicationStatus(ctx, environmentID, appName)
+}
+
+// ScaleApplication 调整应用在指定环境的副本数
+func (s *applicationService) ScaleApplication(ctx context.Context, appName, env string, req *models.ScaleRequest) error {
+	environmentID, err := s.environmentID(ctx, appName, env)
+	if err != nil {
+		return err
+	}
+	req.App = appName
+	return s.operatorManager.ScaleApplication(ctx, environmentID, req)
+}
+
+// RestartApplication 重启应用在指定环境的实例
+func (s *applicationService) RestartApplication(ctx context.Context, appName, env string, req *models.RestartRequest) error {
+	environmentID, err := s.environmentID(ctx, appName, env)
+	if err != nil {
+		return err
+	}
+	req.App = appName
+	return s.operatorManager.RestartApplication(ctx, environmentID, req)
+}
+
+// GetApplicationLogs 获取应用在指定环境的实例日志
+func (s *applicationService) GetApplicationLogs(ctx context.Context, appName, env string, req *models.LogsRequest) (*models.LogsResponse, error) {
+	operator, err := s.environmentOperator(ctx, appName, env)
+	if err != nil {
+		return nil, err
+	}
+	req.App = appName
+	return operator.GetLogs(ctx, req)
+}
+
+// GetApplicationEvents 获取应用在指定环境的事件
+func (s *applicationService) GetApplicationEvents(ctx context.Context, appName, env string) (*models.EventsResponse, error) {
+	operator, err := s.environmentOperator(ctx, appName, env)
+	if err != nil {
+		return nil, err
+	}
+	return operator.GetEvents(ctx, appName)
+}
+
+// UndeployApplication 删除应用在指定环境运行的所有版本，应用与环境的关联保留
+func (s *applicationService) UndeployApplication(ctx context.Context, appName, env string) error {
+	environmentID, err := s.environmentID(ctx, appName, env)
+	if err != nil {
+		return err
+	}
+	return s.operatorManager.DeleteApplication(ctx, environmentID, appName)
+}
+
+// environmentOperator 返回应用关联环境的 Operator
+func (s *applicationService) environmentOperator(ctx context.Context, appName, env string) (interfaces.Operator, error) {
+	environmentID, err := s.environmentID(ctx, appName, env)
+	if err != nil {
+		return nil, err
+	}
+	return s.operatorManager.GetOperator(environmentID)
+}
+
+// environmentID 在应用关联的环境中按 ID 或名称查找环境
+func (s *applicationService) environmentID(ctx context.Context, appName, env string) (string, error) {
+	app, err := s.appRepo.GetByName(ctx, appName)
+	if err != nil {
+		return "", fmt.Errorf("%w: %s: %v", ErrApplicationNotFound, appName, err)
+	}
+
+	for _, e := range app.Environments {
+		if e.ID == env || e.Name == env {
+			return e.ID, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s/%s", ErrEnvironmentNotAssociated, appName, env)
+}
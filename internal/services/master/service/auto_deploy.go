@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path"
+
+	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
+	"go.uber.org/zap"
+)
+
+// defaultAutoDeployStrategy 规则未配置策略时使用：单批次，异常自动回滚
+var defaultAutoDeployStrategy = []models.DeploySteps{
+	{BatchSize: 1, AutoRollback: true},
+}
+
+// autoDeployer 根据环境的自动部署规则为新版本创建部署
+type autoDeployer struct {
+	versionRepo       interfaces.VersionRepository
+	envRepo           interfaces.EnvironmentRepository
+	deploymentService interfaces.DeploymentService
+}
+
+func newAutoDeployer(
+	versionRepo interfaces.VersionRepository,
+	envRepo interfaces.EnvironmentRepository,
+	deploymentService interfaces.DeploymentService,
+) *autoDeployer {
+	return &autoDeployer{
+		versionRepo:       versionRepo,
+		envRepo:           envRepo,
+		deploymentService: deploymentService,
+	}
+}
+
+// TriggerAutoDeployments 对所有激活环境评估自动部署规则，为命中的环境创建部署
+// 每个环境最多命中一条规则；单个环境失败不影响其他环境
+func (a *autoDeployer) TriggerAutoDeployments(ctx context.Context, versionID string) ([]*models.Deployment, error) {
+	version, err := a.versionRepo.GetByID(ctx, versionID)
+	if err != nil {
+		return nil, fmt.Errorf("version not found: %w", err)
+	}
+
+	// 不设置分页，评估所有激活环境
+	active := true
+	envs, _, err := a.envRepo.List(ctx, &models.EnvironmentFilter{IsActive: &active})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	var deployments []*models.Deployment
+	for _, env := range envs {
+		rules, err := env.GetAutoDeployRules()
+		if err != nil {
+			logger.GetLogger().Warn("invalid auto deploy rules",
+				zap.String("environment", env.Name),
+				zap.Error(err),
+			)
+			continue
+		}
+
+		for _, rule := range rules {
+			appOrder, ok := matchAutoDeployRule(&rule, version)
+			if !ok {
+				continue
+			}
+
+			deployment, err := a.createDeployment(ctx, version, env, &rule, appOrder)
+			if err != nil {
+				logger.GetLogger().Error("failed to trigger auto deployment",
+					zap.String("version", version.Version),
+					zap.String("environment", env.Name),
+					zap.Error(err),
+				)
+			} else {
+				deployments = append(deployments, deployment)
+			}
+			break
+		}
+	}
+
+	return deployments, nil
+}
+
+func (a *autoDeployer) createDeployment(ctx context.Context, version *models.Version, env *models.Environment, rule *models.AutoDeployRule, appOrder []string) (*models.Deployment, error) {
+	strategy := rule.Strategy
+	if len(strategy) == 0 {
+		strategy = append([]models.DeploySteps(nil), defaultAutoDeployStrategy...)
+	}
+
+	deployment, err := a.deploymentService.CreateDeployment(ctx, &models.CreateDeploymentRequest{
+		VersionID:      version.ID,
+		EnvironmentID:  env.ID,
+		MustInOrder:    appOrder,
+		Strategy:       strategy,
+		ManualApproval: rule.ManualApproval,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	logger.GetLogger().Info("auto deployment triggered",
+		zap.String("deployment_id", deployment.ID),
+		zap.String("version", version.Version),
+		zap.String("environment", env.Name),
+		zap.Bool("auto_start", rule.AutoStart),
+	)
+
+	if !rule.AutoStart {
+		return deployment, nil
+	}
+	started, err := a.deploymentService.StartDeployment(ctx, deployment.ID)
+	if err != nil {
+		// 部署已创建，启动失败时保留 pending 状态等待手动启动
+		logger.GetLogger().Warn("failed to auto start deployment",
+			zap.String("deployment_id", deployment.ID),
+			zap.Error(err),
+		)
+		return deployment, nil
+	}
+	return started, nil
+}
+
+// matchAutoDeployRule 判断规则是否匹配版本，返回需要按顺序部署的应用 ID
+// 规则未指定应用时返回 nil，表示部署版本中的所有应用
+func matchAutoDeployRule(rule *models.AutoDeployRule, version *models.Version) ([]string, bool) {
+	if len(rule.Repositories) > 0 {
+		matched := false
+		for _, repo := range rule.Repositories {
+			if sameRepository(repo, version.Repository) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, false
+		}
+	}
+
+	if len(rule.TagPatterns) > 0 {
+		matched := false
+		for _, pattern := range rule.TagPatterns {
+			if ok, _ := path.Match(pattern, version.GitTag); ok {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, false
+		}
+	}
+
+	if len(rule.Apps) == 0 {
+		return nil, true
+	}
+
+	builds := version.GetAppBuilds()
+	var appOrder []string
+	for _, name := range rule.Apps {
+		for _, build := range builds {
+			if build.AppName == name {
+				appOrder = append(appOrder, build.AppID)
+				break
+			}
+		}
+	}
+	return appOrder, len(appOrder) > 0
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/models"
+)
+
+func TestMatchAutoDeployRule(t *testing.T) {
+	version := &models.Version{
+		ID:         "ver-1",
+		Version:    "v1.2.0",
+		GitTag:     "v1.2.0",
+		Repository: "https://github.com/example/repo.git",
+	}
+	if err := version.SetAppBuilds([]models.AppBuild{
+		{AppID: "app-api", AppName: "api"},
+		{AppID: "app-web", AppName: "web"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		rule      models.AutoDeployRule
+		wantMatch bool
+		wantApps  []string
+	}{
+		{"empty rule matches all apps", models.AutoDeployRule{}, true, nil},
+		{"repository without .git suffix", models.AutoDeployRule{Repositories: []string{"https://github.com/example/repo"}}, true, nil},
+		{"other repository", models.AutoDeployRule{Repositories: []string{"https://github.com/example/other"}}, false, nil},
+		{"release tag pattern", models.AutoDeployRule{TagPatterns: []string{"v*.*.*"}}, true, nil},
+		{"rc tag pattern", models.AutoDeployRule{TagPatterns: []string{"v*-rc*"}}, false, nil},
+		{"apps keep rule order", models.AutoDeployRule{Apps: []string{"web", "api"}}, true, []string{"app-web", "app-api"}},
+		{"apps not in version", models.AutoDeployRule{Apps: []string{"worker"}}, false, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apps, ok := matchAutoDeployRule(&tt.rule, version)
+			if ok != tt.wantMatch {
+				t.Fatalf("match = %v, want %v", ok, tt.wantMatch)
+			}
+			if ok && !reflect.DeepEqual(apps, tt.wantApps) {
+				t.Fatalf("apps = %v, want %v", apps, tt.wantApps)
+			}
+		})
+	}
+}
+
+type recordingDeploymentService struct {
+	interfaces.DeploymentService
+	created []*models.CreateDeploymentRequest
+}
+
+func (s *recordingDeploymentService) CreateDeployment(ctx context.Context, req *models.CreateDeploymentRequest) (*models.Deployment, error) {
+	s.created = append(s.created, req)
+	return &models.Deployment{ID: "deploy-1", EnvironmentID: req.EnvironmentID}, nil
+}
+
+func TestVersionServiceTriggerAutoDeployments(t *testing.T) {
+	version := &models.Version{ID: "ver-1", Version: "v1.0.0", GitTag: "v1.0.0"}
+	if err := version.SetAppBuilds([]models.AppBuild{{AppID: "app-api", AppName: "api"}}); err != nil {
+		t.Fatal(err)
+	}
+	versionRepo := &fakeVersionRepo{versions: map[string]*models.Version{version.ID: version}}
+	envRepo := &memEnvRepo{envs: map[string]*models.Environment{
+		"env-1": {ID: "env-1", Name: "staging", IsActive: true, Config: []byte(`{"auto_deploy":"[{\"tag_patterns\":[\"v*\"]}]"}`)},
+	}}
+	deploymentService := &recordingDeploymentService{}
+
+	svc := NewVersionService(versionRepo, envRepo, deploymentService)
+	deployments, err := svc.TriggerAutoDeployments(context.Background(), version.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deployments) != 1 || len(deploymentService.created) != 1 {
+		t.Fatalf("expected one auto deployment, got %d (created %d)", len(deployments), len(deploymentService.created))
+	}
+	if got := deploymentService.created[0].EnvironmentID; got != "env-1" {
+		t.Errorf("deployment environment = %s, want env-1", got)
+	}
+
+	// 未配置自动部署时不创建部署
+	deployments, err = NewVersionService(versionRepo, nil, nil).TriggerAutoDeployments(context.Background(), version.ID)
+	if err != nil || deployments != nil {
+		t.Errorf("expected no deployments without auto deploy, got %v, %v", deployments, err)
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+// ErrBuildLogNotFound 指定版本和应用的构建日志不存在
+var ErrBuildLogNotFound = errors.New("build log not found")
+
+// ErrInvalidBuildLogName 版本或应用名不能作为日志文件名
+var ErrInvalidBuildLogName = errors.New("invalid build log name")
+
+// BuildLogStore 按版本和应用持久化构建日志
+// 日志以 JSON Lines 写入 <dir>/<version>/<app>.log，构建进行中的日志同时保存在内存中供实时订阅。
+// 构建时以每次触发独有的构建 ID 为版本目录（git tag 可能包含 /，也可能被多个仓库同时使用），版本创建后通过 Rename 改为版本 ID
+type BuildLogStore struct {
+	dir string
+
+	mu      sync.Mutex
+	running map[string]*BuildLog
+}
+
+// NewBuildLogStore 创建构建日志存储
+func NewBuildLogStore(dir string) *BuildLogStore {
+	return &BuildLogStore{
+		dir:     dir,
+		running: make(map[string]*BuildLog),
+	}
+}
+
+// BuildLog 单个应用一次构建的日志
+type BuildLog struct {
+	store *BuildLogStore
+	key   string
+
+	mu          sync.Mutex
+	file        *os.File
+	step        int
+	entries     []models.BuildLogEntry
+	subscribers map[chan models.BuildLogEntry]struct{}
+	closed      bool
+}
+
+func buildLogKey(version, app string) string {
+	return version + "/" + app
+}
+
+func (s *BuildLogStore) path(version, app string) (string, error) {
+	for _, name := range []string{version, app} {
+		if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+			return "", fmt.Errorf("%w: %q", ErrInvalidBuildLogName, name)
+		}
+	}
+	return filepath.Join(s.dir, version, app+".log"), nil
+}
+
+// Open 开始记录一次构建，已存在的同名日志会被覆盖
+func (s *BuildLogStore) Open(version, app string) (*BuildLog, error) {
+	p, err := s.path(version, app)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return nil, fmt.Errorf("failed to create build log dir: %w", err)
+	}
+	f, err := os.Create(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create build log: %w", err)
+	}
+
+	l := &BuildLog{
+		store:       s,
+		key:         buildLogKey(version, app),
+		file:        f,
+		subscribers: make(map[chan models.BuildLogEntry]struct{}),
+	}
+
+	s.mu.Lock()
+	s.running[l.key] = l
+	s.mu.Unlock()
+
+	return l, nil
+}
+
+// Rename 把版本目录 from 下已结束的构建日志移动到 to，to 已存在时被替换；from 不存在时忽略
+func (s *BuildLogStore) Rename(from, to string) error {
+	src, err := s.path(from, "_")
+	if err != nil {
+		return err
+	}
+	dst, err := s.path(to, "_")
+	if err != nil {
+		return err
+	}
+	src, dst = filepath.Dir(src), filepath.Dir(dst)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.running {
+		if strings.HasPrefix(key, from+"/") {
+			return fmt.Errorf("build logs of %s are still being written", from)
+		}
+	}
+	if _, err := os.Stat(src); os.IsNotExist(err) {
+		return nil
+	}
+	if err := os.RemoveAll(dst); err != nil {
+		return fmt.Errorf("failed to replace build logs of %s: %w", to, err)
+	}
+	if err := os.Rename(src, dst); err != nil {
+		return fmt.Errorf("failed to move build logs to %s: %w", to, err)
+	}
+	return nil
+}
+
+// Read 读取构建日志，running 表示构建是否仍在进行
+func (s *BuildLogStore) Read(version, app string) ([]models.BuildLogEntry, bool, error) {
+	s.mu.Lock()
+	l, ok := s.running[buildLogKey(version, app)]
+	s.mu.Unlock()
+	if ok {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		return append([]models.BuildLogEntry(nil), l.entries...), !l.closed, nil
+	}
+
+	entries, err := s.readFile(version, app)
+	return entries, false, err
+}
+
+// Subscribe 返回当前已有的日志和后续日志的通道
+// 构建已结束时通道为 nil；构建结束后通道被关闭，调用方需调用 cancel 释放订阅
+func (s *BuildLogStore) Subscribe(version, app string) ([]models.BuildLogEntry, <-chan models.BuildLogEntry, func(), error) {
+	s.mu.Lock()
+	l, ok := s.running[buildLogKey(version, app)]
+	s.mu.Unlock()
+	if !ok {
+		entries, err := s.readFile(version, app)
+		return entries, nil, func() {}, err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	history := append([]models.BuildLogEntry(nil), l.entries...)
+	if l.closed {
+		return history, nil, func() {}, nil
+	}
+
+	ch := make(chan models.BuildLogEntry, 256)
+	l.subscribers[ch] = struct{}{}
+	cancel := func() {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+		if _, ok := l.subscribers[ch]; ok {
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+	}
+	return history, ch, cancel, nil
+}
+
+func (s *BuildLogStore) readFile(version, app string) ([]models.BuildLogEntry, error) {
+	p, err := s.path(version, app)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(p)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, ErrBuildLogNotFound
+		}
+		return nil, fmt.Errorf("failed to open build log: %w", err)
+	}
+	defer f.Close()
+
+	var entries []models.BuildLogEntry
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var entry models.BuildLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read build log: %w", err)
+	}
+	return entries, nil
+}
+
+// Append 追加一条日志并推送给订阅者，nil 接收者时忽略
+func (l *BuildLog) Append(entryType models.BuildLogEntryType, message string) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return
+	}
+
+	if entryType == models.BuildLogEntryStep {
+		l.step++
+	}
+	entry := models.BuildLogEntry{
+		Time:    time.Now(),
+		Type:    entryType,
+		Step:    l.step,
+		Message: message,
+	}
+	l.entries = append(l.entries, entry)
+
+	if data, err := json.Marshal(entry); err == nil {
+		_, _ = l.file.Write(append(data, '\n'))
+	}
+
+	for ch := range l.subscribers {
+		select {
+		case ch <- entry:
+		default:
+			// 订阅者消费过慢时断开，避免阻塞构建
+			delete(l.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Close 记录结束状态并结束构建日志，关闭所有订阅通道
+func (l *BuildLog) Close(status AppBuildStatus) {
+	if l == nil {
+		return
+	}
+
+	l.Append(models.BuildLogEntryStatus, string(status))
+
+	l.mu.Lock()
+	l.closed = true
+	for ch := range l.subscribers {
+		delete(l.subscribers, ch)
+		close(ch)
+	}
+	_ = l.file.Close()
+	l.mu.Unlock()
+
+	l.store.mu.Lock()
+	if l.store.running[l.key] == l {
+		delete(l.store.running, l.key)
+	}
+	l.store.mu.Unlock()
+}
@@ -0,0 +1,75 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+func TestParseBuildOutput_FailingRunStep(t *testing.T) {
+	store := NewBuildLogStore(t.TempDir())
+	buildLog, err := store.Open("v1.0.0", "api")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	history, ch, cancel, err := store.Subscribe("v1.0.0", "api")
+	if err != nil || ch == nil {
+		t.Fatalf("Subscribe: %v", err)
+	}
+	defer cancel()
+	if len(history) != 0 {
+		t.Fatalf("unexpected history %v", history)
+	}
+
+	output := `{"stream":"Step 1/2 : FROM golang:1.22\n"}
+{"stream":" ---> 1a2b3c\n"}
+{"stream":"Step 2/2 : RUN make build\n"}
+{"stream":"make: *** No rule to make target 'build'.\n"}
+{"errorDetail":{"code":2,"message":"The command '/bin/sh -c make build' returned a non-zero code: 2"},"error":"The command '/bin/sh -c make build' returned a non-zero code: 2"}`
+
+	err = parseBuildOutput(strings.NewReader(output), buildLog)
+	if err == nil || !strings.Contains(err.Error(), "non-zero code") {
+		t.Fatalf("expected RUN failure, got %v", err)
+	}
+	buildLog.Close(AppBuildStatusFailed)
+
+	var live []models.BuildLogEntry
+	for entry := range ch {
+		live = append(live, entry)
+	}
+
+	entries, running, err := store.Read("v1.0.0", "api")
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if running {
+		t.Fatal("build should not be running after Close")
+	}
+	if len(entries) != len(live) || len(entries) != 6 {
+		t.Fatalf("got %d persisted and %d live entries, want 6", len(entries), len(live))
+	}
+
+	errEntry := entries[4]
+	if errEntry.Type != models.BuildLogEntryError || errEntry.Step != 2 {
+		t.Fatalf("error entry = %+v, want step 2 error", errEntry)
+	}
+	if last := entries[5]; last.Type != models.BuildLogEntryStatus || last.Message != string(AppBuildStatusFailed) {
+		t.Fatalf("status entry = %+v", last)
+	}
+
+	if _, _, err := store.Read("v1.0.0", "web"); err != ErrBuildLogNotFound {
+		t.Fatalf("expected ErrBuildLogNotFound, got %v", err)
+	}
+
+	if err := store.Rename("v1.0.0", "ver-1"); err != nil {
+		t.Fatalf("Rename: %v", err)
+	}
+	if moved, _, err := store.Read("ver-1", "api"); err != nil || len(moved) != len(entries) {
+		t.Fatalf("Read after Rename = %d entries, %v", len(moved), err)
+	}
+	if _, _, err := store.Read("v1.0.0", "api"); err != ErrBuildLogNotFound {
+		t.Fatalf("expected ErrBuildLogNotFound for the tag after Rename, got %v", err)
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"sort"
 	"time"
 
@@ -78,12 +79,12 @@ func (e *SimpleDeployExecutor) Apply(ctx context.Context) (models.TaskStatus, er
 			zap.String("prev_version", prevVersion.ID))
 
 		pkg.Replicas = versionMap[prevVersion.ID]
-		_, err = e.client.Apply(ctx, e.task.AppID, prevVersion.ID, pkg)
+		err = e.apply(ctx, prevVersion.ID, pkg)
 		if err != nil {
 			return models.TaskStatusFailed, err
 		}
 		pkg.Replicas = 0
-		_, err = e.client.Apply(ctx, e.task.AppID, e.task.Deployment.VersionID, pkg)
+		err = e.apply(ctx, e.task.Deployment.VersionID, pkg)
 		if err != nil {
 			return models.TaskStatusFailed, err
 		}
@@ -95,7 +96,7 @@ func (e *SimpleDeployExecutor) Apply(ctx context.Context) (models.TaskStatus, er
 			total += s.BatchSize
 		}
 		pkg.Replicas = total
-		_, err = e.client.Apply(ctx, e.task.AppID, e.task.Deployment.VersionID, pkg)
+		err = e.apply(ctx, e.task.Deployment.VersionID, pkg)
 		if err != nil {
 			return models.TaskStatusFailed, nil
 		}
@@ -178,13 +179,13 @@ func (e *SimpleDeployExecutor) Apply(ctx context.Context) (models.TaskStatus, er
 		}
 
 		pkg.Replicas = versionMap[e.task.Deployment.VersionID] + increase
-		_, err = e.client.Apply(ctx, e.task.AppID, e.task.Deployment.VersionID, pkg)
+		err = e.apply(ctx, e.task.Deployment.VersionID, pkg)
 		if err != nil {
 			return models.TaskStatusFailed, err
 		}
 		for _, k := range decrease {
 			pkg.Replicas = 0
-			_, err = e.client.Apply(ctx, e.task.AppID, k, pkg)
+			err = e.apply(ctx, k, pkg)
 			if err != nil {
 				return models.TaskStatusFailed, err
 			}
@@ -227,13 +228,13 @@ func (e *SimpleDeployExecutor) Apply(ctx context.Context) (models.TaskStatus, er
 	)
 
 	pkg.Replicas -= increaseNum
-	_, err = e.client.Apply(ctx, e.task.AppID, e.task.Deployment.VersionID, pkg)
+	err = e.apply(ctx, e.task.Deployment.VersionID, pkg)
 	if err != nil {
 		return models.TaskStatusFailed, err
 	}
 	for vid, replicas := range decreaseMap {
 		pkg.Replicas = versionMap[vid] - replicas
-		_, err = e.client.Apply(ctx, e.task.AppID, vid, pkg)
+		err = e.apply(ctx, vid, pkg)
 		if err != nil {
 			return models.TaskStatusFailed, err
 		}
@@ -241,3 +242,24 @@ func (e *SimpleDeployExecutor) Apply(ctx context.Context) (models.TaskStatus, er
 
 	return models.TaskStatusRunning, nil
 }
+
+// apply 下发指定版本的部署包，镜像取该版本中应用的构建记录
+func (e *SimpleDeployExecutor) apply(ctx context.Context, versionID string, pkg models.DeploymentPackage) error {
+	version, err := e.versionRepo.GetByID(ctx, versionID)
+	if err != nil {
+		return fmt.Errorf("failed to get version %s: %w", versionID, err)
+	}
+	_, err = e.client.Apply(ctx, e.task.AppID, versionID, versionPackage(pkg, version, e.task.AppID))
+	return err
+}
+
+// versionPackage 返回使用版本构建镜像的部署包：有摘要时为 repo@sha256:...，避免 tag 被覆盖后部署到不同内容
+func versionPackage(pkg models.DeploymentPackage, version *models.Version, appID string) models.DeploymentPackage {
+	for _, build := range version.GetAppBuilds() {
+		if build.AppID == appID {
+			pkg.Image = build.ImageRef()
+			break
+		}
+	}
+	return pkg
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/models"
+)
+
+type fakeDeploymentRepo struct {
+	interfaces.DeploymentRepository
+	deployment *models.Deployment
+}
+
+func (r *fakeDeploymentRepo) GetByID(ctx context.Context, id string) (*models.Deployment, error) {
+	return r.deployment, nil
+}
+
+type fakeVersionRepo struct {
+	interfaces.VersionRepository
+	versions map[string]*models.Version
+}
+
+func (r *fakeVersionRepo) GetByID(ctx context.Context, id string) (*models.Version, error) {
+	return r.versions[id], nil
+}
+
+type recordingDeployClient struct {
+	applied []models.DeploymentPackage
+}
+
+func (c *recordingDeployClient) Apply(ctx context.Context, app string, version string, pkg models.DeploymentPackage) (models.ApplyResponse, error) {
+	c.applied = append(c.applied, pkg)
+	return models.ApplyResponse{Success: true}, nil
+}
+
+func (c *recordingDeployClient) AppStatus(ctx context.Context, app string) ([]models.AgentAppStatus, error) {
+	return nil, nil
+}
+
+func TestSimpleDeployExecutorUsesImageDigest(t *testing.T) {
+	version := &models.Version{ID: "ver-1"}
+	if err := version.SetAppBuilds([]models.AppBuild{{
+		AppID:       "app-api",
+		AppName:     "api",
+		DockerImage: "registry.example.com/api:v1.0.0",
+		Digest:      "sha256:abc123",
+	}}); err != nil {
+		t.Fatal(err)
+	}
+	deployment := &models.Deployment{ID: "dep-1", VersionID: "ver-1"}
+	client := &recordingDeployClient{}
+	executor := &SimpleDeployExecutor{
+		task:           models.Task{DeploymentID: "dep-1", AppID: "app-api", Deployment: *deployment},
+		deploymentRepo: &fakeDeploymentRepo{deployment: deployment},
+		versionRepo:    &fakeVersionRepo{versions: map[string]*models.Version{"ver-1": version}},
+		client:         client,
+	}
+
+	if _, err := executor.Apply(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(client.applied) != 1 {
+		t.Fatalf("applied %d packages, want 1", len(client.applied))
+	}
+	if want := "registry.example.com/api@sha256:abc123"; client.applied[0].Image != want {
+		t.Fatalf("image = %q, want %q", client.applied[0].Image, want)
+	}
+}
@@ -2,18 +2,17 @@ package service
 
 import (
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/boreas/internal/pkg/models"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/image"
-	"github.com/docker/docker/api/types/registry"
 	"github.com/docker/docker/client"
 	"github.com/docker/docker/pkg/archive"
 )
@@ -21,9 +20,10 @@ import (
 type DockerService struct {
 	client   *client.Client
 	registry string
+	auth     *RegistryAuthResolver
 }
 
-func NewDockerService(registry string) (*DockerService, error) {
+func NewDockerService(registry string, auth *RegistryAuthResolver) (*DockerService, error) {
 	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
 	if err != nil {
 		return nil, fmt.Errorf("failed to create docker client: %w", err)
@@ -32,10 +32,13 @@ func NewDockerService(registry string) (*DockerService, error) {
 	return &DockerService{
 		client:   cli,
 		registry: registry,
+		auth:     auth,
 	}, nil
 }
 
-func (d *DockerService) BuildImage(ctx context.Context, appPath, appName, tag string, buildConfig *models.BuildConfig) (string, error) {
+// BuildImage 构建镜像，构建输出按步骤解析后写入 buildLog（可为 nil）
+// 构建流中的错误消息（如 RUN 步骤非零退出）会作为构建失败返回
+func (d *DockerService) BuildImage(ctx context.Context, appPath, appName, tag string, buildConfig *models.BuildConfig, buildLog *BuildLog) (string, error) {
 	if buildConfig == nil {
 		buildConfig = &models.BuildConfig{
 			Dockerfile: "Dockerfile",
@@ -56,7 +59,11 @@ func (d *DockerService) BuildImage(ctx context.Context, appPath, appName, tag st
 	}
 	defer tar.Close()
 
-	imageName := fmt.Sprintf("%s/%s:%s", d.registry, appName, tag)
+	targetRegistry := d.registry
+	if buildConfig.Registry != "" {
+		targetRegistry = buildConfig.Registry
+	}
+	imageName := fmt.Sprintf("%s/%s:%s", strings.TrimSuffix(targetRegistry, "/"), appName, tag)
 	buildOptions := types.ImageBuildOptions{
 		Dockerfile: buildConfig.Dockerfile,
 		Tags:       []string{imageName},
@@ -70,40 +77,126 @@ func (d *DockerService) BuildImage(ctx context.Context, appPath, appName, tag st
 	}
 	defer resp.Body.Close()
 
-	output, err := io.ReadAll(resp.Body)
-	if err != nil {
-		return "", fmt.Errorf("failed to read build output: %w", err)
+	if err := parseBuildOutput(resp.Body, buildLog); err != nil {
+		return "", err
 	}
 
-	log.Printf("Build output: %s", string(output))
-
 	return imageName, nil
 }
 
-func (d *DockerService) PushImage(ctx context.Context, imageName string) error {
-	authConfig := registry.AuthConfig{}
-	encodedJSON, err := json.Marshal(authConfig)
+// PushImage 推送镜像并返回仓库返回的内容摘要
+// 认证信息按镜像所在仓库主机解析
+func (d *DockerService) PushImage(ctx context.Context, imageName string) (string, error) {
+	authStr, err := d.auth.EncodedAuth(registryHostOf(imageName))
 	if err != nil {
-		return fmt.Errorf("failed to encode auth config: %w", err)
+		return "", fmt.Errorf("failed to resolve registry auth: %w", err)
 	}
-	authStr := base64.URLEncoding.EncodeToString(encodedJSON)
 
 	resp, err := d.client.ImagePush(ctx, imageName, image.PushOptions{
 		RegistryAuth: authStr,
 	})
 	if err != nil {
-		return fmt.Errorf("failed to push image: %w", err)
+		return "", fmt.Errorf("failed to push image: %w", err)
 	}
 	defer resp.Close()
 
-	output, err := io.ReadAll(resp)
+	digest, err := parsePushOutput(resp)
 	if err != nil {
-		return fmt.Errorf("failed to read push output: %w", err)
+		return "", err
+	}
+	if digest == "" {
+		return "", fmt.Errorf("push of %s returned no digest", imageName)
 	}
 
-	log.Printf("Push output: %s", string(output))
+	log.Printf("Pushed %s with digest %s", imageName, digest)
 
-	return nil
+	return digest, nil
+}
+
+// buildMessage Docker 构建输出流中的单条消息
+type buildMessage struct {
+	Stream      string `json:"stream"`
+	Status      string `json:"status"`
+	ErrorDetail *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+	Error string `json:"error"`
+}
+
+// parseBuildOutput 解析构建输出流
+// Step N/M 开头的输出作为新步骤，其余输出归属当前步骤；流中出现错误消息时返回构建错误
+func parseBuildOutput(r io.Reader, buildLog *BuildLog) error {
+	dec := json.NewDecoder(r)
+	for {
+		var msg buildMessage
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return fmt.Errorf("failed to read build output: %w", err)
+		}
+
+		if msg.ErrorDetail != nil || msg.Error != "" {
+			errMsg := msg.Error
+			if msg.ErrorDetail != nil && msg.ErrorDetail.Message != "" {
+				errMsg = msg.ErrorDetail.Message
+			}
+			buildLog.Append(models.BuildLogEntryError, errMsg)
+			return fmt.Errorf("build failed: %s", errMsg)
+		}
+
+		text := strings.TrimRight(msg.Stream, "\n")
+		if text == "" {
+			text = msg.Status
+		}
+		if strings.TrimSpace(text) == "" {
+			continue
+		}
+
+		if strings.HasPrefix(text, "Step ") {
+			buildLog.Append(models.BuildLogEntryStep, text)
+		} else {
+			buildLog.Append(models.BuildLogEntryOutput, text)
+		}
+	}
+}
+
+// pushMessage Docker 推送进度流中的单条消息
+type pushMessage struct {
+	Status      string `json:"status"`
+	ErrorDetail *struct {
+		Message string `json:"message"`
+	} `json:"errorDetail"`
+	Error string `json:"error"`
+	Aux   *struct {
+		Tag    string `json:"Tag"`
+		Digest string `json:"Digest"`
+	} `json:"aux"`
+}
+
+// parsePushOutput 解析推送进度流，流中的错误消息转为 error，并提取 aux 中的摘要
+func parsePushOutput(r io.Reader) (string, error) {
+	var digest string
+	dec := json.NewDecoder(r)
+	for {
+		var msg pushMessage
+		if err := dec.Decode(&msg); err != nil {
+			if err == io.EOF {
+				return digest, nil
+			}
+			return "", fmt.Errorf("failed to read push output: %w", err)
+		}
+		if msg.ErrorDetail != nil && msg.ErrorDetail.Message != "" {
+			return "", fmt.Errorf("failed to push image: %s", msg.ErrorDetail.Message)
+		}
+		if msg.Error != "" {
+			return "", fmt.Errorf("failed to push image: %s", msg.Error)
+		}
+		if msg.Aux != nil && msg.Aux.Digest != "" {
+			digest = msg.Aux.Digest
+		}
+	}
 }
 
 func (d *DockerService) Close() error {
@@ -7,22 +7,44 @@ import (
 	"time"
 
 	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/logger"
 	"github.com/boreas/internal/pkg/models"
 	"github.com/boreas/internal/pkg/utils"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
+// operatorHealthCheckTimeout 创建环境时检查 Operator 连通性的超时
+const operatorHealthCheckTimeout = 5 * time.Second
+
+// OperatorFactory 根据环境创建 Operator 客户端
+type OperatorFactory func(env *models.Environment) (interfaces.Operator, error)
+
 type environmentService struct {
 	envRepo interfaces.EnvironmentRepository
+
+	operators       interfaces.OperatorManager
+	operatorFactory OperatorFactory
 }
 
 // NewEnvironmentService 创建环境服务
-func NewEnvironmentService(envRepo interfaces.EnvironmentRepository) interfaces.EnvironmentService {
+// 环境变更时通过 operatorFactory 创建 Operator 并同步到 operators；任一为 nil 时不管理 Operator
+func NewEnvironmentService(
+	envRepo interfaces.EnvironmentRepository,
+	operators interfaces.OperatorManager,
+	operatorFactory OperatorFactory,
+) interfaces.EnvironmentService {
 	return &environmentService{
-		envRepo: envRepo,
+		envRepo:         envRepo,
+		operators:       operators,
+		operatorFactory: operatorFactory,
 	}
 }
 
+func (s *environmentService) managesOperators() bool {
+	return s.operators != nil && s.operatorFactory != nil
+}
+
 func (s *environmentService) CreateEnvironment(ctx context.Context, req *models.CreateEnvironmentRequest) (*models.Environment, error) {
 	// 验证请求
 	if err := utils.ValidateStruct(req); err != nil {
@@ -44,6 +66,11 @@ func (s *environmentService) CreateEnvironment(ctx context.Context, req *models.
 		}
 	}
 
+	// 按环境类型校验配置
+	if _, err := models.ValidateEnvironmentConfig(req.Type, req.Config); err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
 	bs, _ := json.Marshal(req.Config)
 	// 创建环境
 	env := &models.Environment{
@@ -56,10 +83,29 @@ func (s *environmentService) CreateEnvironment(ctx context.Context, req *models.
 		UpdatedAt: time.Now(),
 	}
 
+	// 创建 Operator 并检查连通性，失败时不创建环境
+	var operator interfaces.Operator
+	if s.managesOperators() {
+		operator, err = s.operatorFactory(env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create operator: %w", err)
+		}
+		if env.IsActive {
+			if err := checkOperatorHealth(ctx, operator); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	if err := s.envRepo.Create(ctx, env); err != nil {
 		return nil, fmt.Errorf("failed to create environment: %w", err)
 	}
 
+	// 只有激活的环境注册 Operator，未激活的环境不可部署
+	if operator != nil && env.IsActive {
+		s.operators.RegisterOperator(env.ID, operator)
+	}
+
 	return env, nil
 }
 
@@ -112,6 +158,10 @@ func (s *environmentService) UpdateEnvironment(ctx context.Context, id string, r
 		return nil, fmt.Errorf("environment not found: %w", err)
 	}
 
+	oldType := env.Type
+	oldConfig := string(env.Config)
+	wasActive := env.IsActive
+
 	// 更新字段
 	if req.Name != "" {
 		env.Name = req.Name
@@ -120,6 +170,10 @@ func (s *environmentService) UpdateEnvironment(ctx context.Context, id string, r
 		env.Type = req.Type
 	}
 	if req.Config != nil {
+		// 响应中的敏感配置为占位值，客户端原样提交时保留已保存的值
+		if err := env.RestoreRedactedConfig(req.Config); err != nil {
+			return nil, fmt.Errorf("validation failed: %w", err)
+		}
 		bs, _ := json.Marshal(req.Config)
 		env.Config = bs
 	}
@@ -128,11 +182,48 @@ func (s *environmentService) UpdateEnvironment(ctx context.Context, id string, r
 	}
 	env.UpdatedAt = time.Now()
 
+	// 提交了配置或修改了类型时拒绝不属于该类型的配置键；只修改名称或激活状态时允许保留升级前的配置键
+	if req.Config != nil || env.Type != oldType {
+		err = env.ValidateConfig()
+	} else {
+		_, err = env.GetEnvironmentConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+
+	// 类型或配置变化时重建 Operator，保存前创建以便配置错误时拒绝更新；
+	// 重新激活时同创建环境一样注册 Operator 并检查连通性，停用时移除 Operator
+	var operator interfaces.Operator
+	changed := env.Type != oldType || string(env.Config) != oldConfig
+	if s.managesOperators() && (changed || env.IsActive && !wasActive) {
+		operator, err = s.operatorFactory(env)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create operator: %w", err)
+		}
+		if env.IsActive && !wasActive {
+			if err := checkOperatorHealth(ctx, operator); err != nil {
+				return nil, err
+			}
+		}
+	}
+
 	// 保存更新
 	if err := s.envRepo.Update(ctx, env); err != nil {
 		return nil, fmt.Errorf("failed to update environment: %w", err)
 	}
 
+	switch {
+	case s.operators != nil && !env.IsActive:
+		if wasActive {
+			s.operators.RemoveOperator(env.ID)
+			logger.GetLogger().Info("operator removed", zap.String("environment", env.Name))
+		}
+	case operator != nil:
+		s.operators.RegisterOperator(env.ID, operator)
+		logger.GetLogger().Info("operator replaced", zap.String("environment", env.Name))
+	}
+
 	return env, nil
 }
 
@@ -148,5 +239,67 @@ func (s *environmentService) DeleteEnvironment(ctx context.Context, id string) e
 		return fmt.Errorf("failed to delete environment: %w", err)
 	}
 
+	if s.operators != nil {
+		s.operators.RemoveOperator(id)
+	}
+
+	return nil
+}
+
+// ReloadOperators 按当前配置重建所有激活环境的 Operator
+// 新 Operator 创建成功后才替换旧的，创建失败的环境保留原 Operator；已删除或停用环境的 Operator 会被移除
+func (s *environmentService) ReloadOperators(ctx context.Context) error {
+	if !s.managesOperators() {
+		return nil
+	}
+
+	envs, _, err := s.envRepo.List(ctx, &models.EnvironmentFilter{})
+	if err != nil {
+		return fmt.Errorf("failed to list environments: %w", err)
+	}
+
+	exists := make(map[string]bool, len(envs))
+	var failed []string
+	for _, env := range envs {
+		if !env.IsActive {
+			continue
+		}
+		exists[env.ID] = true
+		operator, err := s.operatorFactory(env)
+		if err != nil {
+			logger.GetLogger().Error("failed to reload operator, keeping previous one",
+				zap.String("environment", env.Name),
+				zap.Error(err),
+			)
+			failed = append(failed, env.Name)
+			continue
+		}
+		s.operators.RegisterOperator(env.ID, operator)
+	}
+
+	for _, envID := range s.operators.ListOperators() {
+		if !exists[envID] {
+			s.operators.RemoveOperator(envID)
+		}
+	}
+
+	logger.GetLogger().Info("operators reloaded",
+		zap.Int("environments", len(envs)),
+		zap.Int("failed", len(failed)),
+	)
+
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to reload operators for environments: %v", failed)
+	}
+	return nil
+}
+
+// checkOperatorHealth 检查 Operator 连通性，环境激活前调用
+func checkOperatorHealth(ctx context.Context, operator interfaces.Operator) error {
+	hctx, cancel := context.WithTimeout(ctx, operatorHealthCheckTimeout)
+	defer cancel()
+	if err := operator.HealthCheck(hctx); err != nil {
+		return fmt.Errorf("operator health check failed: %w", err)
+	}
 	return nil
 }
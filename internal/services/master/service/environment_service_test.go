@@ -0,0 +1,139 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/client/operator"
+	"github.com/boreas/internal/pkg/models"
+)
+
+type memEnvRepo struct {
+	envs map[string]*models.Environment
+}
+
+func (r *memEnvRepo) Create(ctx context.Context, env *models.Environment) error {
+	r.envs[env.ID] = env
+	return nil
+}
+
+func (r *memEnvRepo) GetByID(ctx context.Context, id string) (*models.Environment, error) {
+	env, ok := r.envs[id]
+	if !ok {
+		return nil, fmt.Errorf("environment %s not found", id)
+	}
+	return env, nil
+}
+
+func (r *memEnvRepo) List(ctx context.Context, filter *models.EnvironmentFilter) ([]*models.Environment, int, error) {
+	var envs []*models.Environment
+	for _, env := range r.envs {
+		envs = append(envs, env)
+	}
+	return envs, len(envs), nil
+}
+
+func (r *memEnvRepo) Update(ctx context.Context, env *models.Environment) error {
+	r.envs[env.ID] = env
+	return nil
+}
+
+func (r *memEnvRepo) Delete(ctx context.Context, id string) error {
+	delete(r.envs, id)
+	return nil
+}
+
+type unhealthyOperator struct {
+	*operator.MockClient
+}
+
+func (o unhealthyOperator) HealthCheck(ctx context.Context) error {
+	return errors.New("connection refused")
+}
+
+func TestEnvironmentService_SyncsOperators(t *testing.T) {
+	ctx := context.Background()
+	repo := &memEnvRepo{envs: map[string]*models.Environment{}}
+	manager := operator.NewManager()
+	created := 0
+	factory := func(env *models.Environment) (interfaces.Operator, error) {
+		created++
+		if env.Name == "broken" {
+			return unhealthyOperator{operator.NewMockClient()}, nil
+		}
+		return operator.NewMockClient(), nil
+	}
+	svc := NewEnvironmentService(repo, manager, factory)
+
+	if _, err := svc.CreateEnvironment(ctx, &models.CreateEnvironmentRequest{
+		Name: "broken", Type: "kubernetes", IsActive: true,
+	}); err == nil {
+		t.Fatal("expected health check failure")
+	}
+	if len(repo.envs) != 0 || len(manager.ListOperators()) != 0 {
+		t.Fatal("environment with unhealthy operator should not be created")
+	}
+
+	env, err := svc.CreateEnvironment(ctx, &models.CreateEnvironmentRequest{
+		Name: "staging", Type: "kubernetes", IsActive: true,
+	})
+	if err != nil {
+		t.Fatalf("CreateEnvironment: %v", err)
+	}
+	first, err := manager.GetOperator(env.ID)
+	if err != nil {
+		t.Fatalf("operator not registered: %v", err)
+	}
+
+	if _, err := svc.UpdateEnvironment(ctx, env.ID, &models.UpdateEnvironmentRequest{
+		Config: map[string]string{"namespace": "staging"},
+	}); err != nil {
+		t.Fatalf("UpdateEnvironment: %v", err)
+	}
+	second, _ := manager.GetOperator(env.ID)
+	if first == second {
+		t.Fatal("operator should be replaced after config change")
+	}
+
+	manager.RegisterOperator("stale-env", operator.NewMockClient())
+	if err := svc.ReloadOperators(ctx); err != nil {
+		t.Fatalf("ReloadOperators: %v", err)
+	}
+	if ids := manager.ListOperators(); len(ids) != 1 || ids[0] != env.ID {
+		t.Fatalf("operators after reload = %v", ids)
+	}
+
+	inactive, active := false, true
+	if _, err := svc.UpdateEnvironment(ctx, env.ID, &models.UpdateEnvironmentRequest{IsActive: &inactive}); err != nil {
+		t.Fatalf("UpdateEnvironment: %v", err)
+	}
+	if _, err := manager.GetOperator(env.ID); err == nil {
+		t.Fatal("operator should be removed when the environment is deactivated")
+	}
+	if _, err := svc.UpdateEnvironment(ctx, env.ID, &models.UpdateEnvironmentRequest{IsActive: &active}); err != nil {
+		t.Fatalf("UpdateEnvironment: %v", err)
+	}
+	if _, err := manager.GetOperator(env.ID); err != nil {
+		t.Fatalf("operator should be registered when the environment is reactivated: %v", err)
+	}
+
+	if err := svc.DeleteEnvironment(ctx, env.ID); err != nil {
+		t.Fatalf("DeleteEnvironment: %v", err)
+	}
+	if len(manager.ListOperators()) != 0 {
+		t.Fatal("operator should be removed with environment")
+	}
+	if created != 5 {
+		t.Fatalf("factory called %d times, want 5", created)
+	}
+
+	if _, err := svc.CreateEnvironment(ctx, &models.CreateEnvironmentRequest{
+		Name: "dc1", Type: "physical", IsActive: true,
+		Config: map[string]string{"namespace": "default"},
+	}); err == nil {
+		t.Fatal("expected kubernetes-only config key to be rejected for physical environment")
+	}
+}
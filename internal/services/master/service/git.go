@@ -7,6 +7,7 @@ import (
 	"path/filepath"
 	"strings"
 
+	"github.com/boreas/internal/pkg/models"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
@@ -116,7 +117,17 @@ func (g *GitService) GetPreviousTag(ctx context.Context, repoPath, currentTag st
 	return tagList[len(tagList)-1], nil
 }
 
-func (g *GitService) GetChangedApps(ctx context.Context, repoPath, fromTag, toTag string) ([]string, error) {
+// GetChangedApps 根据两个 tag 之间的文件变更，按应用的路径映射配置计算受影响的应用
+func (g *GitService) GetChangedApps(ctx context.Context, repoPath, fromTag, toTag string, apps []*models.Application) ([]*models.Application, error) {
+	files, err := g.GetChangedFiles(ctx, repoPath, fromTag, toTag)
+	if err != nil {
+		return nil, err
+	}
+	return MapChangedFilesToApps(files, apps), nil
+}
+
+// GetChangedFiles 获取两个 tag 之间变更的文件路径列表
+func (g *GitService) GetChangedFiles(ctx context.Context, repoPath, fromTag, toTag string) ([]string, error) {
 	repo, err := git.PlainOpen(repoPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open repository: %w", err)
@@ -178,25 +189,18 @@ func (g *GitService) GetChangedApps(ctx context.Context, repoPath, fromTag, toTa
 		return nil, fmt.Errorf("failed to diff trees: %w", err)
 	}
 
-	appSet := make(map[string]bool)
+	files := make([]string, 0, len(changes))
 	for _, change := range changes {
-		path := change.To.Name
-		if change.To.Name == "" {
-			path = change.From.Name
+		// 重命名时新旧路径都算作变更
+		if change.From.Name != "" {
+			files = append(files, change.From.Name)
 		}
-
-		parts := strings.Split(path, "/")
-		if len(parts) > 0 && parts[0] != "" {
-			appSet[parts[0]] = true
+		if change.To.Name != "" && change.To.Name != change.From.Name {
+			files = append(files, change.To.Name)
 		}
 	}
 
-	apps := make([]string, 0, len(appSet))
-	for app := range appSet {
-		apps = append(apps, app)
-	}
-
-	return apps, nil
+	return files, nil
 }
 
 func (g *GitService) extractRepoName(repoURL string) string {
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"go.uber.org/zap"
+)
+
+var (
+	// ErrEnvironmentNotFound 按 ID 或名称找不到环境
+	ErrEnvironmentNotFound = errors.New("environment not found")
+	// ErrInvalidCallback 回调内容不合法
+	ErrInvalidCallback = errors.New("invalid operator callback")
+)
+
+// StatusWaker 应用状态变化时唤醒等待中的部署任务
+type StatusWaker interface {
+	Wake(environmentID, app string)
+}
+
+// OperatorCallbackService 处理 Operator 推送的状态回调
+// 校验签名后把最新状态写入 OperatorManager 的缓存，并唤醒等待中的部署任务
+type OperatorCallbackService struct {
+	envRepo       interfaces.EnvironmentRepository
+	operators     interfaces.OperatorManager
+	waker         StatusWaker
+	defaultSecret string // 环境未配置 callback_secret 时使用
+	now           func() time.Time
+}
+
+// NewOperatorCallbackService 创建回调服务，waker 可为 nil
+func NewOperatorCallbackService(
+	envRepo interfaces.EnvironmentRepository,
+	operators interfaces.OperatorManager,
+	waker StatusWaker,
+	defaultSecret string,
+) *OperatorCallbackService {
+	return &OperatorCallbackService{
+		envRepo:       envRepo,
+		operators:     operators,
+		waker:         waker,
+		defaultSecret: defaultSecret,
+		now:           time.Now,
+	}
+}
+
+// HandleCallback 处理一次回调，env 为环境 ID 或名称
+// 签名错误返回 operatorapi.ErrInvalidSignature，环境不存在返回 ErrEnvironmentNotFound
+func (s *OperatorCallbackService) HandleCallback(ctx context.Context, env, timestamp, signature string, body []byte) (*operatorapi.StatusCallback, error) {
+	environment, err := s.findEnvironment(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+	envCfg, err := environment.GetEnvironmentConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	secret := envCfg.CallbackSecret
+	if secret == "" {
+		secret = s.defaultSecret
+	}
+	if err := operatorapi.VerifyCallback(secret, timestamp, signature, body, s.now()); err != nil {
+		return nil, err
+	}
+
+	var cb operatorapi.StatusCallback
+	if err := json.Unmarshal(body, &cb); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCallback, err)
+	}
+	if cb.App == "" {
+		return nil, fmt.Errorf("%w: app is required", ErrInvalidCallback)
+	}
+	if cb.Status != nil {
+		if cb.Status.App == "" {
+			cb.Status.App = cb.App
+		}
+		if cb.Status.App != cb.App {
+			return nil, fmt.Errorf("%w: status is for %s, callback is for %s", ErrInvalidCallback, cb.Status.App, cb.App)
+		}
+		s.operators.UpdateApplicationStatus(environment.ID, cb.Status)
+	}
+
+	logger.GetLogger().Info("Operator status callback received",
+		zap.String("environment_id", environment.ID),
+		zap.String("app", cb.App),
+		zap.String("event", string(cb.Event)))
+
+	if s.waker != nil {
+		s.waker.Wake(environment.ID, cb.App)
+	}
+	return &cb, nil
+}
+
+func (s *OperatorCallbackService) findEnvironment(ctx context.Context, env string) (*models.Environment, error) {
+	if e, err := s.envRepo.GetByID(ctx, env); err == nil {
+		return e, nil
+	}
+
+	list, _, err := s.envRepo.List(ctx, &models.EnvironmentFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list environments: %w", err)
+	}
+	for _, e := range list {
+		if e.Name == env {
+			return e, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrEnvironmentNotFound, env)
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/boreas/internal/pkg/client/operator"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+)
+
+type recordingWaker struct {
+	woken []string
+}
+
+func (w *recordingWaker) Wake(environmentID, app string) {
+	w.woken = append(w.woken, environmentID+"/"+app)
+}
+
+func TestOperatorCallbackService_CachesStatusAndWakes(t *testing.T) {
+	ctx := context.Background()
+	repo := &memEnvRepo{envs: map[string]*models.Environment{
+		"env-1": {ID: "env-1", Name: "staging", Type: "kubernetes", Config: []byte(`{"callback_secret":"s3cret"}`)},
+	}}
+	manager := operator.NewManager()
+	manager.SetStatusCacheTTL(time.Minute)
+	manager.RegisterOperator("env-1", operator.NewMockClient())
+	waker := &recordingWaker{}
+	svc := NewOperatorCallbackService(repo, manager, waker, "default")
+
+	body, _ := json.Marshal(operatorapi.StatusCallback{
+		App:   "demo",
+		Event: operatorapi.CallbackReplicaReady,
+		Status: &models.ApplicationStatusResponse{
+			Versions: []models.VersionStatus{{Version: "v2", Healthy: models.HealthInfo{Level: 100}}},
+		},
+	})
+	ts := time.Now().Unix()
+
+	if _, err := svc.HandleCallback(ctx, "staging", strconv.FormatInt(ts, 10),
+		operatorapi.SignCallback("default", ts, body), body); !errors.Is(err, operatorapi.ErrInvalidSignature) {
+		t.Fatalf("callback signed with the default secret should be rejected when the environment has its own, got %v", err)
+	}
+
+	if _, err := svc.HandleCallback(ctx, "staging", strconv.FormatInt(ts, 10),
+		operatorapi.SignCallback("s3cret", ts, body), body); err != nil {
+		t.Fatalf("HandleCallback: %v", err)
+	}
+	if len(waker.woken) != 1 || waker.woken[0] != "env-1/demo" {
+		t.Fatalf("woken = %v", waker.woken)
+	}
+
+	status, err := manager.GetApplicationStatus(ctx, "env-1", "demo")
+	if err != nil {
+		t.Fatalf("GetApplicationStatus: %v", err)
+	}
+	if len(status.Versions) != 1 || status.Versions[0].Version != "v2" {
+		t.Fatalf("status should come from the pushed callback, got %+v", status)
+	}
+
+	if _, err := svc.HandleCallback(ctx, "unknown", strconv.FormatInt(ts, 10), "", body); !errors.Is(err, ErrEnvironmentNotFound) {
+		t.Fatalf("unknown environment: got %v", err)
+	}
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+// MapChangedFilesToApps 根据应用的路径映射配置计算受影响的应用
+// 文件命中 Include 且未命中 Exclude 时归属该应用；命中 Shared 时该应用作为依赖方受影响
+func MapChangedFilesToApps(files []string, apps []*models.Application) []*models.Application {
+	affected := make([]*models.Application, 0)
+	for _, app := range apps {
+		cfg := app.GetPathConfig()
+		for _, file := range files {
+			if matchAnyPath(cfg.Shared, file) ||
+				(matchAnyPath(cfg.Include, file) && !matchAnyPath(cfg.Exclude, file)) {
+				affected = append(affected, app)
+				break
+			}
+		}
+	}
+
+	sort.Slice(affected, func(i, j int) bool {
+		return affected[i].Name < affected[j].Name
+	})
+	return affected
+}
+
+func matchAnyPath(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if matchPath(pattern, file) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchPath 判断文件路径是否匹配 glob
+// 不含通配符的模式按目录前缀匹配，例如 libs/common 匹配 libs/common/a.go
+func matchPath(pattern, file string) bool {
+	pattern = strings.Trim(path.Clean(pattern), "/")
+	file = strings.Trim(path.Clean(file), "/")
+
+	if !strings.ContainsAny(pattern, "*?[") {
+		return file == pattern || strings.HasPrefix(file, pattern+"/")
+	}
+
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/"))
+}
+
+func matchSegments(pattern, file []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			// ** 匹配零个或多个目录层级
+			for i := 0; i <= len(file); i++ {
+				if matchSegments(pattern[1:], file[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(file) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pattern[0], file[0]); !ok {
+			return false
+		}
+		pattern = pattern[1:]
+		file = file[1:]
+	}
+	return len(file) == 0
+}
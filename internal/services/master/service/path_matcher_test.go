@@ -0,0 +1,74 @@
+package service
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+func newPathTestApp(t *testing.T, name string, paths *models.PathConfig) *models.Application {
+	t.Helper()
+	config := map[string]string{}
+	if paths != nil {
+		bs, err := json.Marshal(paths)
+		if err != nil {
+			t.Fatalf("marshal paths: %v", err)
+		}
+		config["paths"] = string(bs)
+	}
+	bs, _ := json.Marshal(config)
+	return &models.Application{Name: name, Config: bs}
+}
+
+func TestMapChangedFilesToApps(t *testing.T) {
+	apps := []*models.Application{
+		newPathTestApp(t, "api", &models.PathConfig{
+			Root:    "services/api",
+			Exclude: []string{"services/api/**/*.md"},
+			Shared:  []string{"libs/common"},
+		}),
+		newPathTestApp(t, "worker", &models.PathConfig{
+			Root:   "services/worker",
+			Shared: []string{"libs/common/**/*.go"},
+		}),
+		newPathTestApp(t, "web", &models.PathConfig{
+			Root:    "services/web",
+			Include: []string{"services/web/**", "deploy/web/*.yaml"},
+		}),
+		newPathTestApp(t, "legacy", nil),
+	}
+
+	tests := []struct {
+		name  string
+		files []string
+		want  []string
+	}{
+		{"app source", []string{"services/api/main.go"}, []string{"api"}},
+		{"excluded docs", []string{"services/api/docs/README.md"}, []string{}},
+		{"shared library fans out", []string{"libs/common/log/log.go"}, []string{"api", "worker"}},
+		{"shared non-go file", []string{"libs/common/README.md"}, []string{"api"}},
+		{"extra include", []string{"deploy/web/values.yaml"}, []string{"web"}},
+		{"unmapped deploy dir", []string{"deploy/README.md"}, []string{}},
+		{"default top-level dir", []string{"legacy/Dockerfile"}, []string{"legacy"}},
+		{"service name is not top-level", []string{"services/README.md"}, []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MapChangedFilesToApps(tt.files, apps)
+			names := make([]string, 0, len(got))
+			for _, app := range got {
+				names = append(names, app.Name)
+			}
+			if len(names) != len(tt.want) {
+				t.Fatalf("got %v, want %v", names, tt.want)
+			}
+			for i := range names {
+				if names[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", names, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/docker/api/types/registry"
+)
+
+// defaultRegistryHost Docker Hub 在凭证中的主机名
+const defaultRegistryHost = "docker.io"
+
+// RegistryCredential 单个镜像仓库的凭证
+type RegistryCredential struct {
+	Host          string // 仓库主机名，如 registry.example.com:5000
+	Username      string
+	Password      string
+	IdentityToken string
+}
+
+// RegistryAuthResolver 按仓库主机解析推送凭证
+// 优先使用显式配置的凭证，其次是 Docker config.json 中的 credHelpers/credsStore 和 auths
+type RegistryAuthResolver struct {
+	credentials map[string]RegistryCredential
+	dockerCfg   *dockerConfigFile
+}
+
+// dockerConfigFile Docker CLI config.json 中与认证相关的部分
+type dockerConfigFile struct {
+	Auths       map[string]dockerAuthEntry `json:"auths"`
+	CredsStore  string                     `json:"credsStore"`
+	CredHelpers map[string]string          `json:"credHelpers"`
+}
+
+type dockerAuthEntry struct {
+	Auth          string `json:"auth"`
+	Username      string `json:"username"`
+	Password      string `json:"password"`
+	IdentityToken string `json:"identitytoken"`
+}
+
+// NewRegistryAuthResolver 创建凭证解析器
+// dockerConfigPath 为空时使用 $DOCKER_CONFIG/config.json 或 ~/.docker/config.json，文件不存在时忽略
+func NewRegistryAuthResolver(credentials []RegistryCredential, dockerConfigPath string) (*RegistryAuthResolver, error) {
+	r := &RegistryAuthResolver{
+		credentials: make(map[string]RegistryCredential),
+	}
+	for _, cred := range credentials {
+		if cred.Host == "" {
+			return nil, fmt.Errorf("registry credential without host")
+		}
+		r.credentials[normalizeRegistryHost(cred.Host)] = cred
+	}
+
+	if dockerConfigPath == "" {
+		dockerConfigPath = defaultDockerConfigPath()
+	}
+	if dockerConfigPath != "" {
+		data, err := os.ReadFile(dockerConfigPath)
+		switch {
+		case err == nil:
+			var cfg dockerConfigFile
+			if err := json.Unmarshal(data, &cfg); err != nil {
+				return nil, fmt.Errorf("failed to parse docker config %s: %w", dockerConfigPath, err)
+			}
+			r.dockerCfg = &cfg
+		case !os.IsNotExist(err):
+			return nil, fmt.Errorf("failed to read docker config %s: %w", dockerConfigPath, err)
+		}
+	}
+
+	return r, nil
+}
+
+func defaultDockerConfigPath() string {
+	if dir := os.Getenv("DOCKER_CONFIG"); dir != "" {
+		return filepath.Join(dir, "config.json")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".docker", "config.json")
+}
+
+// Resolve 返回推送到指定仓库主机所需的认证信息，未找到凭证时返回空认证
+func (r *RegistryAuthResolver) Resolve(host string) (registry.AuthConfig, error) {
+	host = normalizeRegistryHost(host)
+	auth := registry.AuthConfig{ServerAddress: host}
+	if r == nil {
+		return auth, nil
+	}
+
+	if cred, ok := r.credentials[host]; ok {
+		auth.Username = cred.Username
+		auth.Password = cred.Password
+		auth.IdentityToken = cred.IdentityToken
+		return auth, nil
+	}
+
+	if r.dockerCfg == nil {
+		return auth, nil
+	}
+
+	helper := r.dockerCfg.CredsStore
+	if h, ok := r.dockerCfg.CredHelpers[host]; ok {
+		helper = h
+	}
+	if helper != "" {
+		username, secret, err := credentialHelperGet(helper, host)
+		if err != nil {
+			return auth, err
+		}
+		if username != "" || secret != "" {
+			// 凭证助手以 <token> 作为用户名表示 identity token
+			if username == "<token>" {
+				auth.IdentityToken = secret
+			} else {
+				auth.Username = username
+				auth.Password = secret
+			}
+			return auth, nil
+		}
+	}
+
+	for key, entry := range r.dockerCfg.Auths {
+		if normalizeRegistryHost(key) != host {
+			continue
+		}
+		auth.Username = entry.Username
+		auth.Password = entry.Password
+		auth.IdentityToken = entry.IdentityToken
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return auth, fmt.Errorf("invalid auth for %s in docker config: %w", key, err)
+			}
+			user, pass, ok := strings.Cut(string(decoded), ":")
+			if !ok {
+				return auth, fmt.Errorf("invalid auth for %s in docker config", key)
+			}
+			auth.Username = user
+			auth.Password = pass
+		}
+		break
+	}
+	return auth, nil
+}
+
+// EncodedAuth 返回 Docker API 需要的 base64 编码认证串
+func (r *RegistryAuthResolver) EncodedAuth(host string) (string, error) {
+	auth, err := r.Resolve(host)
+	if err != nil {
+		return "", err
+	}
+	return registry.EncodeAuthConfig(auth)
+}
+
+// credentialHelperGet 调用 docker-credential-<helper> get 获取凭证
+// 凭证不存在时返回空值而不是错误
+func credentialHelperGet(helper, host string) (string, string, error) {
+	cmd := exec.Command("docker-credential-"+helper, "get")
+	cmd.Stdin = strings.NewReader(host)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		msg := strings.TrimSpace(stdout.String() + stderr.String())
+		if strings.Contains(msg, "credentials not found") {
+			return "", "", nil
+		}
+		return "", "", fmt.Errorf("credential helper %s failed for %s: %s: %w", helper, host, msg, err)
+	}
+
+	var resp struct {
+		Username string `json:"Username"`
+		Secret   string `json:"Secret"`
+	}
+	if err := json.Unmarshal(stdout.Bytes(), &resp); err != nil {
+		return "", "", fmt.Errorf("invalid output from credential helper %s: %w", helper, err)
+	}
+	return resp.Username, resp.Secret, nil
+}
+
+// normalizeRegistryHost 统一仓库主机名，去掉协议和路径，Docker Hub 的各种别名归一为 docker.io
+func normalizeRegistryHost(host string) string {
+	host = strings.TrimSpace(host)
+	host = strings.TrimPrefix(host, "https://")
+	host = strings.TrimPrefix(host, "http://")
+	if i := strings.Index(host, "/"); i >= 0 {
+		host = host[:i]
+	}
+	switch host {
+	case "", "index.docker.io", "registry-1.docker.io", "docker.io":
+		return defaultRegistryHost
+	}
+	return host
+}
+
+// registryHostOf 从镜像引用中解析仓库主机名
+// 与 Docker 的规则一致：第一段包含 . 或 : 或为 localhost 时视为主机名，否则为 Docker Hub
+func registryHostOf(imageRef string) string {
+	first, _, ok := strings.Cut(imageRef, "/")
+	if !ok {
+		return defaultRegistryHost
+	}
+	if strings.ContainsAny(first, ".:") || first == "localhost" {
+		return normalizeRegistryHost(first)
+	}
+	return defaultRegistryHost
+}
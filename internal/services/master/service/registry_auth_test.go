@@ -0,0 +1,64 @@
+package service
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRegistryAuthResolver_Resolve(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config.json")
+	auth := base64.StdEncoding.EncodeToString([]byte("hub-user:hub-pass"))
+	content := `{"auths": {
+		"https://index.docker.io/v1/": {"auth": "` + auth + `"},
+		"registry.example.com": {"auth": "` + base64.StdEncoding.EncodeToString([]byte("file-user:file-pass")) + `"}
+	}}`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewRegistryAuthResolver([]RegistryCredential{
+		{Host: "registry.example.com", Username: "robot", Password: "secret"},
+	}, configPath)
+	if err != nil {
+		t.Fatalf("NewRegistryAuthResolver: %v", err)
+	}
+
+	tests := []struct {
+		image    string
+		username string
+		password string
+	}{
+		{"registry.example.com/team/api:v1", "robot", "secret"},
+		{"library/nginx:1.25", "hub-user", "hub-pass"},
+		{"localhost:5000/api:v1", "", ""},
+	}
+	for _, tt := range tests {
+		got, err := r.Resolve(registryHostOf(tt.image))
+		if err != nil {
+			t.Fatalf("Resolve(%s): %v", tt.image, err)
+		}
+		if got.Username != tt.username || got.Password != tt.password {
+			t.Errorf("Resolve(%s) = %s/%s, want %s/%s", tt.image, got.Username, got.Password, tt.username, tt.password)
+		}
+	}
+}
+
+func TestParsePushOutput(t *testing.T) {
+	ok := `{"status":"Pushing"}
+{"status":"v1: digest: sha256:abc size: 1234"}
+{"aux":{"Tag":"v1","Digest":"sha256:abc","Size":1234}}`
+	digest, err := parsePushOutput(strings.NewReader(ok))
+	if err != nil || digest != "sha256:abc" {
+		t.Fatalf("parsePushOutput = %q, %v", digest, err)
+	}
+
+	failed := `{"status":"Pushing"}
+{"errorDetail":{"message":"unauthorized"},"error":"unauthorized"}`
+	if _, err := parsePushOutput(strings.NewReader(failed)); err == nil {
+		t.Fatal("expected error for failed push")
+	}
+}
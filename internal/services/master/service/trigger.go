@@ -5,9 +5,13 @@ import (
 	"fmt"
 	"log"
 	"path/filepath"
+	"strings"
+	"sync"
+	"time"
 
 	"github.com/boreas/internal/interfaces"
 	"github.com/boreas/internal/pkg/models"
+	"github.com/google/uuid"
 )
 
 type TriggerConfig struct {
@@ -15,32 +19,56 @@ type TriggerConfig struct {
 	WorkDir        string
 	DockerRegistry string
 
+	RegistryCredentials []RegistryCredential // 按仓库主机配置的推送凭证
+	DockerConfigPath    string               // Docker config.json 路径，为空时使用默认位置
+
+	BuildConcurrency int           // 并行构建的应用数，<=0 时为 1
+	BuildTimeout     time.Duration // 单次 tag 所有应用构建共享的截止时间，0 表示不限制
+	AppBuildTimeout  time.Duration // 单个应用构建+推送的超时，0 表示不限制
+
 	Apps    interfaces.ApplicationService
 	Version interfaces.VersionService
 }
 
+// imageBuilder 构建并推送应用镜像，由 DockerService 实现
+type imageBuilder interface {
+	BuildImage(ctx context.Context, appPath, appName, tag string, buildConfig *models.BuildConfig, buildLog *BuildLog) (string, error)
+	PushImage(ctx context.Context, imageName string) (string, error)
+	Close() error
+}
+
 type TriggerService struct {
-	config *TriggerConfig
-	git    *GitService
-	docker *DockerService
+	config    *TriggerConfig
+	git       *GitService
+	docker    imageBuilder // Docker 不可用时为 nil
+	buildLogs *BuildLogStore
 
 	interfaces.ApplicationService
 	interfaces.VersionService
 }
 
-func NewTriggerService(config *TriggerConfig) *TriggerService {
-	dockerService, err := NewDockerService(config.DockerRegistry)
+// NewTriggerService 创建触发服务，镜像仓库凭证无法加载时返回错误，避免以未认证的方式推送
+func NewTriggerService(config *TriggerConfig) (*TriggerService, error) {
+	auth, err := NewRegistryAuthResolver(config.RegistryCredentials, config.DockerConfigPath)
 	if err != nil {
+		return nil, fmt.Errorf("failed to load registry credentials: %w", err)
+	}
+
+	var docker imageBuilder
+	if dockerService, err := NewDockerService(config.DockerRegistry, auth); err != nil {
 		log.Printf("Warning: Failed to create docker service: %v", err)
+	} else {
+		docker = dockerService
 	}
 
 	return &TriggerService{
 		config:             config,
 		git:                NewGitService(config.WorkDir),
-		docker:             dockerService,
+		docker:             docker,
+		buildLogs:          NewBuildLogStore(filepath.Join(config.WorkDir, "build-logs")),
 		ApplicationService: config.Apps,
 		VersionService:     config.Version,
-	}
+	}, nil
 }
 
 type TagEvent struct {
@@ -54,8 +82,32 @@ type ProcessResult struct {
 	Message        string            `json:"message"`
 	TriggerCreated bool              `json:"Trigger_created"`
 	TriggerID      string            `json:"Trigger_id,omitempty"`
+	BuildID        string            `json:"build_id,omitempty"` // 版本创建前构建日志的目录，见 BuildLogStore
 	AppsBuilt      []models.AppBuild `json:"apps_built,omitempty"`
+	Builds         []AppBuildResult  `json:"builds,omitempty"`
 	Errors         []string          `json:"errors,omitempty"`
+
+	DeploymentsTriggered []models.DeploymentReference `json:"deployments_triggered,omitempty"`
+}
+
+// AppBuildStatus 单个应用的构建结果状态
+type AppBuildStatus string
+
+const (
+	AppBuildStatusSuccess   AppBuildStatus = "success"   // 构建并推送成功
+	AppBuildStatusFailed    AppBuildStatus = "failed"    // 构建或推送失败
+	AppBuildStatusCancelled AppBuildStatus = "cancelled" // 超时或被取消，未完成
+)
+
+// AppBuildResult 单个应用的构建结果汇总
+type AppBuildResult struct {
+	AppID    string         `json:"app_id"`
+	AppName  string         `json:"app_name"`
+	Status   AppBuildStatus `json:"status"`
+	Image    string         `json:"image,omitempty"`
+	Digest   string         `json:"digest,omitempty"`
+	Error    string         `json:"error,omitempty"`
+	Duration float64        `json:"duration"` // 秒
 }
 
 func (v *TriggerService) ProcessTagEvent(ctx context.Context, event *TagEvent) (*ProcessResult, error) {
@@ -77,7 +129,12 @@ func (v *TriggerService) ProcessTagEvent(ctx context.Context, event *TagEvent) (
 	}
 
 	log.Printf("Step 3: Getting changed apps (diff from %s to %s)", previousTag, event.TagName)
-	changedApps, err := v.git.GetChangedApps(ctx, repoPath, previousTag, event.TagName)
+	repoApps, err := v.listRepositoryApps(ctx, event.Repository)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list applications: %w", err)
+	}
+
+	changedApps, err := v.git.GetChangedApps(ctx, repoPath, previousTag, event.TagName, repoApps)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get changed apps: %w", err)
 	}
@@ -87,55 +144,32 @@ func (v *TriggerService) ProcessTagEvent(ctx context.Context, event *TagEvent) (
 		return result, nil
 	}
 
-	log.Printf("Found %d changed apps: %v", len(changedApps), changedApps)
+	changedNames := make([]string, 0, len(changedApps))
+	for _, app := range changedApps {
+		changedNames = append(changedNames, app.Name)
+	}
+	log.Printf("Found %d changed apps: %v", len(changedApps), changedNames)
+
+	log.Printf("Step 4: Building %d apps (concurrency %d)", len(changedApps), v.buildConcurrency())
+	// 构建日志先写入本次触发独有的目录，tag 可能包含 /，不同仓库也可能同时推送同名 tag
+	result.BuildID = "build-" + uuid.New().String()
+	log.Printf("Build logs of tag %s are written to %s", event.TagName, result.BuildID)
+	builds := v.buildApps(ctx, repoPath, event.TagName, result.BuildID, changedApps)
+	result.Builds = builds
 
 	var builtApps []models.AppBuild
 	var buildErrors []string
-
-	for _, appName := range changedApps {
-		log.Printf("Step 4.%d: Processing app %s", len(builtApps)+1, appName)
-
-		app, err := v.GetApplication(ctx, appName)
-		if err != nil {
-			errMsg := fmt.Sprintf("Failed to get app config for %s: %v", appName, err)
-			log.Printf("Warning: %s", errMsg)
-			buildErrors = append(buildErrors, errMsg)
+	for _, build := range builds {
+		if build.Status != AppBuildStatusSuccess {
+			buildErrors = append(buildErrors, fmt.Sprintf("%s %s: %s", build.AppName, build.Status, build.Error))
 			continue
 		}
-
-		if v.docker == nil {
-			errMsg := fmt.Sprintf("Docker service not available for app %s", appName)
-			log.Printf("Warning: %s", errMsg)
-			buildErrors = append(buildErrors, errMsg)
-			continue
-		}
-
-		appPath := filepath.Join(repoPath, appName)
-		log.Printf("Building docker image for %s at %s", appName, appPath)
-
-		imageName, err := v.docker.BuildImage(ctx, appPath, appName, event.TagName, app.GetBuildConfig())
-		if err != nil {
-			errMsg := fmt.Sprintf("Failed to build image for %s: %v", appName, err)
-			log.Printf("Error: %s", errMsg)
-			buildErrors = append(buildErrors, errMsg)
-			continue
-		}
-
-		log.Printf("Pushing image %s", imageName)
-		err = v.docker.PushImage(ctx, imageName)
-		if err != nil {
-			errMsg := fmt.Sprintf("Failed to push image for %s: %v", appName, err)
-			log.Printf("Error: %s", errMsg)
-			buildErrors = append(buildErrors, errMsg)
-			continue
-		}
-
 		builtApps = append(builtApps, models.AppBuild{
-			AppName:     appName,
-			DockerImage: imageName,
+			AppID:       build.AppID,
+			AppName:     build.AppName,
+			DockerImage: build.Image,
+			Digest:      build.Digest,
 		})
-
-		log.Printf("Successfully built and pushed %s", imageName)
 	}
 
 	if len(buildErrors) > 0 {
@@ -148,6 +182,7 @@ func (v *TriggerService) ProcessTagEvent(ctx context.Context, event *TagEvent) (
 
 	log.Printf("Step 5: Creating Trigger in management system")
 	TriggerReq := &models.CreateVersionRequest{
+		Version:    event.TagName,
 		GitTag:     event.TagName,
 		GitCommit:  event.Commit,
 		Repository: event.Repository,
@@ -159,9 +194,15 @@ func (v *TriggerService) ProcessTagEvent(ctx context.Context, event *TagEvent) (
 		return nil, fmt.Errorf("failed to create Trigger: %w", err)
 	}
 
+	// 构建日志改为按版本 ID 提供，与其他 /versions/:version 路由一致
+	if err := v.buildLogs.Rename(result.BuildID, Trigger.ID); err != nil {
+		log.Printf("Warning: Failed to move build logs to version %s: %v", Trigger.ID, err)
+	}
+
 	result.TriggerCreated = true
 	result.TriggerID = Trigger.ID
 	result.AppsBuilt = builtApps
+	result.DeploymentsTriggered = Trigger.DeploymentsTriggered
 	result.Message = fmt.Sprintf("Successfully created Trigger %s with %d apps", Trigger.ID, len(builtApps))
 
 	log.Printf("Process completed: %s", result.Message)
@@ -169,6 +210,149 @@ func (v *TriggerService) ProcessTagEvent(ctx context.Context, event *TagEvent) (
 	return result, nil
 }
 
+// BuildLogs 返回构建日志存储
+func (v *TriggerService) BuildLogs() *BuildLogStore {
+	return v.buildLogs
+}
+
+func (v *TriggerService) buildConcurrency() int {
+	if v.config.BuildConcurrency <= 0 {
+		return 1
+	}
+	return v.config.BuildConcurrency
+}
+
+// buildApps 使用有界 worker 池并行构建并推送应用镜像，构建日志写入 buildID 目录
+// 所有应用共享 BuildTimeout 截止时间，每个应用有独立的可取消 context；结果顺序与 apps 一致
+func (v *TriggerService) buildApps(ctx context.Context, repoPath, tag, buildID string, apps []*models.Application) []AppBuildResult {
+	if v.config.BuildTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, v.config.BuildTimeout)
+		defer cancel()
+	}
+
+	results := make([]AppBuildResult, len(apps))
+	sem := make(chan struct{}, v.buildConcurrency())
+	var wg sync.WaitGroup
+
+	for i, app := range apps {
+		results[i] = AppBuildResult{
+			AppID:   app.ID,
+			AppName: app.Name,
+		}
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Status = AppBuildStatusCancelled
+			results[i].Error = ctx.Err().Error()
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int, app *models.Application) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			v.buildApp(ctx, repoPath, tag, buildID, app, &results[i])
+		}(i, app)
+	}
+
+	wg.Wait()
+	return results
+}
+
+// buildApp 构建并推送单个应用，结果写入 result
+func (v *TriggerService) buildApp(ctx context.Context, repoPath, tag, buildID string, app *models.Application, result *AppBuildResult) {
+	start := time.Now()
+	defer func() {
+		result.Duration = time.Since(start).Seconds()
+	}()
+
+	var appCtx context.Context
+	var cancel context.CancelFunc
+	if v.config.AppBuildTimeout > 0 {
+		appCtx, cancel = context.WithTimeout(ctx, v.config.AppBuildTimeout)
+	} else {
+		appCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel()
+
+	buildLog, err := v.buildLogs.Open(buildID, app.Name)
+	if err != nil {
+		log.Printf("Warning: Failed to open build log for %s: %v", app.Name, err)
+	}
+	defer func() {
+		buildLog.Close(result.Status)
+	}()
+
+	fail := func(err error) {
+		result.Status = AppBuildStatusFailed
+		if appCtx.Err() != nil {
+			result.Status = AppBuildStatusCancelled
+		}
+		result.Error = err.Error()
+		buildLog.Append(models.BuildLogEntryError, err.Error())
+		log.Printf("Error: app %s %s: %v", app.Name, result.Status, err)
+	}
+
+	if v.docker == nil {
+		fail(fmt.Errorf("docker service not available"))
+		return
+	}
+
+	appPath := filepath.Join(repoPath, app.GetPathConfig().Root)
+	log.Printf("Building docker image for %s at %s", app.Name, appPath)
+
+	imageName, err := v.docker.BuildImage(appCtx, appPath, app.Name, tag, app.GetBuildConfig(), buildLog)
+	if err != nil {
+		fail(fmt.Errorf("failed to build image: %w", err))
+		return
+	}
+
+	log.Printf("Pushing image %s", imageName)
+	digest, err := v.docker.PushImage(appCtx, imageName)
+	if err != nil {
+		fail(fmt.Errorf("failed to push image: %w", err))
+		return
+	}
+
+	result.Status = AppBuildStatusSuccess
+	result.Image = imageName
+	result.Digest = digest
+	log.Printf("Successfully built and pushed %s@%s", imageName, digest)
+}
+
+// listRepositoryApps 列出属于指定仓库的所有应用
+func (v *TriggerService) listRepositoryApps(ctx context.Context, repository string) ([]*models.Application, error) {
+	var apps []*models.Application
+	for page := 1; ; page++ {
+		resp, err := v.GetApplicationList(ctx, &models.ListApplicationsRequest{
+			Page:     page,
+			PageSize: 100,
+		})
+		if err != nil {
+			return nil, err
+		}
+		for _, app := range resp.Applications {
+			if sameRepository(app.Repository, repository) {
+				apps = append(apps, app)
+			}
+		}
+		if len(resp.Applications) == 0 || page*resp.PageSize >= resp.Total {
+			break
+		}
+	}
+	return apps, nil
+}
+
+// sameRepository 比较仓库地址，忽略 .git 后缀和末尾的斜杠
+func sameRepository(a, b string) bool {
+	normalize := func(s string) string {
+		return strings.TrimSuffix(strings.TrimSuffix(strings.TrimSpace(s), "/"), ".git")
+	}
+	return normalize(a) == normalize(b)
+}
+
 func (v *TriggerService) Close() error {
 	if v.docker != nil {
 		return v.docker.Close()
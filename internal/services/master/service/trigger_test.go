@@ -2,7 +2,12 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
 )
 
 func TestVersionService_ProcessTagEvent(t *testing.T) {
@@ -11,7 +16,10 @@ func TestVersionService_ProcessTagEvent(t *testing.T) {
 		DockerRegistry: "registry.example.com",
 	}
 
-	service := NewTriggerService(config)
+	service, err := NewTriggerService(config)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	event := &TagEvent{
 		TagName:    "v1.0.0",
@@ -31,3 +39,167 @@ func TestVersionService_ProcessTagEvent(t *testing.T) {
 		t.Logf("Result: %+v", result)
 	}
 }
+
+// stubBuilder 以函数模拟镜像构建，推送直接返回
+type stubBuilder struct {
+	build func(ctx context.Context, appName string) (string, error)
+}
+
+func (b *stubBuilder) BuildImage(ctx context.Context, appPath, appName, tag string, buildConfig *models.BuildConfig, buildLog *BuildLog) (string, error) {
+	return b.build(ctx, appName)
+}
+
+func (b *stubBuilder) PushImage(ctx context.Context, imageName string) (string, error) {
+	return "sha256:" + imageName, ctx.Err()
+}
+
+func (b *stubBuilder) Close() error { return nil }
+
+func newBuildTestService(t *testing.T, config *TriggerConfig, build func(ctx context.Context, appName string) (string, error)) *TriggerService {
+	t.Helper()
+	return &TriggerService{
+		config:    config,
+		docker:    &stubBuilder{build: build},
+		buildLogs: NewBuildLogStore(t.TempDir()),
+	}
+}
+
+func testApps(n int) []*models.Application {
+	apps := make([]*models.Application, n)
+	for i := range apps {
+		apps[i] = &models.Application{ID: fmt.Sprintf("app-%d", i), Name: fmt.Sprintf("app%d", i)}
+	}
+	return apps
+}
+
+// waitOrCancel 模拟耗时 d 的构建，ctx 结束时提前返回
+func waitOrCancel(ctx context.Context, d time.Duration) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(d):
+		return nil
+	}
+}
+
+func TestBuildApps_BoundedConcurrencyKeepsOrder(t *testing.T) {
+	var mu sync.Mutex
+	running, maxRunning := 0, 0
+	apps := testApps(6)
+	svc := newBuildTestService(t, &TriggerConfig{BuildConcurrency: 2}, func(ctx context.Context, appName string) (string, error) {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			running--
+			mu.Unlock()
+		}()
+		// 排在前面的应用构建得更久，完成顺序与输入顺序相反
+		var i int
+		fmt.Sscanf(appName, "app%d", &i)
+		if err := waitOrCancel(ctx, time.Duration(len(apps)-i)*5*time.Millisecond); err != nil {
+			return "", err
+		}
+		return "registry.example.com/" + appName + ":v1", nil
+	})
+
+	results := svc.buildApps(context.Background(), t.TempDir(), "v1", "build-1", apps)
+	if maxRunning != 2 {
+		t.Errorf("max concurrent builds = %d, want 2", maxRunning)
+	}
+	for i, r := range results {
+		if r.AppID != apps[i].ID || r.Status != AppBuildStatusSuccess || r.Image != "registry.example.com/"+apps[i].Name+":v1" {
+			t.Errorf("results[%d] = %+v, want a successful build of %s", i, r, apps[i].Name)
+		}
+	}
+}
+
+func TestBuildApps_BuildTimeoutCancelsRemainingBuilds(t *testing.T) {
+	apps := testApps(3)
+	svc := newBuildTestService(t, &TriggerConfig{BuildConcurrency: 1, BuildTimeout: 50 * time.Millisecond}, func(ctx context.Context, appName string) (string, error) {
+		return "", waitOrCancel(ctx, time.Minute)
+	})
+
+	start := time.Now()
+	results := svc.buildApps(context.Background(), t.TempDir(), "v1", "build-1", apps)
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("buildApps took %s after the build timeout", elapsed)
+	}
+	for i, r := range results {
+		if r.AppID != apps[i].ID || r.Status != AppBuildStatusCancelled || r.Error == "" {
+			t.Errorf("results[%d] = %+v, want cancelled", i, r)
+		}
+	}
+}
+
+func TestBuildApps_AppBuildTimeoutOnlyCancelsThatApp(t *testing.T) {
+	apps := testApps(2)
+	svc := newBuildTestService(t, &TriggerConfig{BuildConcurrency: 2, AppBuildTimeout: 50 * time.Millisecond}, func(ctx context.Context, appName string) (string, error) {
+		if appName == "app0" {
+			return "", waitOrCancel(ctx, time.Minute)
+		}
+		return "registry.example.com/" + appName + ":v1", nil
+	})
+
+	results := svc.buildApps(context.Background(), t.TempDir(), "v1", "build-1", apps)
+	if results[0].Status != AppBuildStatusCancelled {
+		t.Errorf("results[0] = %+v, want cancelled by the app build timeout", results[0])
+	}
+	if results[1].Status != AppBuildStatusSuccess {
+		t.Errorf("results[1] = %+v, want success", results[1])
+	}
+}
+
+func TestBuildApps_LogsKeyedByBuildID(t *testing.T) {
+	apps := testApps(1)
+	var mu sync.Mutex
+	var tags []string
+	svc := newBuildTestService(t, &TriggerConfig{BuildConcurrency: 1}, func(ctx context.Context, appName string) (string, error) {
+		return "registry.example.com/" + appName + ":release-v1", nil
+	})
+	svc.docker = &tagRecorder{imageBuilder: svc.docker, record: func(tag string) {
+		mu.Lock()
+		tags = append(tags, tag)
+		mu.Unlock()
+	}}
+
+	// 两个仓库同时推送包含 / 的同名 tag，日志互不覆盖
+	var wg sync.WaitGroup
+	results := make([][]AppBuildResult, 2)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i] = svc.buildApps(context.Background(), t.TempDir(), "release/v1", fmt.Sprintf("build-%d", i), apps)
+		}(i)
+	}
+	wg.Wait()
+
+	for i, r := range results {
+		if r[0].Status != AppBuildStatusSuccess {
+			t.Fatalf("build %d = %+v", i, r[0])
+		}
+		entries, running, err := svc.buildLogs.Read(fmt.Sprintf("build-%d", i), apps[0].Name)
+		if err != nil || running || len(entries) == 0 || entries[len(entries)-1].Message != string(AppBuildStatusSuccess) {
+			t.Errorf("logs of build-%d = %+v, running %v, %v", i, entries, running, err)
+		}
+	}
+	if len(tags) != 2 || tags[0] != "release/v1" {
+		t.Errorf("images built with tags %v, want the git tag", tags)
+	}
+}
+
+// tagRecorder 记录构建使用的 tag
+type tagRecorder struct {
+	imageBuilder
+	record func(tag string)
+}
+
+func (r *tagRecorder) BuildImage(ctx context.Context, appPath, appName, tag string, buildConfig *models.BuildConfig, buildLog *BuildLog) (string, error) {
+	r.record(tag)
+	return r.imageBuilder.BuildImage(ctx, appPath, appName, tag, buildConfig, buildLog)
+}
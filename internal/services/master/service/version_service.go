@@ -7,20 +7,33 @@ import (
 	"time"
 
 	"github.com/boreas/internal/interfaces"
+	"github.com/boreas/internal/pkg/logger"
 	"github.com/boreas/internal/pkg/models"
 	"github.com/boreas/internal/pkg/utils"
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 )
 
 type versionService struct {
 	versionRepo interfaces.VersionRepository
+
+	autoDeploy *autoDeployer
 }
 
 // NewVersionService 创建版本服务
-func NewVersionService(versionRepo interfaces.VersionRepository) interfaces.VersionService {
-	return &versionService{
+// envRepo 和 deploymentService 用于版本创建后评估环境的自动部署规则，为 nil 时不自动部署
+func NewVersionService(
+	versionRepo interfaces.VersionRepository,
+	envRepo interfaces.EnvironmentRepository,
+	deploymentService interfaces.DeploymentService,
+) interfaces.VersionService {
+	s := &versionService{
 		versionRepo: versionRepo,
 	}
+	if envRepo != nil && deploymentService != nil {
+		s.autoDeploy = newAutoDeployer(versionRepo, envRepo, deploymentService)
+	}
+	return s
 }
 
 func (s *versionService) CreateVersion(ctx context.Context, req *models.CreateVersionRequest) (*models.Version, error) {
@@ -67,9 +80,33 @@ func (s *versionService) CreateVersion(ctx context.Context, req *models.CreateVe
 		return nil, fmt.Errorf("failed to create version: %w", err)
 	}
 
+	// 评估自动部署规则，失败不影响版本创建
+	deployments, err := s.TriggerAutoDeployments(ctx, version.ID)
+	if err != nil {
+		logger.GetLogger().Error("failed to evaluate auto deploy rules",
+			zap.String("version", version.Version),
+			zap.Error(err),
+		)
+	}
+	for _, d := range deployments {
+		version.DeploymentsTriggered = append(version.DeploymentsTriggered, models.DeploymentReference{
+			DeploymentID:  d.ID,
+			EnvironmentID: d.EnvironmentID,
+			Status:        d.Status,
+		})
+	}
+
 	return version, nil
 }
 
+// TriggerAutoDeployments 对所有激活环境评估自动部署规则，为命中的环境创建部署；未配置自动部署时不创建部署
+func (s *versionService) TriggerAutoDeployments(ctx context.Context, versionID string) ([]*models.Deployment, error) {
+	if s.autoDeploy == nil {
+		return nil, nil
+	}
+	return s.autoDeploy.TriggerAutoDeployments(ctx, versionID)
+}
+
 func (s *versionService) GetVersionList(ctx context.Context, req *models.ListVersionsRequest) (*models.VersionListResponse, error) {
 	// 设置默认值
 	if req.Page <= 0 {
@@ -22,17 +22,20 @@ type workflowController struct {
 	taskRepo       interfaces.TaskRepository
 	deploymentRepo interfaces.DeploymentRepository
 	versionRepo    interfaces.VersionRepository
+	operators      interfaces.OperatorManager
 	config         WorkflowConfig
 	ctx            context.Context
 	cancel         context.CancelFunc
 	wg             sync.WaitGroup
 	log            *zap.Logger
+	wake           chan struct{} // 收到 Operator 状态回调时唤醒调度器，不必等到下一个周期
 }
 
 func NewWorkflowController(
 	taskRepo interfaces.TaskRepository,
 	deploymentRepo interfaces.DeploymentRepository,
 	versionRepo interfaces.VersionRepository,
+	operators interfaces.OperatorManager,
 	config WorkflowConfig,
 	log *zap.Logger,
 ) *workflowController {
@@ -52,10 +55,24 @@ func NewWorkflowController(
 		taskRepo:       taskRepo,
 		deploymentRepo: deploymentRepo,
 		versionRepo:    versionRepo,
+		operators:      operators,
 		config:         config,
 		ctx:            ctx,
 		cancel:         cancel,
 		log:            log,
+		wake:           make(chan struct{}, 1),
+	}
+}
+
+// Wake 应用在环境中的状态发生变化，立即调度等待中的部署任务
+func (wc *workflowController) Wake(environmentID, app string) {
+	wc.log.Debug("Workflow woken by status change",
+		zap.String("environment_id", environmentID),
+		zap.String("app", app))
+	select {
+	case wc.wake <- struct{}{}:
+	default:
+		// 已有未处理的唤醒，合并
 	}
 }
 
@@ -218,6 +235,8 @@ func (wc *workflowController) pendingTaskScheduler() {
 			return
 		case <-ticker.C:
 			wc.processPendingTasks()
+		case <-wc.wake:
+			wc.processPendingTasks()
 		}
 	}
 }
@@ -286,6 +305,15 @@ func (wc *workflowController) executeTask(ctx context.Context, task *models.Task
 		}
 	}
 
+	// 环境的 Operator 熔断时暂停部署任务，保持当前状态等待恢复，而不是判定失败
+	if task.Type == models.TaskTypeDeploy && wc.operators != nil &&
+		wc.operators.IsCircuitOpen(task.Deployment.EnvironmentID) {
+		wc.log.Warn("Operator circuit open, task paused",
+			zap.String("task_id", task.ID),
+			zap.String("environment_id", task.Deployment.EnvironmentID))
+		return nil
+	}
+
 	// 依赖都完成了，可以执行任务
 	task.Step = models.TaskStepRunning
 	task.Status = models.TaskStatusRunning
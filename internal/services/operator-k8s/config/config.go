@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/spf13/viper"
 )
 
@@ -14,6 +15,8 @@ type Config struct {
 	Server ServerConfig `mapstructure:"server"`
 	Log    LogConfig    `mapstructure:"log"`
 	K8s    K8sConfig    `mapstructure:"k8s"`
+
+	Callback operatorapi.CallbackConfig `mapstructure:"callback"` // 向 Master 推送状态回调
 }
 
 // ServerConfig 服务器配置
@@ -32,12 +35,16 @@ type LogConfig struct {
 // K8sConfig Kubernetes配置
 type K8sConfig struct {
 	ConfigPath  string            `mapstructure:"config_path"`
-	Namespace   string            `mapstructure:"namespace"`
-	Context     string            `mapstructure:"context"`
+	Namespace   string            `mapstructure:"namespace"`    // 默认命名空间
+	Context     string            `mapstructure:"context"`      // 默认 kubeconfig 上下文，为空时使用 current-context
+	Clusters    []string          `mapstructure:"clusters"`     // 允许使用的上下文，为空时允许 kubeconfig 中的所有上下文
+	Namespaces  []string          `mapstructure:"namespaces"`   // 允许使用的命名空间，为空时不限制
 	Timeout     int               `mapstructure:"timeout"`      // 操作超时（秒）
 	RetryCount  int               `mapstructure:"retry_count"`  // 重试次数
 	HealthCheck HealthCheckConfig `mapstructure:"health_check"` // 健康检查配置
 	Deployment  DeploymentConfig  `mapstructure:"deployment"`   // 部署配置
+	Renderer    RendererConfig    `mapstructure:"renderer"`     // Helm/Kustomize 部署包的渲染命令
+	Controller  ControllerConfig  `mapstructure:"controller"`   // BoreasApplication 控制器模式
 	Config      map[string]string `mapstructure:"config"`       // 自定义配置
 }
 
@@ -47,6 +54,20 @@ type HealthCheckConfig struct {
 	Timeout  int `mapstructure:"timeout"`  // 超时时间（秒）
 }
 
+// RendererConfig 清单渲染命令配置
+type RendererConfig struct {
+	Helm      string   `mapstructure:"helm"`      // helm 可执行文件
+	Kustomize string   `mapstructure:"kustomize"` // kustomize 可执行文件，设置为 kubectl 时使用 kubectl kustomize
+	Sources   []string `mapstructure:"sources"`   // 允许的 Chart/overlay 目录或仓库地址前缀，为空时不支持 helm 和 kustomize 部署包
+}
+
+// ControllerConfig BoreasApplication 控制器配置
+type ControllerConfig struct {
+	Enabled   bool   `mapstructure:"enabled"`   // 是否调和 BoreasApplication 资源
+	Namespace string `mapstructure:"namespace"` // 监听的命名空间，为空时监听所有命名空间
+	Resync    int    `mapstructure:"resync"`    // 重新调和所有资源的间隔（秒）
+}
+
 // DeploymentConfig 部署配置
 type DeploymentConfig struct {
 	Timeout         int `mapstructure:"timeout"`          // 部署超时（秒）
@@ -143,6 +164,8 @@ func setDefaults() {
 	viper.SetDefault("k8s.config_path", "")
 	viper.SetDefault("k8s.namespace", "default")
 	viper.SetDefault("k8s.context", "")
+	viper.SetDefault("k8s.clusters", []string{})
+	viper.SetDefault("k8s.namespaces", []string{})
 	viper.SetDefault("k8s.timeout", 30)
 	viper.SetDefault("k8s.retry_count", 3)
 	viper.SetDefault("k8s.health_check.interval", 60)
@@ -152,6 +175,17 @@ func setDefaults() {
 	viper.SetDefault("k8s.deployment.retry_interval", 30)
 	viper.SetDefault("k8s.deployment.status_check", 30)
 	viper.SetDefault("k8s.deployment.rollback_timeout", 180)
+	viper.SetDefault("k8s.renderer.helm", "helm")
+	viper.SetDefault("k8s.renderer.kustomize", "kustomize")
+	viper.SetDefault("k8s.controller.enabled", false)
+	viper.SetDefault("k8s.controller.namespace", "")
+	viper.SetDefault("k8s.controller.resync", 300)
+
+	// 状态回调配置
+	viper.SetDefault("callback.url", "")
+	viper.SetDefault("callback.secret", "")
+	viper.SetDefault("callback.interval", 5)
+	viper.SetDefault("callback.timeout", 600)
 }
 
 // overrideFromEnv 从环境变量覆盖配置
@@ -177,6 +211,14 @@ func overrideFromEnv(cfg *Config) {
 		cfg.Log.Output = output
 	}
 
+	// 状态回调配置
+	if url := os.Getenv("K8S_CALLBACK_URL"); url != "" {
+		cfg.Callback.URL = url
+	}
+	if secret := os.Getenv("K8S_CALLBACK_SECRET"); secret != "" {
+		cfg.Callback.Secret = secret
+	}
+
 	// K8s 配置
 	if configPath := os.Getenv("K8S_CONFIG_PATH"); configPath != "" {
 		cfg.K8s.ConfigPath = configPath
@@ -187,6 +229,12 @@ func overrideFromEnv(cfg *Config) {
 	if context := os.Getenv("K8S_CONTEXT"); context != "" {
 		cfg.K8s.Context = context
 	}
+	if clusters := os.Getenv("K8S_CLUSTERS"); clusters != "" {
+		cfg.K8s.Clusters = strings.Split(clusters, ",")
+	}
+	if namespaces := os.Getenv("K8S_NAMESPACES"); namespaces != "" {
+		cfg.K8s.Namespaces = strings.Split(namespaces, ",")
+	}
 	if timeout := os.Getenv("K8S_TIMEOUT"); timeout != "" {
 		if t, err := strconv.Atoi(timeout); err == nil {
 			cfg.K8s.Timeout = t
@@ -217,6 +265,28 @@ func overrideFromEnv(cfg *Config) {
 			cfg.K8s.Deployment.MaxConcurrent = c
 		}
 	}
+	if helm := os.Getenv("K8S_RENDERER_HELM"); helm != "" {
+		cfg.K8s.Renderer.Helm = helm
+	}
+	if kustomize := os.Getenv("K8S_RENDERER_KUSTOMIZE"); kustomize != "" {
+		cfg.K8s.Renderer.Kustomize = kustomize
+	}
+	if sources := os.Getenv("K8S_RENDERER_SOURCES"); sources != "" {
+		cfg.K8s.Renderer.Sources = strings.Split(sources, ",")
+	}
+	if enabled := os.Getenv("K8S_CONTROLLER_ENABLED"); enabled != "" {
+		if b, err := strconv.ParseBool(enabled); err == nil {
+			cfg.K8s.Controller.Enabled = b
+		}
+	}
+	if namespace := os.Getenv("K8S_CONTROLLER_NAMESPACE"); namespace != "" {
+		cfg.K8s.Controller.Namespace = namespace
+	}
+	if resync := os.Getenv("K8S_CONTROLLER_RESYNC"); resync != "" {
+		if r, err := strconv.Atoi(resync); err == nil {
+			cfg.K8s.Controller.Resync = r
+		}
+	}
 }
 
 // GetServerAddr 获取服务器地址
@@ -1,90 +1,334 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/boreas/internal/pkg/logger"
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"github.com/boreas/internal/pkg/utils"
 	"github.com/boreas/internal/services/operator-k8s/service"
 	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
 )
 
+// capabilities K8s Operator 声明的能力：每个版本一个 Deployment，按比例分配副本，支持扩缩容
+var capabilities = operatorapi.NewCapabilities(models.EnvironmentTypeKubernetes,
+	operatorapi.FeatureMultiVersion,
+	operatorapi.FeatureVersionStatus,
+	operatorapi.FeatureScale,
+)
+
+// rolloutWatchTimeout Apply 后监听滚动更新的最长时间
+const rolloutWatchTimeout = 15 * time.Minute
+
+// target 部署目标：kubeconfig 上下文和命名空间
+type target struct {
+	cluster   string
+	namespace string
+}
+
 type OperatorK8sHandler struct {
-	operatorService *service.OperatorK8sService
+	router   *service.ClusterRouter
+	notifier *operatorapi.Notifier
+
+	mu       sync.Mutex
+	rollouts map[string]context.CancelFunc // key: app，正在监听的滚动更新
+	targets  map[string]target             // key: app，最近一次 Apply 的目标，用于状态回调
 }
 
-func NewOperatorK8sHandler(operatorService *service.OperatorK8sService) *OperatorK8sHandler {
+func NewOperatorK8sHandler(router *service.ClusterRouter) *OperatorK8sHandler {
 	return &OperatorK8sHandler{
-		operatorService: operatorService,
+		router:   router,
+		rollouts: make(map[string]context.CancelFunc),
+		targets:  make(map[string]target),
 	}
 }
 
+// WithNotifier 设置状态回调推送器，Apply 成功后向 Master 推送状态变化
+func (h *OperatorK8sHandler) WithNotifier(notifier *operatorapi.Notifier) *OperatorK8sHandler {
+	h.notifier = notifier
+	return h
+}
+
 func (h *OperatorK8sHandler) RegisterRoutes(r *gin.Engine) {
-	v1 := r.Group("/v1")
+	v1 := r.Group("/" + operatorapi.Version)
+	v1.Use(operatorapi.RequireVersion())
 	{
 		v1.GET("/health", h.HealthCheck)
 		v1.GET("/ready", h.ReadyCheck)
+		v1.GET("/capabilities", h.Capabilities)
 		v1.POST("/apply", h.Apply)
 		v1.GET("/status/:app", h.GetStatus)
+		v1.DELETE("/apps/:app", h.Delete)
+		v1.POST("/scale", h.Scale)
+		v1.POST("/restart", h.Restart)
+		v1.GET("/logs/:app", h.GetLogs)
+		v1.GET("/events/:app", h.GetEvents)
+		v1.GET("/clusters", h.Clusters)
+	}
+}
+
+// service 按请求头选择部署目标，失败时写入错误响应并返回 nil
+func (h *OperatorK8sHandler) service(c *gin.Context) *service.OperatorK8sService {
+	return h.serviceFor(c, target{cluster: c.GetHeader(operatorapi.HeaderCluster), namespace: c.GetHeader(operatorapi.HeaderNamespace)})
+}
+
+func (h *OperatorK8sHandler) serviceFor(c *gin.Context, t target) *service.OperatorK8sService {
+	svc, err := h.router.Service(t.cluster, t.namespace)
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), err.Error())
+		return nil
+	}
+	return svc
+}
+
+// ApplicationStatus 查询应用在最近一次 Apply 的目标上的状态，供状态回调使用
+func (h *OperatorK8sHandler) ApplicationStatus(ctx context.Context, app string) (*models.ApplicationStatusResponse, error) {
+	h.mu.Lock()
+	t := h.targets[app]
+	h.mu.Unlock()
+
+	svc, err := h.router.Service(t.cluster, t.namespace)
+	if err != nil {
+		return nil, err
 	}
+	return svc.GetApplicationStatus(app)
 }
 
 func (h *OperatorK8sHandler) HealthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
+	utils.Success(c, gin.H{
 		"status":  "healthy",
 		"service": "operator-k8s",
 	})
 }
 
+// ReadyCheck 检查默认集群的连通性
 func (h *OperatorK8sHandler) ReadyCheck(c *gin.Context) {
-	if err := h.operatorService.CheckK8sConnection(); err != nil {
-		c.JSON(http.StatusServiceUnavailable, gin.H{
-			"status":  "not ready",
-			"service": "operator-k8s",
-			"error":   err.Error(),
-		})
+	svc, err := h.router.Service("", "")
+	if err == nil {
+		err = svc.CheckK8sConnection()
+	}
+	if err != nil {
+		utils.Error(c, http.StatusServiceUnavailable, "Service not ready: "+err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, gin.H{
+	utils.Success(c, gin.H{
 		"status":  "ready",
 		"service": "operator-k8s",
 	})
 }
 
+// Capabilities 协议版本和能力
+func (h *OperatorK8sHandler) Capabilities(c *gin.Context) {
+	utils.Success(c, capabilities)
+}
+
 func (h *OperatorK8sHandler) Apply(c *gin.Context) {
-	var req models.ApplyRequest
+	var req models.ApplyDeploymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, models.ApplyResponse{
-			Success: false,
-			Message: "Invalid request: " + err.Error(),
-		})
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if err := operatorapi.ValidateApplyRequest(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if err := operatorapi.CheckFeatures(capabilities, &req); err != nil {
+		utils.Error(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	// 请求体中的目标优先于请求头
+	t := target{cluster: req.Cluster, namespace: req.Namespace}
+	if t.cluster == "" {
+		t.cluster = c.GetHeader(operatorapi.HeaderCluster)
+	}
+	if t.namespace == "" {
+		t.namespace = c.GetHeader(operatorapi.HeaderNamespace)
+	}
+	svc := h.serviceFor(c, t)
+	if svc == nil {
 		return
 	}
 
-	resp, err := h.operatorService.Apply(&req)
+	resp, err := svc.Apply(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, models.ApplyResponse{
-			Success: false,
-			Message: "Failed to apply: " + err.Error(),
-			App:     req.App,
-			Version: req.Version,
-		})
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to apply: "+err.Error())
 		return
 	}
 
-	c.JSON(http.StatusOK, resp)
+	h.mu.Lock()
+	h.targets[req.App] = t
+	h.mu.Unlock()
+	h.notifier.ApplyFinished(req.App, resp.Message)
+	h.watchRollout(svc, t, req.App)
+	utils.Success(c, resp)
+}
+
+// watchRollout 在后台监听应用的滚动更新，失败时记录日志并推送 rollout_failed 回调
+// 同一目标上同一应用的上一次监听会被取消
+func (h *OperatorK8sHandler) watchRollout(svc *service.OperatorK8sService, t target, app string) {
+	key := t.cluster + "/" + t.namespace + "/" + app
+	ctx, cancel := context.WithTimeout(context.Background(), rolloutWatchTimeout)
+	h.mu.Lock()
+	if prev, ok := h.rollouts[key]; ok {
+		prev()
+	}
+	h.rollouts[key] = cancel
+	h.mu.Unlock()
+
+	go func() {
+		defer cancel()
+		err := svc.WatchRollout(ctx, app)
+
+		h.mu.Lock()
+		if ctx.Err() != context.Canceled {
+			delete(h.rollouts, key)
+		}
+		h.mu.Unlock()
+
+		switch {
+		case err == nil:
+			logger.GetLogger().Info("Rollout completed", zap.String("app", app))
+		case errors.Is(err, service.ErrRolloutFailed):
+			logger.GetLogger().Warn("Rollout failed", zap.String("app", app), zap.Error(err))
+			status, _ := svc.GetApplicationStatus(app)
+			cb := &operatorapi.StatusCallback{App: app, Event: operatorapi.CallbackRolloutFailed, Message: err.Error(), Status: status}
+			if err := h.notifier.Notify(context.Background(), cb); err != nil {
+				logger.GetLogger().Warn("Failed to push rollout failure", zap.String("app", app), zap.Error(err))
+			}
+		case ctx.Err() == context.DeadlineExceeded:
+			logger.GetLogger().Warn("Rollout still in progress after watch timeout", zap.String("app", app))
+		case ctx.Err() == nil:
+			logger.GetLogger().Warn("Rollout watch stopped", zap.String("app", app), zap.Error(err))
+		}
+	}()
 }
 
 func (h *OperatorK8sHandler) GetStatus(c *gin.Context) {
 	app := c.Param("app")
+	svc := h.service(c)
+	if svc == nil {
+		return
+	}
 
-	status, err := h.operatorService.GetStatus(app)
+	status, err := svc.GetApplicationStatus(app)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": "Failed to get status: " + err.Error(),
-		})
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get status: "+err.Error())
+		return
+	}
+
+	utils.Success(c, status)
+}
+
+// Delete 删除应用的所有版本
+func (h *OperatorK8sHandler) Delete(c *gin.Context) {
+	app := c.Param("app")
+	svc := h.service(c)
+	if svc == nil {
 		return
 	}
 
-	c.JSON(http.StatusOK, status)
+	if err := svc.Delete(app); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to delete: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: app, Message: "Application deleted"})
+}
+
+// Scale 调整副本数
+func (h *OperatorK8sHandler) Scale(c *gin.Context) {
+	var req models.ScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.App == "" {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: app and non-negative replicas are required")
+		return
+	}
+
+	svc := h.service(c)
+	if svc == nil {
+		return
+	}
+
+	if err := svc.Scale(&req); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to scale: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: req.App, Message: fmt.Sprintf("Scaled to %d replicas", req.Replicas)})
+}
+
+// Restart 滚动重启
+func (h *OperatorK8sHandler) Restart(c *gin.Context) {
+	var req models.RestartRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.App == "" {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: app is required")
+		return
+	}
+
+	svc := h.service(c)
+	if svc == nil {
+		return
+	}
+
+	if err := svc.Restart(&req); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to restart: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: req.App, Message: "Restart triggered"})
+}
+
+// GetLogs 获取 Pod 日志
+func (h *OperatorK8sHandler) GetLogs(c *gin.Context) {
+	var req models.LogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	req.App = c.Param("app")
+
+	svc := h.service(c)
+	if svc == nil {
+		return
+	}
+
+	logs, err := svc.GetLogs(&req)
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get logs: "+err.Error())
+		return
+	}
+
+	utils.Success(c, logs)
+}
+
+// GetEvents 获取 Kubernetes 事件
+func (h *OperatorK8sHandler) GetEvents(c *gin.Context) {
+	svc := h.service(c)
+	if svc == nil {
+		return
+	}
+
+	events, err := svc.GetEvents(c.Param("app"))
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get events: "+err.Error())
+		return
+	}
+
+	utils.Success(c, events)
+}
+
+// Clusters 可用的集群及最近一次健康检查结果
+func (h *OperatorK8sHandler) Clusters(c *gin.Context) {
+	utils.Success(c, gin.H{
+		"default":  h.router.DefaultCluster(),
+		"clusters": h.router.Health(),
+	})
 }
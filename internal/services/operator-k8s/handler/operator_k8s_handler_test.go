@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/boreas/internal/pkg/client/operator"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi/operatorapitest"
+	"github.com/boreas/internal/services/operator-k8s/service"
+	"github.com/gin-gonic/gin"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestOperatorK8sConformance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	router := service.NewClusterRouterWithClientsets(map[string]kubernetes.Interface{"default": fake.NewSimpleClientset()}, "default", "default", 5)
+	NewOperatorK8sHandler(router).RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	operatorapitest.Run(t, operatorapitest.Target{
+		BaseURL: srv.URL,
+		App:     "demo",
+		Package: models.DeploymentPackage{Type: "docker", Image: "demo:latest", Replicas: 2},
+	})
+}
+
+func TestOperatorK8sRoutesByTarget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	west, east := fake.NewSimpleClientset(), fake.NewSimpleClientset()
+	router := service.NewClusterRouterWithClientsets(map[string]kubernetes.Interface{"west": west, "east": east}, "west", "default", 5)
+	NewOperatorK8sHandler(router).RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client := operator.NewK8sClient(srv.URL).WithTarget("east", "apps")
+	_, err := client.Apply(context.Background(), &models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 1, Package: models.DeploymentPackage{Type: "docker", Image: "demo:v1"}},
+	}})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if _, err := east.AppsV1().Deployments("apps").Get(context.Background(), "demo-v1", metav1.GetOptions{}); err != nil {
+		t.Errorf("deployment not created in east/apps: %v", err)
+	}
+	if list, _ := west.AppsV1().Deployments("").List(context.Background(), metav1.ListOptions{}); len(list.Items) != 0 {
+		t.Errorf("default cluster should be untouched, got %d deployments", len(list.Items))
+	}
+
+	_, err = operator.NewK8sClient(srv.URL).WithTarget("north", "").GetApplicationStatus(context.Background(), "demo")
+	if err == nil || !strings.Contains(err.Error(), "unknown deployment target") {
+		t.Errorf("unknown cluster error = %v", err)
+	}
+}
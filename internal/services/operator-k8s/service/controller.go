@@ -0,0 +1,304 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"go.uber.org/zap"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+const (
+	// controllerRetryInterval 列出或监听 BoreasApplication 失败后的重试间隔
+	controllerRetryInterval = 5 * time.Second
+	// controllerProgressInterval 处于 Progressing 阶段的资源刷新状态的间隔
+	controllerProgressInterval = 5 * time.Second
+)
+
+// Controller 调和 BoreasApplication 资源：按 .spec 下发应用（与 /v1/apply 相同），把应用状态写回 .status。
+// .metadata.generation 变化时下发应用，其他变化只刷新状态；Progressing 阶段的资源定期刷新状态直到就绪或失败。
+// 每个 resync 周期重新下发所有资源，以修正手工修改（漂移）和 Operator 重启期间的变化
+type Controller struct {
+	dynamic   dynamic.Interface
+	router    *ClusterRouter
+	namespace string // 监听的命名空间，为空时监听所有命名空间
+	resync    time.Duration
+}
+
+// NewController 创建控制器，dyn 为 BoreasApplication 所在集群（默认集群）的 dynamic client，
+// 应用部署到资源所在的命名空间
+func NewController(dyn dynamic.Interface, router *ClusterRouter, namespace string, resync time.Duration) *Controller {
+	if resync <= 0 {
+		resync = 5 * time.Minute
+	}
+	return &Controller{dynamic: dyn, router: router, namespace: namespace, resync: resync}
+}
+
+// Run 持续调和直到 ctx 结束：先列出所有资源逐个下发，再监听变化，每个 resync 周期重新列出
+func (c *Controller) Run(ctx context.Context) {
+	log := logger.GetLogger()
+	resources := c.resources(c.namespace)
+	for ctx.Err() == nil {
+		list, err := resources.List(ctx, metav1.ListOptions{})
+		if err != nil {
+			log.Error("Failed to list BoreasApplications", zap.Error(err))
+			sleepContext(ctx, controllerRetryInterval)
+			continue
+		}
+		progressing := map[types.NamespacedName]bool{}
+		for i := range list.Items {
+			c.reconcileLogged(ctx, &list.Items[i], true, progressing)
+		}
+
+		w, err := resources.Watch(ctx, metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+		if err != nil {
+			log.Error("Failed to watch BoreasApplications", zap.Error(err))
+			sleepContext(ctx, controllerRetryInterval)
+			continue
+		}
+		c.watch(ctx, w, progressing)
+	}
+}
+
+// watch 处理监听事件并定期刷新 progressing 中资源的状态，resync 周期到达、监听中断或 ctx 结束时返回
+func (c *Controller) watch(ctx context.Context, w watch.Interface, progressing map[types.NamespacedName]bool) {
+	defer w.Stop()
+	timer := time.NewTimer(c.resync)
+	defer timer.Stop()
+	ticker := time.NewTicker(controllerProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			return
+		case <-ticker.C:
+			for key := range progressing {
+				obj, err := c.resources(key.Namespace).Get(ctx, key.Name, metav1.GetOptions{})
+				if err != nil {
+					if apierrors.IsNotFound(err) {
+						delete(progressing, key)
+					}
+					continue
+				}
+				c.reconcileLogged(ctx, obj, false, progressing)
+			}
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+					c.reconcileLogged(ctx, obj, false, progressing)
+				}
+			case watch.Deleted:
+				if obj, ok := event.Object.(*unstructured.Unstructured); ok {
+					delete(progressing, types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()})
+				}
+			case watch.Error:
+				logger.GetLogger().Warn("BoreasApplication watch failed", zap.Error(apierrors.FromObject(event.Object)))
+				return
+			}
+		}
+	}
+}
+
+// Reconcile 调和一个 BoreasApplication 并重新下发应用，资源已不存在时返回 nil
+func (c *Controller) Reconcile(ctx context.Context, namespace, name string) error {
+	obj, err := c.resources(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get BoreasApplication %s/%s: %w", namespace, name, err)
+	}
+	_, err = c.reconcile(ctx, obj, true)
+	return err
+}
+
+// reconcileLogged 调和资源并记录错误，按调和后的阶段把资源加入或移出 progressing
+func (c *Controller) reconcileLogged(ctx context.Context, obj *unstructured.Unstructured, force bool, progressing map[types.NamespacedName]bool) {
+	key := types.NamespacedName{Namespace: obj.GetNamespace(), Name: obj.GetName()}
+	phase, err := c.reconcile(ctx, obj, force)
+	if err != nil {
+		logger.GetLogger().Warn("Failed to reconcile BoreasApplication",
+			zap.String("namespace", obj.GetNamespace()),
+			zap.String("name", obj.GetName()),
+			zap.Error(err))
+	}
+	if phase == operatorapi.PhaseProgressing {
+		progressing[key] = true
+	} else {
+		delete(progressing, key)
+	}
+}
+
+// reconcile 资源删除中时删除应用并移除 finalizer，删除失败时保留 finalizer 并把原因写入 .status；否则补上 finalizer，下发应用并更新状态，返回写入的阶段。
+// force 为 false 且当前 generation 已成功下发时不重新下发，只刷新状态
+func (c *Controller) reconcile(ctx context.Context, obj *unstructured.Unstructured, force bool) (string, error) {
+	resources := c.resources(obj.GetNamespace())
+	spec, specErr := operatorapi.ApplicationSpecOf(obj)
+	svc, targetErr := c.router.Service("", obj.GetNamespace())
+
+	if obj.GetDeletionTimestamp() != nil {
+		if !hasFinalizer(obj) {
+			return "", nil
+		}
+		if err := deleteApplication(spec, specErr, svc, targetErr); err != nil {
+			// 无法确认应用已删除时保留 finalizer，避免遗留集群中的资源；原因写入 .status，resync 时重试
+			status := &operatorapi.ApplicationCRStatus{
+				ObservedGeneration: obj.GetGeneration(),
+				Phase:              operatorapi.PhaseFailed,
+				Message:            err.Error(),
+			}
+			if statusErr := c.updateStatus(ctx, obj, status); statusErr != nil {
+				return status.Phase, errors.Join(err, statusErr)
+			}
+			return status.Phase, err
+		}
+		obj.SetFinalizers(removeString(obj.GetFinalizers(), operatorapi.CRDFinalizer))
+		if _, err := resources.Update(ctx, obj, metav1.UpdateOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return "", fmt.Errorf("failed to remove finalizer: %w", err)
+		}
+		return "", nil
+	}
+
+	if !hasFinalizer(obj) {
+		obj.SetFinalizers(append(obj.GetFinalizers(), operatorapi.CRDFinalizer))
+		updated, err := resources.Update(ctx, obj, metav1.UpdateOptions{})
+		if err != nil {
+			return "", fmt.Errorf("failed to add finalizer: %w", err)
+		}
+		obj = updated
+	}
+
+	status := &operatorapi.ApplicationCRStatus{ObservedGeneration: obj.GetGeneration()}
+	err := errors.Join(specErr, targetErr)
+	if err == nil && (force || !appliedGeneration(obj)) {
+		req := &models.ApplyDeploymentRequest{App: spec.App, Versions: spec.Versions}
+		if err = operatorapi.ValidateApplyRequest(req); err == nil {
+			_, err = svc.Apply(req)
+		}
+	}
+	if err != nil {
+		status.Phase = operatorapi.PhaseFailed
+		status.Message = err.Error()
+	} else {
+		fillStatus(status, svc, spec.App)
+	}
+
+	if statusErr := c.updateStatus(ctx, obj, status); statusErr != nil {
+		return status.Phase, errors.Join(err, statusErr)
+	}
+	return status.Phase, err
+}
+
+// deleteApplication 删除资源对应的应用，应用不存在视为已删除；.spec 无效或命名空间无法路由到集群时无法确定要删除的应用，返回错误
+func deleteApplication(spec *operatorapi.ApplicationSpec, specErr error, svc *OperatorK8sService, targetErr error) error {
+	if err := errors.Join(specErr, targetErr); err != nil {
+		return fmt.Errorf("cannot delete application: %w", err)
+	}
+	if err := svc.Delete(spec.App); err != nil && !errors.Is(err, operatorapi.ErrAppNotFound) {
+		return fmt.Errorf("failed to delete %s: %w", spec.App, err)
+	}
+	return nil
+}
+
+// appliedGeneration 资源当前的 generation 是否已经成功下发过；下发失败的资源每次调和都重试
+func appliedGeneration(obj *unstructured.Unstructured) bool {
+	current, err := operatorapi.ApplicationStatusOf(obj)
+	if err != nil || current == nil {
+		return false
+	}
+	return current.ObservedGeneration == obj.GetGeneration() && current.Phase != "" && current.Phase != operatorapi.PhaseFailed
+}
+
+// fillStatus 按应用当前状态设置阶段：全部就绪为 Ready，有版本滚动更新超时为 Failed，否则为 Progressing
+func fillStatus(status *operatorapi.ApplicationCRStatus, svc *OperatorK8sService, app string) {
+	resp, err := svc.GetApplicationStatus(app)
+	if err != nil {
+		status.Phase = operatorapi.PhaseProgressing
+		status.Message = err.Error()
+		return
+	}
+	status.Healthy = resp.Healthy
+	status.Versions = resp.Versions
+	status.Message = resp.Healthy.Msg
+	status.Phase = operatorapi.PhaseProgressing
+	if resp.Healthy.Level == 100 {
+		status.Phase = operatorapi.PhaseReady
+	}
+	for _, v := range resp.Versions {
+		if strings.HasPrefix(v.Healthy.Msg, rolloutFailedPrefix) {
+			status.Phase = operatorapi.PhaseFailed
+			status.Message = v.Version + ": " + v.Healthy.Msg
+		}
+	}
+}
+
+// updateStatus 状态有变化时写入 .status 子资源
+func (c *Controller) updateStatus(ctx context.Context, obj *unstructured.Unstructured, status *operatorapi.ApplicationCRStatus) error {
+	current, err := operatorapi.ApplicationStatusOf(obj)
+	if err == nil {
+		a, _ := json.Marshal(current)
+		b, _ := json.Marshal(status)
+		if bytes.Equal(a, b) {
+			return nil
+		}
+	}
+	if err := operatorapi.SetApplicationStatus(obj, status); err != nil {
+		return err
+	}
+	if _, err := c.resources(obj.GetNamespace()).UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update status of %s/%s: %w", obj.GetNamespace(), obj.GetName(), err)
+	}
+	return nil
+}
+
+func (c *Controller) resources(namespace string) dynamic.ResourceInterface {
+	if namespace == "" {
+		return c.dynamic.Resource(operatorapi.ApplicationResource)
+	}
+	return c.dynamic.Resource(operatorapi.ApplicationResource).Namespace(namespace)
+}
+
+func hasFinalizer(obj *unstructured.Unstructured) bool {
+	for _, f := range obj.GetFinalizers() {
+		if f == operatorapi.CRDFinalizer {
+			return true
+		}
+	}
+	return false
+}
+
+func removeString(items []string, s string) []string {
+	var result []string
+	for _, item := range items {
+		if item != s {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+func sleepContext(ctx context.Context, d time.Duration) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(d):
+	}
+}
@@ -0,0 +1,436 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boreas/internal/pkg/models"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// exposure 应用级的 Service、Ingress 和自动伸缩配置，Apply 时与 Deployment 一起下发
+// 为 nil 的对象在 Apply 时会被删除（只删除带 Boreas managed-by 标签的对象）
+type exposure struct {
+	service     *corev1.Service
+	ingress     *networkingv1.Ingress
+	autoscaling *models.AutoscalingConfig
+}
+
+// buildExposure 从请求的版本中取出应用级配置，多个版本都配置时取第一个
+func (s *OperatorK8sService) buildExposure(app string, versions []models.VersionDeployment) (*exposure, error) {
+	var (
+		serviceCfg *models.ServiceExposure
+		ingressCfg *models.IngressExposure
+		exp        exposure
+	)
+	for _, v := range versions {
+		if serviceCfg == nil {
+			serviceCfg = v.Package.Service
+		}
+		if ingressCfg == nil {
+			ingressCfg = v.Package.Ingress
+		}
+		if exp.autoscaling == nil {
+			exp.autoscaling = v.Package.Autoscaling
+		}
+	}
+
+	service, err := s.buildService(app, serviceCfg, versions)
+	if err != nil {
+		return nil, err
+	}
+	exp.service = service
+
+	if ingressCfg != nil {
+		if service == nil {
+			return nil, fmt.Errorf("ingress requires service ports")
+		}
+		ingress, err := s.buildIngress(app, ingressCfg, service)
+		if err != nil {
+			return nil, err
+		}
+		exp.ingress = ingress
+	}
+
+	if a := exp.autoscaling; a != nil {
+		if a.MinReplicas < 1 || a.MaxReplicas < a.MinReplicas {
+			return nil, fmt.Errorf("autoscaling requires 1 <= min_replicas <= max_replicas")
+		}
+		if a.TargetCPUPercent <= 0 {
+			return nil, fmt.Errorf("autoscaling target_cpu_percent must be positive")
+		}
+	}
+	return &exp, nil
+}
+
+// buildService 生成应用共享的 Service，按 app 标签选择所有版本的 Pod
+// 未配置 service 时按部署包的端口生成 ClusterIP Service，没有端口时返回 nil
+func (s *OperatorK8sService) buildService(app string, cfg *models.ServiceExposure, versions []models.VersionDeployment) (*corev1.Service, error) {
+	var ports []corev1.ServicePort
+	serviceType := corev1.ServiceTypeClusterIP
+	if cfg != nil {
+		if len(cfg.Ports) > 0 {
+			for _, v := range versions {
+				for _, p := range v.Package.Ports {
+					if p.HostPort != 0 {
+						return nil, fmt.Errorf("host_port sets the port of the generated service and cannot be combined with service.ports")
+					}
+				}
+			}
+		}
+		switch cfg.Type {
+		case "", string(corev1.ServiceTypeClusterIP):
+		case string(corev1.ServiceTypeNodePort), string(corev1.ServiceTypeLoadBalancer):
+			serviceType = corev1.ServiceType(cfg.Type)
+		default:
+			return nil, fmt.Errorf("unsupported service type: %s", cfg.Type)
+		}
+
+		for _, p := range cfg.Ports {
+			if p.Port <= 0 {
+				return nil, fmt.Errorf("service port is required")
+			}
+			if p.NodePort != 0 && serviceType == corev1.ServiceTypeClusterIP {
+				return nil, fmt.Errorf("node_port requires a NodePort or LoadBalancer service")
+			}
+			target := p.TargetPort
+			if target == 0 {
+				target = p.Port
+			}
+			name := p.Name
+			if name == "" {
+				name = fmt.Sprintf("port-%d", p.Port)
+			}
+			ports = append(ports, corev1.ServicePort{
+				Name:       name,
+				Port:       int32(p.Port),
+				TargetPort: intstr.FromInt(target),
+				NodePort:   int32(p.NodePort),
+				Protocol:   serviceProtocol(p.Protocol),
+			})
+		}
+	}
+
+	if len(ports) == 0 {
+		ports = packageServicePorts(versions)
+	}
+	if len(ports) == 0 {
+		if cfg != nil {
+			return nil, fmt.Errorf("service requires at least one port")
+		}
+		return nil, nil
+	}
+
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app,
+			Namespace: s.namespace,
+			Labels:    managedLabels(app),
+		},
+		Spec: corev1.ServiceSpec{
+			Type:     serviceType,
+			Selector: map[string]string{LabelApp: app},
+			Ports:    ports,
+		},
+	}, nil
+}
+
+// packageServicePorts 按第一个声明了端口的版本生成 Service 端口，host_port 作为 Service 端口
+func packageServicePorts(versions []models.VersionDeployment) []corev1.ServicePort {
+	for _, v := range versions {
+		if len(v.Package.Ports) == 0 {
+			continue
+		}
+		var ports []corev1.ServicePort
+		for _, p := range v.Package.Ports {
+			port := p.HostPort
+			if port == 0 {
+				port = p.ContainerPort
+			}
+			ports = append(ports, corev1.ServicePort{
+				Name:       fmt.Sprintf("port-%d", port),
+				Port:       int32(port),
+				TargetPort: intstr.FromInt(p.ContainerPort),
+				Protocol:   serviceProtocol(p.Protocol),
+			})
+		}
+		return ports
+	}
+	return nil
+}
+
+// buildIngress 生成指向应用 Service 的 Ingress
+func (s *OperatorK8sService) buildIngress(app string, cfg *models.IngressExposure, service *corev1.Service) (*networkingv1.Ingress, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("ingress host is required")
+	}
+	path := cfg.Path
+	if path == "" {
+		path = "/"
+	}
+	if !strings.HasPrefix(path, "/") {
+		return nil, fmt.Errorf("ingress path must start with /: %s", path)
+	}
+
+	port := service.Spec.Ports[0].Port
+	if cfg.Port != 0 {
+		port = int32(cfg.Port)
+		found := false
+		for _, p := range service.Spec.Ports {
+			found = found || p.Port == port
+		}
+		if !found {
+			return nil, fmt.Errorf("ingress port %d is not a service port", cfg.Port)
+		}
+	}
+
+	pathType := networkingv1.PathTypePrefix
+	ingress := &networkingv1.Ingress{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      app,
+			Namespace: s.namespace,
+			Labels:    managedLabels(app),
+		},
+		Spec: networkingv1.IngressSpec{
+			Rules: []networkingv1.IngressRule{{
+				Host: cfg.Host,
+				IngressRuleValue: networkingv1.IngressRuleValue{
+					HTTP: &networkingv1.HTTPIngressRuleValue{
+						Paths: []networkingv1.HTTPIngressPath{{
+							Path:     path,
+							PathType: &pathType,
+							Backend: networkingv1.IngressBackend{
+								Service: &networkingv1.IngressServiceBackend{
+									Name: app,
+									Port: networkingv1.ServiceBackendPort{Number: port},
+								},
+							},
+						}},
+					},
+				},
+			}},
+		},
+	}
+	if cfg.ClassName != "" {
+		className := cfg.ClassName
+		ingress.Spec.IngressClassName = &className
+	}
+	if cfg.TLSSecret != "" {
+		ingress.Spec.TLS = []networkingv1.IngressTLS{{Hosts: []string{cfg.Host}, SecretName: cfg.TLSSecret}}
+	}
+	return ingress, nil
+}
+
+// buildAutoscaler 为版本的 Deployment 生成 HPA，应用的最小/最大副本数按 Percent 分配给各版本
+func (s *OperatorK8sService) buildAutoscaler(app, version string, minReplicas, maxReplicas int32, cfg *models.AutoscalingConfig) *autoscalingv2.HorizontalPodAutoscaler {
+	if minReplicas < 1 {
+		minReplicas = 1
+	}
+	if maxReplicas < minReplicas {
+		maxReplicas = minReplicas
+	}
+	target := int32(cfg.TargetCPUPercent)
+	labels := managedLabels(app)
+	labels[LabelVersion] = version
+	name := deploymentName(app, version)
+
+	return &autoscalingv2.HorizontalPodAutoscaler{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: s.namespace,
+			Labels:    labels,
+		},
+		Spec: autoscalingv2.HorizontalPodAutoscalerSpec{
+			ScaleTargetRef: autoscalingv2.CrossVersionObjectReference{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       name,
+			},
+			MinReplicas: &minReplicas,
+			MaxReplicas: maxReplicas,
+			Metrics: []autoscalingv2.MetricSpec{{
+				Type: autoscalingv2.ResourceMetricSourceType,
+				Resource: &autoscalingv2.ResourceMetricSource{
+					Name: corev1.ResourceCPU,
+					Target: autoscalingv2.MetricTarget{
+						Type:               autoscalingv2.UtilizationMetricType,
+						AverageUtilization: &target,
+					},
+				},
+			}},
+		},
+	}
+}
+
+func managedLabels(app string) map[string]string {
+	return map[string]string{LabelApp: app, LabelManagedBy: ManagedBy}
+}
+
+// isManaged 对象是否由 Boreas 创建，只有这类对象会被更新或回收
+func isManaged(meta metav1.ObjectMeta) bool {
+	return meta.Labels[LabelManagedBy] == ManagedBy
+}
+
+// applyService 创建、更新或删除应用的 Service
+func (s *OperatorK8sService) applyService(ctx context.Context, app string, spec *corev1.Service) error {
+	services := s.clientset.CoreV1().Services(s.namespace)
+	existing, err := services.Get(ctx, app, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get service %s: %w", app, err)
+	}
+	found := err == nil
+
+	switch {
+	case spec == nil:
+		if found && isManaged(existing.ObjectMeta) {
+			if err := services.Delete(ctx, app, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete service %s: %w", app, err)
+			}
+		}
+		return nil
+	case !found:
+		if _, err := services.Create(ctx, spec, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create service %s: %w", app, err)
+		}
+		return nil
+	case !isManaged(existing.ObjectMeta):
+		return fmt.Errorf("service %s exists and is not managed by %s", app, ManagedBy)
+	}
+
+	// 未指定 node_port 时沿用已分配的端口，避免每次 Apply 重新分配
+	if spec.Spec.Type != corev1.ServiceTypeClusterIP {
+		assigned := make(map[int32]int32, len(existing.Spec.Ports))
+		for _, p := range existing.Spec.Ports {
+			assigned[p.Port] = p.NodePort
+		}
+		for i := range spec.Spec.Ports {
+			if spec.Spec.Ports[i].NodePort == 0 {
+				spec.Spec.Ports[i].NodePort = assigned[spec.Spec.Ports[i].Port]
+			}
+		}
+	}
+	existing.Labels = spec.Labels
+	existing.Spec.Type = spec.Spec.Type
+	existing.Spec.Selector = spec.Spec.Selector
+	existing.Spec.Ports = spec.Spec.Ports
+	if _, err := services.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update service %s: %w", app, err)
+	}
+	return nil
+}
+
+// applyIngress 创建、更新或删除应用的 Ingress
+func (s *OperatorK8sService) applyIngress(ctx context.Context, app string, spec *networkingv1.Ingress) error {
+	ingresses := s.clientset.NetworkingV1().Ingresses(s.namespace)
+	existing, err := ingresses.Get(ctx, app, metav1.GetOptions{})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("failed to get ingress %s: %w", app, err)
+	}
+	found := err == nil
+
+	switch {
+	case spec == nil:
+		if found && isManaged(existing.ObjectMeta) {
+			if err := ingresses.Delete(ctx, app, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+				return fmt.Errorf("failed to delete ingress %s: %w", app, err)
+			}
+		}
+		return nil
+	case !found:
+		if _, err := ingresses.Create(ctx, spec, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create ingress %s: %w", app, err)
+		}
+		return nil
+	case !isManaged(existing.ObjectMeta):
+		return fmt.Errorf("ingress %s exists and is not managed by %s", app, ManagedBy)
+	}
+
+	existing.Labels = spec.Labels
+	existing.Spec = spec.Spec
+	if _, err := ingresses.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update ingress %s: %w", app, err)
+	}
+	return nil
+}
+
+// applyAutoscalers 为每个运行中的版本创建或更新 HPA，并删除不在 keep 中的 HPA
+func (s *OperatorK8sService) applyAutoscalers(ctx context.Context, app string, versions []models.VersionDeployment, cfg *models.AutoscalingConfig, keep map[string]bool) error {
+	hpas := s.clientset.AutoscalingV2().HorizontalPodAutoscalers(s.namespace)
+
+	if cfg != nil {
+		minReplicas := versionReplicas(cfg.MinReplicas, versions)
+		maxReplicas := versionReplicas(cfg.MaxReplicas, versions)
+		for _, v := range versions {
+			spec := s.buildAutoscaler(app, v.Version, minReplicas[v.Version], maxReplicas[v.Version], cfg)
+			if !keep[spec.Name] {
+				continue
+			}
+			existing, err := hpas.Get(ctx, spec.Name, metav1.GetOptions{})
+			if err != nil {
+				if !apierrors.IsNotFound(err) {
+					return fmt.Errorf("failed to get autoscaler %s: %w", spec.Name, err)
+				}
+				if _, err := hpas.Create(ctx, spec, metav1.CreateOptions{}); err != nil {
+					return fmt.Errorf("failed to create autoscaler %s: %w", spec.Name, err)
+				}
+				continue
+			}
+			existing.Labels = spec.Labels
+			existing.Spec = spec.Spec
+			if _, err := hpas.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+				return fmt.Errorf("failed to update autoscaler %s: %w", spec.Name, err)
+			}
+		}
+	} else {
+		keep = nil
+	}
+
+	return s.pruneAutoscalers(ctx, app, keep)
+}
+
+// pruneAutoscalers 删除应用中不在 keep 里的 HPA，keep 为 nil 时全部删除
+func (s *OperatorK8sService) pruneAutoscalers(ctx context.Context, app string, keep map[string]bool) error {
+	hpas := s.clientset.AutoscalingV2().HorizontalPodAutoscalers(s.namespace)
+	list, err := hpas.List(ctx, metav1.ListOptions{LabelSelector: appSelector(app, "")})
+	if err != nil {
+		return fmt.Errorf("failed to list autoscalers: %w", err)
+	}
+	var names []string
+	for _, h := range list.Items {
+		if !keep[h.Name] {
+			names = append(names, h.Name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := hpas.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete autoscaler %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// deleteExposure 删除应用的 Service、Ingress 和 HPA
+func (s *OperatorK8sService) deleteExposure(ctx context.Context, app string) error {
+	if err := s.applyIngress(ctx, app, nil); err != nil {
+		return err
+	}
+	if err := s.applyService(ctx, app, nil); err != nil {
+		return err
+	}
+	return s.pruneAutoscalers(ctx, app, nil)
+}
+
+func serviceProtocol(protocol string) corev1.Protocol {
+	if strings.EqualFold(protocol, "udp") {
+		return corev1.ProtocolUDP
+	}
+	return corev1.ProtocolTCP
+}
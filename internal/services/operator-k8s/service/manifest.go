@@ -0,0 +1,448 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// inventoryKey 清单记录 ConfigMap 中保存记录的键
+const inventoryKey = "inventory"
+
+// objectRef 清单中的一个对象
+type objectRef struct {
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+}
+
+func (r objectRef) String() string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(r.Kind), r.Name)
+}
+
+// inventory 应用通过清单类部署包创建的对象，按版本记录，Apply 时删除不再出现的对象
+type inventory struct {
+	Versions map[string][]objectRef `json:"versions"`
+}
+
+// workloadKinds 会给 Pod 模板补充 app/version 标签的工作负载
+var workloadKinds = map[schema.GroupKind]bool{
+	{Group: "apps", Kind: "Deployment"}:  true,
+	{Group: "apps", Kind: "StatefulSet"}: true,
+	{Group: "apps", Kind: "DaemonSet"}:   true,
+}
+
+// inventoryName 应用清单记录 ConfigMap 的名称
+func inventoryName(app string) string {
+	return deploymentName("boreas-inventory", app)
+}
+
+// applyManifests 渲染清单类部署包并以 server-side apply 下发，对象带 app/version 标签并记录在清单中
+// 清单类部署包同一时间只运行一个版本（percent > 0 的版本），切换或回滚版本时删除新版本中不再出现的对象
+func (s *OperatorK8sService) applyManifests(ctx context.Context, req *models.ApplyDeploymentRequest) (*models.ApplyDeploymentResponse, error) {
+	if s.dynamic == nil || s.mapper == nil {
+		return nil, fmt.Errorf("manifest packages require a dynamic client")
+	}
+
+	var active *models.VersionDeployment
+	for i := range req.Versions {
+		v := &req.Versions[i]
+		if !isManifestPackage(v.Package.Type) {
+			return nil, fmt.Errorf("%w: cannot mix %s and %s packages", operatorapi.ErrInvalidPackage, v.Package.Type, req.Versions[0].Package.Type)
+		}
+		if v.Percent <= 0 {
+			continue
+		}
+		if active != nil {
+			return nil, fmt.Errorf("%w: %s packages run one version at a time, got %s and %s",
+				operatorapi.ErrInvalidPackage, v.Package.Type, active.Version, v.Version)
+		}
+		active = v
+	}
+
+	var objects []*unstructured.Unstructured
+	var refs []objectRef
+	if active != nil {
+		data, err := s.renderPackage(ctx, req.App, active.Package)
+		if err != nil {
+			return nil, fmt.Errorf("%w: version %s: %v", operatorapi.ErrInvalidPackage, active.Version, err)
+		}
+		objects, err = decodeManifests(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: version %s: %v", operatorapi.ErrInvalidPackage, active.Version, err)
+		}
+		// 在修改任何资源前校验所有对象
+		for _, obj := range objects {
+			if err := s.prepareObject(req.App, active.Version, obj); err != nil {
+				return nil, fmt.Errorf("%w: version %s: %v", operatorapi.ErrInvalidPackage, active.Version, err)
+			}
+			refs = append(refs, refOf(obj))
+		}
+	}
+
+	// 同名对象已存在但不属于该应用时拒绝下发，避免覆盖手工创建或其他应用的对象
+	for _, obj := range objects {
+		if err := s.checkOwnership(ctx, req.App, obj); err != nil {
+			return nil, err
+		}
+	}
+
+	previous, err := s.loadInventory(ctx, req.App)
+	if err != nil {
+		return nil, err
+	}
+	next := &inventory{Versions: map[string][]objectRef{}}
+	if active != nil {
+		next.Versions[active.Version] = refs
+	}
+
+	// 先记录新旧对象的并集，下发中途失败时下一次 Apply 仍能回收
+	if err := s.saveInventory(ctx, req.App, mergeInventory(previous, next)); err != nil {
+		return nil, err
+	}
+	for _, obj := range objects {
+		if err := s.applyObject(ctx, obj); err != nil {
+			return nil, err
+		}
+	}
+
+	stale := staleRefs(previous, next)
+	if err := s.deleteObjects(ctx, stale); err != nil {
+		return nil, err
+	}
+	if err := s.saveInventory(ctx, req.App, next); err != nil {
+		return nil, err
+	}
+
+	message := "No version is active"
+	if active != nil {
+		message = fmt.Sprintf("Applied %s: %d objects", active.Version, len(objects))
+	}
+	if len(stale) > 0 {
+		names := make([]string, 0, len(stale))
+		for _, r := range stale {
+			names = append(names, r.String())
+		}
+		message += fmt.Sprintf("; removed %s", strings.Join(names, ", "))
+	}
+	return &models.ApplyDeploymentResponse{Success: true, Message: message, App: req.App}, nil
+}
+
+// decodeManifests 解析多文档 YAML/JSON，展开 List 对象，忽略空文档
+func decodeManifests(data []byte) ([]*unstructured.Unstructured, error) {
+	decoder := yamlutil.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	var objects []*unstructured.Unstructured
+	seen := make(map[objectRef]bool)
+	add := func(obj *unstructured.Unstructured) error {
+		if obj.GetKind() == "" || obj.GetAPIVersion() == "" {
+			return fmt.Errorf("object %q is missing apiVersion or kind", obj.GetName())
+		}
+		if obj.GetName() == "" {
+			return fmt.Errorf("%s object is missing metadata.name", obj.GetKind())
+		}
+		ref := refOf(obj)
+		if seen[ref] {
+			return fmt.Errorf("duplicate object %s", ref)
+		}
+		seen[ref] = true
+		objects = append(objects, obj)
+		return nil
+	}
+
+	for {
+		var doc map[string]interface{}
+		if err := decoder.Decode(&doc); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, fmt.Errorf("failed to parse manifests: %w", err)
+		}
+		if len(doc) == 0 {
+			continue
+		}
+		obj := &unstructured.Unstructured{Object: doc}
+		if obj.IsList() {
+			err := obj.EachListItem(func(item runtime.Object) error {
+				return add(item.(*unstructured.Unstructured))
+			})
+			if err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if err := add(obj); err != nil {
+			return nil, err
+		}
+	}
+	if len(objects) == 0 {
+		return nil, fmt.Errorf("no objects in manifests")
+	}
+	return objects, nil
+}
+
+// prepareObject 校验对象并补充命名空间和标签：只允许目标命名空间中的对象
+func (s *OperatorK8sService) prepareObject(app, version string, obj *unstructured.Unstructured) error {
+	mapping, err := s.restMapping(obj.GroupVersionKind())
+	if err != nil {
+		return err
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return fmt.Errorf("cluster-scoped object %s is not allowed", refOf(obj))
+	}
+	switch ns := obj.GetNamespace(); ns {
+	case "":
+		obj.SetNamespace(s.namespace)
+	case s.namespace:
+	default:
+		return fmt.Errorf("object %s is in namespace %s, expected %s", refOf(obj), ns, s.namespace)
+	}
+
+	labels := obj.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[LabelApp] = app
+	labels[LabelVersion] = version
+	labels[LabelManagedBy] = ManagedBy
+	obj.SetLabels(labels)
+
+	// Pod 模板缺少 app/version 标签时补上，用于状态查询；已有的标签可能被 selector 使用，不覆盖
+	if workloadKinds[obj.GroupVersionKind().GroupKind()] {
+		podLabels, _, err := unstructured.NestedStringMap(obj.Object, "spec", "template", "metadata", "labels")
+		if err != nil {
+			return fmt.Errorf("invalid pod template labels in %s: %w", refOf(obj), err)
+		}
+		if podLabels == nil {
+			podLabels = map[string]string{}
+		}
+		for k, v := range map[string]string{LabelApp: app, LabelVersion: version} {
+			if _, ok := podLabels[k]; !ok {
+				podLabels[k] = v
+			}
+		}
+		if err := unstructured.SetNestedStringMap(obj.Object, podLabels, "spec", "template", "metadata", "labels"); err != nil {
+			return fmt.Errorf("failed to set pod template labels in %s: %w", refOf(obj), err)
+		}
+	}
+	return nil
+}
+
+// checkOwnership 检查集群中的同名对象由 Boreas 为该应用创建，对象不存在时通过
+func (s *OperatorK8sService) checkOwnership(ctx context.Context, app string, obj *unstructured.Unstructured) error {
+	mapping, err := s.restMapping(obj.GroupVersionKind())
+	if err != nil {
+		return err
+	}
+	live, err := s.dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace()).Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get %s: %w", refOf(obj), err)
+	}
+	labels := live.GetLabels()
+	if labels[LabelManagedBy] != ManagedBy || labels[LabelApp] != app {
+		return fmt.Errorf("%s exists and is not managed by %s for app %s", refOf(obj), ManagedBy, app)
+	}
+	return nil
+}
+
+// applyObject 以 server-side apply 下发对象，字段管理者为 boreas；调用前需通过 checkOwnership
+func (s *OperatorK8sService) applyObject(ctx context.Context, obj *unstructured.Unstructured) error {
+	mapping, err := s.restMapping(obj.GroupVersionKind())
+	if err != nil {
+		return err
+	}
+	_, err = s.dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace()).
+		Apply(ctx, obj.GetName(), obj, metav1.ApplyOptions{FieldManager: ManagedBy, Force: true})
+	if err != nil {
+		return fmt.Errorf("failed to apply %s: %w", refOf(obj), err)
+	}
+	return nil
+}
+
+// deleteObjects 删除清单中的对象，已不存在的对象忽略
+func (s *OperatorK8sService) deleteObjects(ctx context.Context, refs []objectRef) error {
+	propagation := metav1.DeletePropagationBackground
+	for _, ref := range refs {
+		gv, err := schema.ParseGroupVersion(ref.APIVersion)
+		if err != nil {
+			return fmt.Errorf("invalid apiVersion in inventory: %w", err)
+		}
+		mapping, err := s.restMapping(gv.WithKind(ref.Kind))
+		if err != nil {
+			return err
+		}
+		err = s.dynamic.Resource(mapping.Resource).Namespace(ref.Namespace).
+			Delete(ctx, ref.Name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s: %w", ref, err)
+		}
+	}
+	return nil
+}
+
+// deleteManifests 删除应用通过清单创建的所有对象和清单记录，返回是否存在清单记录
+func (s *OperatorK8sService) deleteManifests(ctx context.Context, app string) (bool, error) {
+	if s.dynamic == nil || s.mapper == nil {
+		return false, nil
+	}
+	inv, err := s.loadInventory(ctx, app)
+	if err != nil {
+		return false, err
+	}
+	if len(inv.Versions) == 0 {
+		return false, nil
+	}
+	if err := s.deleteObjects(ctx, staleRefs(inv, &inventory{})); err != nil {
+		return true, err
+	}
+	return true, s.saveInventory(ctx, app, &inventory{})
+}
+
+func (s *OperatorK8sService) restMapping(gvk schema.GroupVersionKind) (*meta.RESTMapping, error) {
+	mapping, err := s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil && meta.IsNoMatchError(err) {
+		// 可能是新安装的 CRD，刷新发现缓存后重试一次
+		if r, ok := s.mapper.(meta.ResettableRESTMapper); ok {
+			r.Reset()
+			mapping, err = s.mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unknown kind %s: %w", gvk, err)
+	}
+	return mapping, nil
+}
+
+// loadInventory 读取应用的清单记录，不存在时返回空记录
+func (s *OperatorK8sService) loadInventory(ctx context.Context, app string) (*inventory, error) {
+	inv := &inventory{Versions: map[string][]objectRef{}}
+	cm, err := s.clientset.CoreV1().ConfigMaps(s.namespace).Get(ctx, inventoryName(app), metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return inv, nil
+		}
+		return nil, fmt.Errorf("failed to get inventory of %s: %w", app, err)
+	}
+	if data := cm.Data[inventoryKey]; data != "" {
+		if err := json.Unmarshal([]byte(data), inv); err != nil {
+			return nil, fmt.Errorf("invalid inventory of %s: %w", app, err)
+		}
+	}
+	if inv.Versions == nil {
+		inv.Versions = map[string][]objectRef{}
+	}
+	return inv, nil
+}
+
+// saveInventory 保存应用的清单记录，记录为空时删除 ConfigMap
+func (s *OperatorK8sService) saveInventory(ctx context.Context, app string, inv *inventory) error {
+	configMaps := s.clientset.CoreV1().ConfigMaps(s.namespace)
+	name := inventoryName(app)
+	if len(inv.Versions) == 0 {
+		if err := configMaps.Delete(ctx, name, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete inventory of %s: %w", app, err)
+		}
+		return nil
+	}
+
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("failed to marshal inventory: %w", err)
+	}
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: s.namespace, Labels: managedLabels(app)},
+		Data:       map[string]string{inventoryKey: string(data)},
+	}
+	existing, err := configMaps.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get inventory of %s: %w", app, err)
+		}
+		if _, err := configMaps.Create(ctx, cm, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create inventory of %s: %w", app, err)
+		}
+		return nil
+	}
+	existing.Data = cm.Data
+	if _, err := configMaps.Update(ctx, existing, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update inventory of %s: %w", app, err)
+	}
+	return nil
+}
+
+func refOf(obj *unstructured.Unstructured) objectRef {
+	return objectRef{APIVersion: obj.GetAPIVersion(), Kind: obj.GetKind(), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+// mergeInventory 合并两份记录中的对象
+func mergeInventory(a, b *inventory) *inventory {
+	merged := &inventory{Versions: map[string][]objectRef{}}
+	for _, inv := range []*inventory{a, b} {
+		for version, refs := range inv.Versions {
+			merged.Versions[version] = appendUnique(merged.Versions[version], refs...)
+		}
+	}
+	return merged
+}
+
+// staleRefs previous 中存在而 next 中不存在的对象（按 kind/命名空间/名称比较，忽略版本），按名称排序
+func staleRefs(previous, next *inventory) []objectRef {
+	type key struct{ group, kind, namespace, name string }
+	keyOf := func(r objectRef) key {
+		gv, _ := schema.ParseGroupVersion(r.APIVersion)
+		return key{gv.Group, r.Kind, r.Namespace, r.Name}
+	}
+	kept := make(map[key]bool)
+	for _, refs := range next.Versions {
+		for _, r := range refs {
+			kept[keyOf(r)] = true
+		}
+	}
+
+	var stale []objectRef
+	seen := make(map[key]bool)
+	for _, refs := range previous.Versions {
+		for _, r := range refs {
+			k := keyOf(r)
+			if kept[k] || seen[k] {
+				continue
+			}
+			seen[k] = true
+			stale = append(stale, r)
+		}
+	}
+	sort.Slice(stale, func(i, j int) bool { return stale[i].String() < stale[j].String() })
+	return stale
+}
+
+func appendUnique(refs []objectRef, more ...objectRef) []objectRef {
+	for _, r := range more {
+		dup := false
+		for _, existing := range refs {
+			dup = dup || existing == r
+		}
+		if !dup {
+			refs = append(refs, r)
+		}
+	}
+	return refs
+}
@@ -2,43 +2,47 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
+)
+
+// 资源标签
+const (
+	LabelApp       = "app"
+	LabelVersion   = "version"
+	LabelManagedBy = "app.kubernetes.io/managed-by"
+
+	// ManagedBy Operator 创建的资源的 managed-by 标签值
+	ManagedBy = "boreas"
 )
 
 type OperatorK8sService struct {
-	clientset *kubernetes.Clientset
+	clientset kubernetes.Interface
 	namespace string
 	timeout   time.Duration
-}
-
-func NewOperatorK8sService(kubeconfig, namespace string, timeout int) (*OperatorK8sService, error) {
-	var config *rest.Config
-	var err error
-
-	if kubeconfig != "" {
-		config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-	} else {
-		config, err = rest.InClusterConfig()
-	}
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to build kubernetes config: %w", err)
-	}
 
-	clientset, err := kubernetes.NewForConfig(config)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes clientset: %w", err)
-	}
+	// 清单类部署包（manifest/helm/kustomize）使用，未设置时不支持这些类型
+	dynamic  dynamic.Interface
+	mapper   meta.RESTMapper
+	renderer Renderer
+}
 
+// NewOperatorK8sServiceWithClientset 使用 clientset 操作指定命名空间，由 ClusterRouter 调用（测试中传入 fake clientset）
+func NewOperatorK8sServiceWithClientset(clientset kubernetes.Interface, namespace string, timeout int) *OperatorK8sService {
 	if namespace == "" {
 		namespace = "default"
 	}
@@ -51,7 +55,20 @@ func NewOperatorK8sService(kubeconfig, namespace string, timeout int) (*Operator
 		clientset: clientset,
 		namespace: namespace,
 		timeout:   time.Duration(timeout) * time.Second,
-	}, nil
+	}
+}
+
+// WithDynamicClient 设置下发任意对象所需的 dynamic client 和 RESTMapper
+func (s *OperatorK8sService) WithDynamicClient(dyn dynamic.Interface, mapper meta.RESTMapper) *OperatorK8sService {
+	s.dynamic = dyn
+	s.mapper = mapper
+	return s
+}
+
+// WithRenderer 设置 Helm/Kustomize 渲染器
+func (s *OperatorK8sService) WithRenderer(renderer Renderer) *OperatorK8sService {
+	s.renderer = renderer
+	return s
 }
 
 func (s *OperatorK8sService) CheckK8sConnection() error {
@@ -66,136 +83,291 @@ func (s *OperatorK8sService) CheckK8sConnection() error {
 	return nil
 }
 
-func (s *OperatorK8sService) Apply(req *models.ApplyRequest) (*models.ApplyResponse, error) {
+// Apply 应用部署：每个版本一个名为 <app>-<version> 的 Deployment，副本数按 Percent 分配，
+// 共享的 Service 按 app 标签选择所有版本的 Pod，并按部署包配置下发 Ingress 和 HPA。
+// 副本数为 0 或不在请求中的版本、以及不再配置的 Service/Ingress/HPA 会被删除。
+// manifest/helm/kustomize 类型的部署包见 applyManifests
+func (s *OperatorK8sService) Apply(req *models.ApplyDeploymentRequest) (*models.ApplyDeploymentResponse, error) {
+	if err := validateLabelValues(req); err != nil {
+		return nil, fmt.Errorf("%w: %v", operatorapi.ErrInvalidPackage, err)
+	}
+	for _, v := range req.Versions {
+		if isManifestPackage(v.Package.Type) {
+			ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+			defer cancel()
+			return s.applyManifests(ctx, req)
+		}
+	}
+
+	for _, v := range req.Versions {
+		switch v.Package.Type {
+		case "":
+			return nil, fmt.Errorf("package type is required")
+		case "docker":
+			if v.Package.Image == "" {
+				return nil, fmt.Errorf("image is required for docker deployment")
+			}
+		default:
+			return nil, fmt.Errorf("unsupported package type: %s", v.Package.Type)
+		}
+		// 在修改任何资源前校验所有版本的 Pod 配置
+		if _, err := buildPodSpec(req.App, v.Package); err != nil {
+			return nil, fmt.Errorf("%w: version %s: %v", operatorapi.ErrInvalidPackage, v.Version, err)
+		}
+	}
+	exp, err := s.buildExposure(req.App, req.Versions)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", operatorapi.ErrInvalidPackage, err)
+	}
+
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	pkg := req.Package.Type
-	if pkg == "" {
-		return nil, fmt.Errorf("package type is required")
+	replicas := versionReplicas(totalReplicas(req.Versions), req.Versions)
+	keep := make(map[string]bool, len(req.Versions))
+	var applied []string
+	for _, v := range req.Versions {
+		n := replicas[v.Version]
+		if n == 0 {
+			continue
+		}
+		if err := s.applyVersionDeployment(ctx, req.App, v, n, exp.autoscaling != nil); err != nil {
+			return nil, err
+		}
+		keep[deploymentName(req.App, v.Version)] = true
+		applied = append(applied, fmt.Sprintf("%s=%d", v.Version, n))
 	}
 
-	switch pkg {
-	case "docker":
-		return s.applyDockerDeployment(ctx, req)
-	default:
-		return nil, fmt.Errorf("unsupported package type: %s", pkg)
+	if err := s.applyService(ctx, req.App, exp.service); err != nil {
+		return nil, err
 	}
+	if err := s.applyIngress(ctx, req.App, exp.ingress); err != nil {
+		return nil, err
+	}
+	if err := s.applyAutoscalers(ctx, req.App, req.Versions, exp.autoscaling, keep); err != nil {
+		return nil, err
+	}
+
+	removed, err := s.pruneDeployments(ctx, req.App, keep)
+	if err != nil {
+		return nil, err
+	}
+	// 从清单类部署包切换回镜像部署时，回收清单创建的对象
+	if _, err := s.deleteManifests(ctx, req.App); err != nil {
+		return nil, err
+	}
+
+	message := fmt.Sprintf("Applied %s", strings.Join(applied, ", "))
+	if len(applied) == 0 {
+		message = "No version has replicas"
+	}
+	if len(removed) > 0 {
+		message += fmt.Sprintf("; removed %s", strings.Join(removed, ", "))
+	}
+	return &models.ApplyDeploymentResponse{
+		Success: true,
+		Message: message,
+		App:     req.App,
+	}, nil
 }
 
-func (s *OperatorK8sService) GetStatus(app string) (*models.AppStatusResponse, error) {
+// GetApplicationStatus 获取应用状态，按 Deployment 的 version 标签分组，应用健康度按副本数加权；
+// 每个版本的 Nodes 为该版本的 Pod，滚动更新超时的版本在健康信息中说明
+func (s *OperatorK8sService) GetApplicationStatus(app string) (*models.ApplicationStatusResponse, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
 	defer cancel()
 
-	deployment, err := s.clientset.AppsV1().Deployments(s.namespace).Get(ctx, app, metav1.GetOptions{})
+	deployments, err := s.listAppDeployments(ctx, app, "")
 	if err != nil {
-		return nil, fmt.Errorf("failed to get deployment: %w", err)
+		return nil, err
 	}
+	sort.Slice(deployments, func(i, j int) bool {
+		return deploymentVersion(&deployments[i]) < deploymentVersion(&deployments[j])
+	})
 
-	// 计算健康度：加权平均（假设每个就绪Pod健康度为100，未就绪为0）
-	// 健康度 = (就绪副本数 / 总副本数) * 100
-	healthy := models.HealthInfo{
-		Level: 0,
-		Msg:   "No replicas ready",
+	pods, err := s.clientset.CoreV1().Pods(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector(app, "")})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+	sort.Slice(pods.Items, func(i, j int) bool { return pods.Items[i].Name < pods.Items[j].Name })
+	nodes := make(map[string][]models.NodeStatus)
+	for i := range pods.Items {
+		p := &pods.Items[i]
+		nodes[podVersion(p)] = append(nodes[podVersion(p)], podNodeStatus(p))
 	}
 
-	if deployment.Status.Replicas > 0 {
-		if deployment.Status.ReadyReplicas == deployment.Status.Replicas {
-			healthy.Level = 100
-			healthy.Msg = "All replicas ready"
-		} else if deployment.Status.ReadyReplicas > 0 {
-			healthy.Level = int(float64(deployment.Status.ReadyReplicas) / float64(deployment.Status.Replicas) * 100)
-			healthy.Msg = fmt.Sprintf("%d/%d replicas ready", deployment.Status.ReadyReplicas, deployment.Status.Replicas)
-		} else {
-			healthy.Msg = "No replicas ready"
+	resp := &models.ApplicationStatusResponse{App: app}
+	var ready, total int32
+	for i := range deployments {
+		d := &deployments[i]
+		version := deploymentVersion(d)
+		healthy := replicaHealth(d.Status.ReadyReplicas, d.Status.Replicas)
+		if msg, failed := rolloutFailure(d); failed {
+			healthy.Msg = rolloutFailedPrefix + msg
+		}
+		versionNodes := nodes[version]
+		if versionNodes == nil {
+			versionNodes = []models.NodeStatus{}
 		}
-	} else {
-		healthy.Msg = "No replicas configured"
+		resp.Versions = append(resp.Versions, models.VersionStatus{
+			Version: version,
+			Healthy: healthy,
+			Nodes:   versionNodes,
+		})
+		ready += d.Status.ReadyReplicas
+		total += d.Status.Replicas
 	}
+	resp.Healthy = replicaHealth(ready, total)
+	return resp, nil
+}
 
-	version := ""
-	if deployment.Spec.Template.Spec.Containers != nil && len(deployment.Spec.Template.Spec.Containers) > 0 {
-		version = deployment.Spec.Template.Spec.Containers[0].Image
+// replicaHealth 健康度 = 就绪副本数 / 总副本数 * 100
+func replicaHealth(ready, total int32) models.HealthInfo {
+	switch {
+	case total == 0:
+		return models.HealthInfo{Level: 0, Msg: "No replicas configured"}
+	case ready == total:
+		return models.HealthInfo{Level: 100, Msg: "All replicas ready"}
+	case ready > 0:
+		return models.HealthInfo{
+			Level: int(float64(ready) / float64(total) * 100),
+			Msg:   fmt.Sprintf("%d/%d replicas ready", ready, total),
+		}
+	default:
+		return models.HealthInfo{Level: 0, Msg: "No replicas ready"}
 	}
+}
 
-	return &models.AppStatusResponse{
-		App:     app,
-		Version: version,
-		Healthy: healthy,
-	}, nil
+// deploymentVersion Deployment 运行的版本，旧的 Deployment 没有 version 标签时取镜像
+func deploymentVersion(d *appsv1.Deployment) string {
+	if version := d.Spec.Template.Labels[LabelVersion]; version != "" {
+		return version
+	}
+	if version := d.Labels[LabelVersion]; version != "" {
+		return version
+	}
+	if len(d.Spec.Template.Spec.Containers) > 0 {
+		return d.Spec.Template.Spec.Containers[0].Image
+	}
+	return ""
 }
 
-func (s *OperatorK8sService) applyDockerDeployment(ctx context.Context, req *models.ApplyRequest) (*models.ApplyResponse, error) {
-	image := req.Package.Image
-	if image == "" {
-		return nil, fmt.Errorf("image is required for docker deployment")
+// applyVersionDeployment 创建或更新版本的 Deployment，autoscaled 时副本数由 HPA 管理，更新时保留当前副本数
+func (s *OperatorK8sService) applyVersionDeployment(ctx context.Context, app string, version models.VersionDeployment, replicas int32, autoscaled bool) error {
+	spec, err := s.buildDeploymentSpec(app, version.Version, replicas, version.Package)
+	if err != nil {
+		return err
 	}
+	deployments := s.clientset.AppsV1().Deployments(s.namespace)
 
-	replicas := int32(1)
-	if req.Package.Replicas > 0 {
-		replicas = int32(req.Package.Replicas)
+	existing, err := deployments.Get(ctx, spec.Name, metav1.GetOptions{})
+	if err != nil {
+		if !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to get deployment %s: %w", spec.Name, err)
+		}
+		if _, err := deployments.Create(ctx, spec, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create deployment %s: %w", spec.Name, err)
+		}
+		return nil
+	}
+	if !isManaged(existing.ObjectMeta) {
+		return fmt.Errorf("deployment %s exists and is not managed by %s", spec.Name, ManagedBy)
+	}
+	// 名称 <app>-<version> 不唯一（如 a-b/c 与 a/b-c），不覆盖其他应用或版本的 Deployment
+	if existing.Labels[LabelApp] != app || existing.Labels[LabelVersion] != version.Version {
+		return fmt.Errorf("deployment %s already belongs to app %q version %q",
+			spec.Name, existing.Labels[LabelApp], existing.Labels[LabelVersion])
 	}
 
-	deploymentSpec := s.buildDeploymentSpec(req.App, req.Version, image, replicas, req.Package)
+	// 保留重启注解等由其他操作写入的模板注解
+	spec.Spec.Template.Annotations = existing.Spec.Template.Annotations
+	if autoscaled && existing.Spec.Replicas != nil {
+		spec.Spec.Replicas = existing.Spec.Replicas
+	}
+	spec.ResourceVersion = existing.ResourceVersion
+	if _, err := deployments.Update(ctx, spec, metav1.UpdateOptions{}); err != nil {
+		return fmt.Errorf("failed to update deployment %s: %w", spec.Name, err)
+	}
+	return nil
+}
 
-	_, err := s.clientset.AppsV1().Deployments(s.namespace).Get(ctx, req.App, metav1.GetOptions{})
+// pruneDeployments 删除应用中不在 keep 里的 Deployment（副本数降为 0 或已不在请求中的版本）和升级前创建的 Deployment，返回删除的版本
+func (s *OperatorK8sService) pruneDeployments(ctx context.Context, app string, keep map[string]bool) ([]string, error) {
+	list, err := s.clientset.AppsV1().Deployments(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: appSelector(app, "")})
 	if err != nil {
-		_, err = s.clientset.AppsV1().Deployments(s.namespace).Create(ctx, deploymentSpec, metav1.CreateOptions{})
-		if err != nil {
-			return nil, fmt.Errorf("failed to create deployment: %w", err)
-		}
-		return &models.ApplyResponse{
-			Success: true,
-			Message: fmt.Sprintf("Deployment %s created successfully", req.App),
-			App:     req.App,
-			Version: req.Version,
-		}, nil
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
 	}
 
-	_, err = s.clientset.AppsV1().Deployments(s.namespace).Update(ctx, deploymentSpec, metav1.UpdateOptions{})
+	var removed []string
+	for i := range list.Items {
+		d := &list.Items[i]
+		if keep[d.Name] || !isManaged(d.ObjectMeta) {
+			continue
+		}
+		err := s.clientset.AppsV1().Deployments(s.namespace).Delete(ctx, d.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return removed, fmt.Errorf("failed to delete deployment %s: %w", d.Name, err)
+		}
+		removed = append(removed, deploymentVersion(d))
+	}
+	legacy, err := s.deleteLegacyDeployment(ctx, app)
 	if err != nil {
-		return nil, fmt.Errorf("failed to update deployment: %w", err)
+		return removed, err
 	}
+	if legacy != "" {
+		removed = append(removed, legacy)
+	}
+	sort.Strings(removed)
+	return removed, nil
+}
 
-	return &models.ApplyResponse{
-		Success: true,
-		Message: fmt.Sprintf("Deployment %s updated successfully", req.App),
-		App:     req.App,
-		Version: req.Version,
-	}, nil
+// isLegacyDeployment 是否为升级前的 Operator 为应用创建的 Deployment：名称为 <app>，带 app、version 标签但没有 managed-by 标签。
+// 它和新的版本 Deployment 共用 Service，不删除时会继续接收流量
+func isLegacyDeployment(d *appsv1.Deployment, app string) bool {
+	return d.Name == app && !isManaged(d.ObjectMeta) &&
+		d.Labels[LabelApp] == app && d.Labels[LabelVersion] != ""
 }
 
-func (s *OperatorK8sService) buildDeploymentSpec(name, version, image string, replicas int32, pkg models.DeploymentPackage) *appsv1.Deployment {
-	labels := map[string]string{
-		"app":     name,
-		"version": version,
+// deleteLegacyDeployment 删除升级前创建的 Deployment，返回其版本；不存在时返回空字符串
+func (s *OperatorK8sService) deleteLegacyDeployment(ctx context.Context, app string) (string, error) {
+	deployments := s.clientset.AppsV1().Deployments(s.namespace)
+	d, err := deployments.Get(ctx, app, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "", nil
+		}
+		return "", fmt.Errorf("failed to get deployment %s: %w", app, err)
 	}
-
-	container := corev1.Container{
-		Name:  name,
-		Image: image,
+	if !isLegacyDeployment(d, app) {
+		return "", nil
+	}
+	if err := deployments.Delete(ctx, app, metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return "", fmt.Errorf("failed to delete legacy deployment %s: %w", app, err)
 	}
+	return deploymentVersion(d), nil
+}
 
-	// 处理端口映射
-	for _, p := range pkg.Ports {
-		container.Ports = append(container.Ports, corev1.ContainerPort{
-			ContainerPort: int32(p.ContainerPort),
-		})
+func (s *OperatorK8sService) buildDeploymentSpec(app, version string, replicas int32, pkg models.DeploymentPackage) (*appsv1.Deployment, error) {
+	labels := map[string]string{
+		LabelApp:     app,
+		LabelVersion: version,
+	}
+	meta := map[string]string{
+		LabelApp:       app,
+		LabelVersion:   version,
+		LabelManagedBy: ManagedBy,
 	}
 
-	// 处理环境变量
-	for k, v := range pkg.Environment {
-		container.Env = append(container.Env, corev1.EnvVar{
-			Name:  k,
-			Value: v,
-		})
+	podSpec, err := buildPodSpec(app, pkg)
+	if err != nil {
+		return nil, fmt.Errorf("version %s: %w", version, err)
 	}
 
 	return &appsv1.Deployment{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      name,
+			Name:      deploymentName(app, version),
 			Namespace: s.namespace,
-			Labels:    labels,
+			Labels:    meta,
 		},
 		Spec: appsv1.DeploymentSpec{
 			Replicas: &replicas,
@@ -206,10 +378,226 @@ func (s *OperatorK8sService) buildDeploymentSpec(name, version, image string, re
 				ObjectMeta: metav1.ObjectMeta{
 					Labels: labels,
 				},
-				Spec: corev1.PodSpec{
-					Containers: []corev1.Container{container},
-				},
+				Spec: podSpec,
 			},
 		},
+	}, nil
+}
+
+// appSelector 按应用（和版本）选择 Boreas 创建的资源的标签选择器，命名空间中其他带 app 标签的资源不会被选中
+func appSelector(app, version string) string {
+	return podSelector(app, version) + "," + LabelManagedBy + "=" + ManagedBy
+}
+
+// podSelector 按应用（和版本）选择 Pod 的标签选择器。Pod 模板没有 managed-by 标签，只用于查询状态和日志
+func podSelector(app, version string) string {
+	selector := LabelApp + "=" + app
+	if version != "" {
+		selector += "," + LabelVersion + "=" + version
+	}
+	return selector
+}
+
+// validateLabelValues 应用名和版本号会写入标签，必须是合法的标签值
+func validateLabelValues(req *models.ApplyDeploymentRequest) error {
+	if errs := validation.IsValidLabelValue(req.App); req.App == "" || len(errs) > 0 {
+		return fmt.Errorf("invalid app name %q: must be a valid label value", req.App)
+	}
+	for _, v := range req.Versions {
+		if errs := validation.IsValidLabelValue(v.Version); v.Version == "" || len(errs) > 0 {
+			return fmt.Errorf("invalid version %q: %s", v.Version, strings.Join(errs, "; "))
+		}
+	}
+	return nil
+}
+
+// listAppDeployments 列出应用的 Deployment，没有时返回 operatorapi.ErrAppNotFound
+func (s *OperatorK8sService) listAppDeployments(ctx context.Context, app, version string) ([]appsv1.Deployment, error) {
+	list, err := s.clientset.AppsV1().Deployments(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: appSelector(app, version)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deployments: %w", err)
+	}
+	if len(list.Items) == 0 {
+		return nil, fmt.Errorf("%w: no deployments for %s", operatorapi.ErrAppNotFound, appSelector(app, version))
+	}
+	return list.Items, nil
+}
+
+// Delete 删除应用的所有 Deployment（包括升级前创建的 Deployment）、Service、Ingress、HPA 以及清单类部署包创建的对象
+func (s *OperatorK8sService) Delete(app string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	hadManifests, err := s.deleteManifests(ctx, app)
+	if err != nil {
+		return err
+	}
+	legacy, err := s.deleteLegacyDeployment(ctx, app)
+	if err != nil {
+		return err
+	}
+	deployments, err := s.listAppDeployments(ctx, app, "")
+	if err != nil {
+		if (hadManifests || legacy != "") && errors.Is(err, operatorapi.ErrAppNotFound) {
+			return s.deleteExposure(ctx, app)
+		}
+		return err
+	}
+	for _, d := range deployments {
+		if !isManaged(d.ObjectMeta) {
+			continue
+		}
+		err := s.clientset.AppsV1().Deployments(s.namespace).Delete(ctx, d.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete deployment %s: %w", d.Name, err)
+		}
+	}
+	return s.deleteExposure(ctx, app)
+}
+
+// Scale 调整副本数：指定版本时只调整该版本，否则按各版本当前的副本比例分配总副本数
+func (s *OperatorK8sService) Scale(req *models.ScaleRequest) error {
+	if req.Replicas < 0 {
+		return fmt.Errorf("replicas must not be negative")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	deployments, err := s.listAppDeployments(ctx, req.App, req.Version)
+	if err != nil {
+		return err
+	}
+
+	shares := make([]models.VersionDeployment, len(deployments))
+	var current int32
+	for i := range deployments {
+		if r := deployments[i].Spec.Replicas; r != nil {
+			current += *r
+		}
+	}
+	for i := range deployments {
+		d := &deployments[i]
+		percent := 1 / float64(len(deployments))
+		if current > 0 {
+			percent = 0
+			if d.Spec.Replicas != nil {
+				percent = float64(*d.Spec.Replicas) / float64(current)
+			}
+		}
+		shares[i] = models.VersionDeployment{Version: d.Name, Percent: percent}
+	}
+	replicas := versionReplicas(req.Replicas, shares)
+
+	for i := range deployments {
+		d := &deployments[i]
+		n := replicas[d.Name]
+		d.Spec.Replicas = &n
+		if _, err := s.clientset.AppsV1().Deployments(s.namespace).Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to scale deployment %s: %w", d.Name, err)
+		}
+	}
+	return nil
+}
+
+// Restart 滚动重启应用（或指定版本）的 Deployment，与 kubectl rollout restart 相同，通过修改模板注解触发
+func (s *OperatorK8sService) Restart(req *models.RestartRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	deployments, err := s.listAppDeployments(ctx, req.App, req.Version)
+	if err != nil {
+		return err
+	}
+	restartedAt := time.Now().Format(time.RFC3339)
+	for i := range deployments {
+		d := &deployments[i]
+		if d.Spec.Template.Annotations == nil {
+			d.Spec.Template.Annotations = map[string]string{}
+		}
+		d.Spec.Template.Annotations["kubectl.kubernetes.io/restartedAt"] = restartedAt
+		if _, err := s.clientset.AppsV1().Deployments(s.namespace).Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to restart deployment %s: %w", d.Name, err)
+		}
+	}
+	return nil
+}
+
+// GetLogs 获取应用 Pod 的最近日志，单个 Pod 失败时记录在该实例的 Error 中
+func (s *OperatorK8sService) GetLogs(req *models.LogsRequest) (*models.LogsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	pods, err := s.clientset.CoreV1().Pods(s.namespace).List(ctx, metav1.ListOptions{LabelSelector: podSelector(req.App, req.Version)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	lines := int64(req.Lines)
+	if lines <= 0 {
+		lines = operatorapi.DefaultLogLines
+	}
+
+	resp := &models.LogsResponse{App: req.App, Instances: []models.InstanceLogs{}}
+	for _, pod := range pods.Items {
+		if req.Node != "" && pod.Name != req.Node {
+			continue
+		}
+		instance := models.InstanceLogs{Node: pod.Name, Version: pod.Labels[LabelVersion], Lines: []string{}}
+		raw, err := s.clientset.CoreV1().Pods(s.namespace).GetLogs(pod.Name, &corev1.PodLogOptions{TailLines: &lines}).DoRaw(ctx)
+		if err != nil {
+			instance.Error = err.Error()
+		} else {
+			instance.Lines = splitLogLines(string(raw))
+		}
+		resp.Instances = append(resp.Instances, instance)
+	}
+	return resp, nil
+}
+
+// GetEvents 获取与应用 Deployment/ReplicaSet/Pod 相关的 Kubernetes 事件，按时间排序
+func (s *OperatorK8sService) GetEvents(app string) (*models.EventsResponse, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), s.timeout)
+	defer cancel()
+
+	list, err := s.clientset.CoreV1().Events(s.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+
+	resp := &models.EventsResponse{App: app, Events: []models.OperatorEvent{}}
+	for _, e := range list.Items {
+		name := e.InvolvedObject.Name
+		if name != app && !strings.HasPrefix(name, app+"-") {
+			continue
+		}
+		resp.Events = append(resp.Events, models.OperatorEvent{
+			Time:    eventTime(&e),
+			Type:    e.Type,
+			Reason:  e.Reason,
+			Object:  e.InvolvedObject.Kind + "/" + name,
+			Message: e.Message,
+		})
+	}
+	sort.Slice(resp.Events, func(i, j int) bool { return resp.Events[i].Time.Before(resp.Events[j].Time) })
+	return resp, nil
+}
+
+func eventTime(e *corev1.Event) time.Time {
+	switch {
+	case !e.LastTimestamp.IsZero():
+		return e.LastTimestamp.Time
+	case !e.EventTime.IsZero():
+		return e.EventTime.Time
+	default:
+		return e.CreationTimestamp.Time
+	}
+}
+
+func splitLogLines(raw string) []string {
+	raw = strings.TrimRight(raw, "\n")
+	if raw == "" {
+		return []string{}
 	}
+	return strings.Split(raw, "\n")
 }
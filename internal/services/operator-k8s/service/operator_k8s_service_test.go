@@ -0,0 +1,771 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestVersionReplicas(t *testing.T) {
+	cases := []struct {
+		name     string
+		total    int
+		versions []models.VersionDeployment
+		want     map[string]int32
+	}{
+		{"canary", 10, []models.VersionDeployment{{Version: "v1", Percent: 0.9}, {Version: "v2", Percent: 0.1}},
+			map[string]int32{"v1": 9, "v2": 1}},
+		{"small canary gets one replica", 3, []models.VersionDeployment{{Version: "v1", Percent: 0.95}, {Version: "v2", Percent: 0.05}},
+			map[string]int32{"v1": 3, "v2": 1}},
+		{"largest remainder", 3, []models.VersionDeployment{{Version: "v1", Percent: 0.5}, {Version: "v2", Percent: 0.5}},
+			map[string]int32{"v1": 2, "v2": 1}},
+		{"zero percent is removed", 4, []models.VersionDeployment{{Version: "v1", Percent: 0}, {Version: "v2", Percent: 1}},
+			map[string]int32{"v1": 0, "v2": 4}},
+	}
+	for _, c := range cases {
+		got := versionReplicas(c.total, c.versions)
+		for v, n := range c.want {
+			if got[v] != n {
+				t.Errorf("%s: %s = %d, want %d", c.name, v, got[v], n)
+			}
+		}
+	}
+}
+
+func TestApply_OneDeploymentPerVersion(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	svc := NewOperatorK8sServiceWithClientset(clientset, "default", 5)
+	pkg := models.DeploymentPackage{
+		Type: "docker", Image: "demo:v1", Replicas: 10,
+		Ports: []models.PortMapping{{ContainerPort: 8080}},
+	}
+	canary := pkg
+	canary.Image = "demo:v2"
+
+	_, err := svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 0.9, Package: pkg},
+		{Version: "v2", Percent: 0.1, Package: canary},
+	}})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	for name, want := range map[string]int32{"demo-v1": 9, "demo-v2": 1} {
+		d, err := clientset.AppsV1().Deployments("default").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("deployment %s: %v", name, err)
+		}
+		if *d.Spec.Replicas != want {
+			t.Errorf("%s replicas = %d, want %d", name, *d.Spec.Replicas, want)
+		}
+	}
+	service, err := clientset.CoreV1().Services("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("shared service: %v", err)
+	}
+	if len(service.Spec.Selector) != 1 || service.Spec.Selector[LabelApp] != "demo" {
+		t.Errorf("service selector = %v", service.Spec.Selector)
+	}
+
+	status, err := svc.GetApplicationStatus("demo")
+	if err != nil {
+		t.Fatalf("GetApplicationStatus: %v", err)
+	}
+	if len(status.Versions) != 2 || status.Versions[0].Version != "v1" || status.Versions[1].Version != "v2" {
+		t.Fatalf("status versions = %+v", status.Versions)
+	}
+
+	// 旧版本降到 0% 后被回收
+	_, err = svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 0, Package: pkg},
+		{Version: "v2", Percent: 1, Package: canary},
+	}})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	list, _ := clientset.AppsV1().Deployments("default").List(ctx, metav1.ListOptions{})
+	if len(list.Items) != 1 || list.Items[0].Name != "demo-v2" || *list.Items[0].Spec.Replicas != 10 {
+		t.Fatalf("deployments after promotion = %+v", list.Items)
+	}
+}
+
+func TestApply_LeavesUnmanagedDeployments(t *testing.T) {
+	ctx := context.Background()
+	foreign := func(name string) *appsv1.Deployment {
+		return &appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{
+			Name: name, Namespace: "default", Labels: map[string]string{LabelApp: "demo"},
+		}}
+	}
+	clientset := fake.NewSimpleClientset(foreign("demo-worker"), foreign("demo-v3"))
+	svc := NewOperatorK8sServiceWithClientset(clientset, "default", 5)
+	pkg := models.DeploymentPackage{Type: "docker", Image: "demo:v1"}
+
+	for _, version := range []string{"v1", "v2"} {
+		if _, err := svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+			{Version: version, Percent: 1, Package: pkg},
+		}}); err != nil {
+			t.Fatalf("Apply %s: %v", version, err)
+		}
+	}
+	if _, err := svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v3", Percent: 1, Package: pkg},
+	}}); err == nil {
+		t.Fatal("expected an error when the version's deployment name is taken by an unmanaged deployment")
+	}
+	if err := svc.Delete("demo"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	list, _ := clientset.AppsV1().Deployments("default").List(ctx, metav1.ListOptions{})
+	if len(list.Items) != 2 {
+		t.Fatalf("deployments after Delete = %d, want the 2 unmanaged ones", len(list.Items))
+	}
+
+	_, err := svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "release/1.0", Percent: 1, Package: pkg},
+	}})
+	if !errors.Is(err, operatorapi.ErrInvalidPackage) {
+		t.Fatalf("expected ErrInvalidPackage for a version that is not a label value, got %v", err)
+	}
+}
+
+func TestApply_RejectsDeploymentNameCollision(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	svc := NewOperatorK8sServiceWithClientset(clientset, "default", 5)
+	pkg := models.DeploymentPackage{Type: "docker", Image: "demo:v1"}
+
+	if _, err := svc.Apply(&models.ApplyDeploymentRequest{App: "a-b", Versions: []models.VersionDeployment{
+		{Version: "c", Percent: 1, Package: pkg},
+	}}); err != nil {
+		t.Fatalf("Apply a-b/c: %v", err)
+	}
+	// a/b-c 与 a-b/c 的 Deployment 名称相同，不能覆盖
+	_, err := svc.Apply(&models.ApplyDeploymentRequest{App: "a", Versions: []models.VersionDeployment{
+		{Version: "b-c", Percent: 1, Package: pkg},
+	}})
+	if err == nil || !strings.Contains(err.Error(), "already belongs to app") {
+		t.Fatalf("expected an error when the deployment name belongs to another app, got %v", err)
+	}
+	d, err := clientset.AppsV1().Deployments("default").Get(ctx, "a-b-c", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Labels[LabelApp] != "a-b" || d.Labels[LabelVersion] != "c" {
+		t.Errorf("deployment a-b-c labels = %v, want app a-b version c", d.Labels)
+	}
+}
+
+func TestApply_DeletesLegacyDeployment(t *testing.T) {
+	ctx := context.Background()
+	// 升级前的 Operator 创建的对象：名称为应用名，带 app、version 标签，没有 managed-by 标签
+	legacy := func() *appsv1.Deployment {
+		labels := map[string]string{"app": "demo", "version": "v0"}
+		replicas := int32(2)
+		return &appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo", Namespace: "default", Labels: labels},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Selector: &metav1.LabelSelector{MatchLabels: labels},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: labels},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{{Name: "demo", Image: "demo:v0"}}},
+				},
+			},
+		}
+	}
+	clientset := fake.NewSimpleClientset(legacy())
+	svc := NewOperatorK8sServiceWithClientset(clientset, "default", 5)
+	pkg := models.DeploymentPackage{Type: "docker", Image: "demo:v1"}
+
+	resp, err := svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 1, Package: pkg},
+	}})
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if !strings.Contains(resp.Message, "removed v0") {
+		t.Errorf("message = %q, want the legacy version reported as removed", resp.Message)
+	}
+	list, _ := clientset.AppsV1().Deployments("default").List(ctx, metav1.ListOptions{})
+	if len(list.Items) != 1 || list.Items[0].Name != "demo-v1" {
+		t.Fatalf("deployments after upgrade = %+v", list.Items)
+	}
+
+	// 只有升级前的 Deployment 时 Delete 也会删除它
+	clientset = fake.NewSimpleClientset(legacy())
+	svc = NewOperatorK8sServiceWithClientset(clientset, "default", 5)
+	if err := svc.Delete("demo"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := clientset.AppsV1().Deployments("default").Get(ctx, "demo", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Fatalf("legacy deployment after Delete: %v", err)
+	}
+}
+
+func TestBuildPodSpec(t *testing.T) {
+	spec, err := buildPodSpec("demo", models.DeploymentPackage{
+		Type: "docker", Image: "demo:v1",
+		Command: []string{"/app"}, Args: []string{"--port=8080"},
+		Ports:     []models.PortMapping{{ContainerPort: 8080}},
+		Resources: models.ResourceLimits{CPURequest: "100m", CPULimit: "1", MemoryLimit: "512Mi"},
+		Volumes: []models.VolumeMount{
+			{Type: models.VolumeTypeConfigMap, Source: "demo-config", ContainerPath: "/etc/demo"},
+			{Type: models.VolumeTypeEmptyDir, ContainerPath: "/tmp"},
+		},
+		HealthCheck:      &models.HealthCheckConfig{Endpoint: "/healthz", Interval: 10, Timeout: 2},
+		ImagePullSecrets: []string{"registry"},
+	})
+	if err != nil {
+		t.Fatalf("buildPodSpec: %v", err)
+	}
+	c := spec.Containers[0]
+	if c.Command[0] != "/app" || c.Args[0] != "--port=8080" {
+		t.Errorf("command/args = %v %v", c.Command, c.Args)
+	}
+	if c.Resources.Requests.Cpu().String() != "100m" || c.Resources.Limits.Memory().String() != "512Mi" {
+		t.Errorf("resources = %+v", c.Resources)
+	}
+	if c.ReadinessProbe == nil || c.ReadinessProbe.HTTPGet.Path != "/healthz" || c.ReadinessProbe.HTTPGet.Port.IntValue() != 8080 {
+		t.Errorf("readiness probe = %+v", c.ReadinessProbe)
+	}
+	if len(spec.Volumes) != 2 || spec.Volumes[0].ConfigMap.Name != "demo-config" || len(c.VolumeMounts) != 2 {
+		t.Errorf("volumes = %+v, mounts = %+v", spec.Volumes, c.VolumeMounts)
+	}
+	if len(spec.ImagePullSecrets) != 1 || spec.ImagePullSecrets[0].Name != "registry" {
+		t.Errorf("image pull secrets = %v", spec.ImagePullSecrets)
+	}
+
+	unsupported := []models.DeploymentPackage{
+		{Resources: models.ResourceLimits{CPUSet: "0-1"}},
+		{Resources: models.ResourceLimits{MemoryLimit: "lots"}},
+		{Volumes: []models.VolumeMount{{HostPath: "/data", ContainerPath: "/data"}}},
+		{Volumes: []models.VolumeMount{{Type: models.VolumeTypeSecret, ContainerPath: "/secret"}}},
+		{HealthCheck: &models.HealthCheckConfig{Endpoint: "/healthz"}},
+		{Ports: []models.PortMapping{{ContainerPort: 8080, HostPort: 70000}}},
+	}
+	for i, pkg := range unsupported {
+		if _, err := buildPodSpec("demo", pkg); err == nil {
+			t.Errorf("case %d: expected error for %+v", i, pkg)
+		}
+	}
+}
+
+func TestBuildExposure_HostPort(t *testing.T) {
+	svc := NewOperatorK8sServiceWithClientset(fake.NewSimpleClientset(), "default", 5)
+	pkg := models.DeploymentPackage{Type: "docker", Image: "demo:v1", Ports: []models.PortMapping{{ContainerPort: 8080, HostPort: 80}}}
+
+	exp, err := svc.buildExposure("demo", []models.VersionDeployment{{Version: "v1", Percent: 1, Package: pkg}})
+	if err != nil {
+		t.Fatalf("buildExposure: %v", err)
+	}
+	if p := exp.service.Spec.Ports[0]; p.Port != 80 || p.TargetPort.IntValue() != 8080 {
+		t.Errorf("service port = %+v, want 80 -> 8080", p)
+	}
+
+	pkg.Service = &models.ServiceExposure{Ports: []models.ServicePort{{Port: 8000, TargetPort: 8080}}}
+	if _, err := svc.buildExposure("demo", []models.VersionDeployment{{Version: "v1", Percent: 1, Package: pkg}}); err == nil {
+		t.Error("expected an error for host_port combined with service.ports")
+	}
+}
+
+func TestApply_ExposureLifecycle(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	svc := NewOperatorK8sServiceWithClientset(clientset, "default", 5)
+	pkg := models.DeploymentPackage{
+		Type: "docker", Image: "demo:v1", Replicas: 4,
+		Ports:       []models.PortMapping{{ContainerPort: 8080}},
+		Service:     &models.ServiceExposure{Type: "NodePort", Ports: []models.ServicePort{{Port: 80, TargetPort: 8080}}},
+		Ingress:     &models.IngressExposure{Host: "demo.example.com", TLSSecret: "demo-tls"},
+		Autoscaling: &models.AutoscalingConfig{MinReplicas: 2, MaxReplicas: 10, TargetCPUPercent: 70},
+	}
+	versions := []models.VersionDeployment{{Version: "v1", Percent: 1, Package: pkg}}
+	if _, err := svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: versions}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	service, err := clientset.CoreV1().Services("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("service: %v", err)
+	}
+	if service.Spec.Type != "NodePort" || service.Spec.Ports[0].Port != 80 || service.Labels[LabelManagedBy] != ManagedBy {
+		t.Errorf("service = %+v", service)
+	}
+	ingress, err := clientset.NetworkingV1().Ingresses("default").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("ingress: %v", err)
+	}
+	backend := ingress.Spec.Rules[0].HTTP.Paths[0].Backend.Service
+	if ingress.Spec.Rules[0].Host != "demo.example.com" || backend.Name != "demo" || backend.Port.Number != 80 || ingress.Spec.TLS[0].SecretName != "demo-tls" {
+		t.Errorf("ingress = %+v", ingress.Spec)
+	}
+	hpa, err := clientset.AutoscalingV2().HorizontalPodAutoscalers("default").Get(ctx, "demo-v1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("hpa: %v", err)
+	}
+	if *hpa.Spec.MinReplicas != 2 || hpa.Spec.MaxReplicas != 10 || hpa.Spec.ScaleTargetRef.Name != "demo-v1" {
+		t.Errorf("hpa = %+v", hpa.Spec)
+	}
+
+	// 去掉 Ingress 和自动伸缩后，对应对象被回收
+	plain := pkg
+	plain.Ingress, plain.Autoscaling = nil, nil
+	versions[0].Package = plain
+	if _, err := svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: versions}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if ingresses, _ := clientset.NetworkingV1().Ingresses("default").List(ctx, metav1.ListOptions{}); len(ingresses.Items) != 0 {
+		t.Errorf("ingress not pruned: %+v", ingresses.Items)
+	}
+	if hpas, _ := clientset.AutoscalingV2().HorizontalPodAutoscalers("default").List(ctx, metav1.ListOptions{}); len(hpas.Items) != 0 {
+		t.Errorf("hpa not pruned: %+v", hpas.Items)
+	}
+
+	if err := svc.Delete("demo"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := clientset.CoreV1().Services("default").Get(ctx, "demo", metav1.GetOptions{}); err == nil {
+		t.Error("service not deleted with application")
+	}
+}
+
+func TestGetApplicationStatus_PodsAndRolloutFailure(t *testing.T) {
+	ctx := context.Background()
+	replicas := int32(2)
+	labels := map[string]string{LabelApp: "demo", LabelVersion: "v1"}
+	clientset := fake.NewSimpleClientset(
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo-v1", Namespace: "default",
+				Labels: map[string]string{LabelApp: "demo", LabelVersion: "v1", LabelManagedBy: ManagedBy}},
+			Spec: appsv1.DeploymentSpec{
+				Replicas: &replicas,
+				Template: corev1.PodTemplateSpec{ObjectMeta: metav1.ObjectMeta{Labels: labels}},
+			},
+			Status: appsv1.DeploymentStatus{
+				Replicas: 2, ReadyReplicas: 1,
+				Conditions: []appsv1.DeploymentCondition{{
+					Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse,
+					Reason: "ProgressDeadlineExceeded", Message: `ReplicaSet "demo-v1-abc" has timed out progressing.`,
+				}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo-v1-a", Namespace: "default", Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: "node-1"},
+			Status: corev1.PodStatus{
+				Phase:      corev1.PodRunning,
+				Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: corev1.ConditionTrue}},
+			},
+		},
+		&corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: "demo-v1-b", Namespace: "default", Labels: labels},
+			Spec:       corev1.PodSpec{NodeName: "node-2"},
+			Status: corev1.PodStatus{
+				Phase: corev1.PodRunning,
+				ContainerStatuses: []corev1.ContainerStatus{{
+					RestartCount: 3,
+					State:        corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}},
+				}},
+			},
+		},
+	)
+	svc := NewOperatorK8sServiceWithClientset(clientset, "default", 5)
+
+	status, err := svc.GetApplicationStatus("demo")
+	if err != nil {
+		t.Fatalf("GetApplicationStatus: %v", err)
+	}
+	nodes := status.Versions[0].Nodes
+	if len(nodes) != 2 {
+		t.Fatalf("nodes = %+v", nodes)
+	}
+	if nodes[0].Node != "node-1" || nodes[0].Status != models.InstanceStatusReady || nodes[0].Healthy.Level != 100 {
+		t.Errorf("ready pod = %+v", nodes[0])
+	}
+	if nodes[1].Instance != "demo-v1-b" || nodes[1].Status != models.InstanceStatusNotReady || nodes[1].Restarts != 3 ||
+		!strings.Contains(nodes[1].Healthy.Msg, "CrashLoopBackOff") {
+		t.Errorf("crashing pod = %+v", nodes[1])
+	}
+	if !strings.HasPrefix(status.Versions[0].Healthy.Msg, "Rollout failed") {
+		t.Errorf("version health = %+v", status.Versions[0].Healthy)
+	}
+
+	if err := svc.WatchRollout(ctx, "demo"); !errors.Is(err, ErrRolloutFailed) {
+		t.Errorf("WatchRollout error = %v, want ErrRolloutFailed", err)
+	}
+}
+
+func TestNewClusterRouter_KubeconfigContexts(t *testing.T) {
+	kubeconfig := filepath.Join(t.TempDir(), "config")
+	err := os.WriteFile(kubeconfig, []byte(`apiVersion: v1
+kind: Config
+current-context: west
+clusters:
+- name: west
+  cluster: {server: "https://west.example.com"}
+- name: east
+  cluster: {server: "https://east.example.com"}
+users:
+- name: boreas
+  user: {token: test}
+contexts:
+- name: west
+  context: {cluster: west, user: boreas}
+- name: east
+  context: {cluster: east, user: boreas}
+`), 0o600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	router, err := NewClusterRouter(RouterConfig{Kubeconfig: kubeconfig, Namespace: "apps", Namespaces: []string{"apps"}, Timeout: 5})
+	if err != nil {
+		t.Fatalf("NewClusterRouter: %v", err)
+	}
+	if router.DefaultCluster() != "west" || strings.Join(router.Clusters(), ",") != "east,west" {
+		t.Errorf("default = %s, clusters = %v", router.DefaultCluster(), router.Clusters())
+	}
+	if _, err := router.Service("east", ""); err != nil {
+		t.Errorf("Service(east): %v", err)
+	}
+	if _, err := router.Service("north", ""); !errors.Is(err, operatorapi.ErrUnknownTarget) {
+		t.Errorf("unknown cluster error = %v", err)
+	}
+	if _, err := router.Service("east", "kube-system"); !errors.Is(err, operatorapi.ErrUnknownTarget) {
+		t.Errorf("disallowed namespace error = %v", err)
+	}
+
+	if _, err := NewClusterRouter(RouterConfig{Kubeconfig: kubeconfig, Clusters: []string{"north"}}); err == nil {
+		t.Error("expected error for context missing from kubeconfig")
+	}
+}
+
+func TestCommandRenderer_RejectsUntrustedSources(t *testing.T) {
+	dir := t.TempDir()
+	r := NewCommandRenderer("false", "false", []string{dir, "oci://registry.example.com/charts"})
+
+	allowed := []string{
+		filepath.Join(dir, "web-1.0.0.tgz"),
+		filepath.Join(dir, "overlays", "prod"),
+		"oci://registry.example.com/charts/web",
+	}
+	for _, ref := range allowed {
+		if err := r.checkSource("chart", ref); err != nil {
+			t.Errorf("checkSource(%q) = %v", ref, err)
+		}
+	}
+
+	rejected := []string{
+		"--post-renderer=/bin/sh",
+		"charts/web",
+		filepath.Join(dir, "..", "other"),
+		dir + "-other/web",
+		"https://evil.example.com/web.tgz",
+		"oci://registry.example.com/charts-other/web",
+	}
+	for _, ref := range rejected {
+		if err := r.checkSource("chart", ref); err == nil {
+			t.Errorf("checkSource(%q) should be rejected", ref)
+		}
+	}
+
+	if _, err := r.RenderHelm(context.Background(), "--kubeconfig=/etc/shadow", "default", allowed[0], nil); err == nil || !strings.Contains(err.Error(), "invalid release") {
+		t.Errorf("RenderHelm with a flag as release = %v", err)
+	}
+	if _, err := NewCommandRenderer("false", "false", nil).RenderKustomize(context.Background(), allowed[1]); err == nil || !strings.Contains(err.Error(), "not in an allowed directory") {
+		t.Errorf("RenderKustomize without sources = %v", err)
+	}
+}
+
+type fakeRenderer struct {
+	chart  string
+	values map[string]interface{}
+	out    string
+}
+
+func (r *fakeRenderer) RenderHelm(_ context.Context, _, _, chart string, values map[string]interface{}) ([]byte, error) {
+	r.chart, r.values = chart, values
+	return []byte(r.out), nil
+}
+
+func (r *fakeRenderer) RenderKustomize(context.Context, string) ([]byte, error) {
+	return []byte(r.out), nil
+}
+
+func TestApply_ManifestPackages(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	dyn := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	// fake 的对象跟踪器不支持 unstructured 对象的 apply，这里按整体替换处理
+	dyn.PrependReactor("patch", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		patch := action.(k8stesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		obj := &unstructured.Unstructured{}
+		if err := json.Unmarshal(patch.GetPatch(), &obj.Object); err != nil {
+			return true, nil, err
+		}
+		tracker := dyn.Tracker()
+		if _, err := tracker.Get(patch.GetResource(), patch.GetNamespace(), patch.GetName()); apierrors.IsNotFound(err) {
+			return true, obj, tracker.Create(patch.GetResource(), obj, patch.GetNamespace())
+		}
+		return true, obj, tracker.Update(patch.GetResource(), obj, patch.GetNamespace())
+	})
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	renderer := &fakeRenderer{}
+	svc := NewOperatorK8sServiceWithClientset(clientset, "default", 5).WithDynamicClient(dyn, mapper).WithRenderer(renderer)
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	v1 := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: web-config
+data:
+  mode: v1
+`
+	apply := func(versions ...models.VersionDeployment) error {
+		_, err := svc.Apply(&models.ApplyDeploymentRequest{App: "demo", Versions: versions})
+		return err
+	}
+	if err := apply(models.VersionDeployment{Version: "v1", Percent: 1, Package: models.DeploymentPackage{Type: models.PackageTypeManifest, Manifests: v1}}); err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+
+	web, err := dyn.Resource(deployments).Namespace("default").Get(ctx, "web", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("deployment web: %v", err)
+	}
+	if web.GetLabels()[LabelVersion] != "v1" || web.GetLabels()[LabelManagedBy] != ManagedBy {
+		t.Errorf("labels = %v", web.GetLabels())
+	}
+	podLabels, _, _ := unstructured.NestedStringMap(web.Object, "spec", "template", "metadata", "labels")
+	if podLabels[LabelApp] != "web" || podLabels[LabelVersion] != "v1" {
+		t.Errorf("pod template labels = %v, existing app label must be kept", podLabels)
+	}
+	inv, err := svc.loadInventory(ctx, "demo")
+	if err != nil || len(inv.Versions["v1"]) != 2 {
+		t.Fatalf("inventory = %+v, %v", inv, err)
+	}
+
+	// 切换到只包含 Deployment 的 Helm 版本，ConfigMap 被回收
+	renderer.out = strings.SplitN(v1, "---", 2)[0]
+	err = apply(
+		models.VersionDeployment{Version: "v1", Percent: 0, Package: models.DeploymentPackage{Type: models.PackageTypeManifest, Manifests: v1}},
+		models.VersionDeployment{Version: "v2", Percent: 1, Package: models.DeploymentPackage{
+			Type: models.PackageTypeHelm, Chart: "web-2.0.0.tgz", Values: map[string]interface{}{"replicas": 2}}},
+	)
+	if err != nil {
+		t.Fatalf("Apply helm: %v", err)
+	}
+	if renderer.chart != "web-2.0.0.tgz" || renderer.values["replicas"] != 2 {
+		t.Errorf("renderer got chart %q values %v", renderer.chart, renderer.values)
+	}
+	if _, err := dyn.Resource(configMaps).Namespace("default").Get(ctx, "web-config", metav1.GetOptions{}); err == nil {
+		t.Error("web-config should be pruned")
+	}
+	web, _ = dyn.Resource(deployments).Namespace("default").Get(ctx, "web", metav1.GetOptions{})
+	if web.GetLabels()[LabelVersion] != "v2" {
+		t.Errorf("web version = %s, want v2", web.GetLabels()[LabelVersion])
+	}
+
+	// 集群级和其他命名空间的对象被拒绝，且不修改已有对象
+	for _, manifest := range []string{
+		"apiVersion: rbac.authorization.k8s.io/v1\nkind: ClusterRole\nmetadata:\n  name: admin\n",
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: other\n  namespace: kube-system\n",
+	} {
+		err := apply(models.VersionDeployment{Version: "v3", Percent: 1, Package: models.DeploymentPackage{Type: models.PackageTypeManifest, Manifests: manifest}})
+		if !errors.Is(err, operatorapi.ErrInvalidPackage) {
+			t.Errorf("Apply(%q) error = %v, want ErrInvalidPackage", manifest, err)
+		}
+	}
+	if _, err := dyn.Resource(deployments).Namespace("default").Get(ctx, "web", metav1.GetOptions{}); err != nil {
+		t.Errorf("web should survive rejected applies: %v", err)
+	}
+
+	// 手工创建的同名对象不会被覆盖
+	manual := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1", "kind": "ConfigMap",
+		"metadata": map[string]interface{}{"name": "manual", "namespace": "default"},
+		"data":     map[string]interface{}{"mode": "manual"},
+	}}
+	if _, err := dyn.Resource(configMaps).Namespace("default").Create(ctx, manual, metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	err = apply(models.VersionDeployment{Version: "v3", Percent: 1, Package: models.DeploymentPackage{Type: models.PackageTypeManifest,
+		Manifests: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: manual\ndata:\n  mode: boreas\n"}})
+	if err == nil || !strings.Contains(err.Error(), "not managed by") {
+		t.Errorf("Apply over an unmanaged object = %v", err)
+	}
+	manual, _ = dyn.Resource(configMaps).Namespace("default").Get(ctx, "manual", metav1.GetOptions{})
+	if mode, _, _ := unstructured.NestedString(manual.Object, "data", "mode"); mode != "manual" {
+		t.Errorf("unmanaged config map was modified: mode = %q", mode)
+	}
+
+	if err := svc.Delete("demo"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := dyn.Resource(deployments).Namespace("default").Get(ctx, "web", metav1.GetOptions{}); err == nil {
+		t.Error("web should be deleted with the app")
+	}
+}
+
+func TestController_ReconcilesBoreasApplication(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	router := NewClusterRouterWithClientsets(map[string]kubernetes.Interface{"prod": clientset}, "prod", "default", 5)
+	cr, err := operatorapi.NewApplicationObject("demo", "team-a", &operatorapi.ApplicationSpec{Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 1, Package: models.DeploymentPackage{Type: "docker", Image: "demo:v1", Replicas: 2}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr.SetGeneration(1)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{operatorapi.ApplicationResource: operatorapi.CRDKind + "List"}, cr)
+	controller := NewController(dyn, router, "", time.Minute)
+	resources := dyn.Resource(operatorapi.ApplicationResource).Namespace("team-a")
+
+	if err := controller.Reconcile(ctx, "team-a", "demo"); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	d, err := clientset.AppsV1().Deployments("team-a").Get(ctx, "demo-v1", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("deployment: %v", err)
+	}
+	obj, _ := resources.Get(ctx, "demo", metav1.GetOptions{})
+	status, _ := operatorapi.ApplicationStatusOf(obj)
+	if status.Phase != operatorapi.PhaseProgressing || status.ObservedGeneration != 1 || len(status.Versions) != 1 {
+		t.Errorf("status = %+v", status)
+	}
+	if !hasFinalizer(obj) {
+		t.Error("finalizer should be added")
+	}
+
+	// 手工修改的副本数在下一次调和时恢复
+	five := int32(5)
+	d.Spec.Replicas = &five
+	if _, err := clientset.AppsV1().Deployments("team-a").Update(ctx, d, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// generation 未变化的事件只刷新状态，Progressing 的资源等待下一次刷新
+	progressing := map[types.NamespacedName]bool{}
+	obj, _ = resources.Get(ctx, "demo", metav1.GetOptions{})
+	controller.reconcileLogged(ctx, obj, false, progressing)
+	d, _ = clientset.AppsV1().Deployments("team-a").Get(ctx, "demo-v1", metav1.GetOptions{})
+	if *d.Spec.Replicas != 5 {
+		t.Errorf("replicas after status refresh = %d, the unchanged spec should not be applied again", *d.Spec.Replicas)
+	}
+	if !progressing[types.NamespacedName{Namespace: "team-a", Name: "demo"}] {
+		t.Error("a Progressing application should be requeued")
+	}
+
+	if err := controller.Reconcile(ctx, "team-a", "demo"); err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	d, _ = clientset.AppsV1().Deployments("team-a").Get(ctx, "demo-v1", metav1.GetOptions{})
+	if *d.Spec.Replicas != 2 {
+		t.Errorf("replicas after resync = %d, want 2", *d.Spec.Replicas)
+	}
+
+	// 无效的 spec 写入 Failed 状态
+	obj, _ = resources.Get(ctx, "demo", metav1.GetOptions{})
+	_ = operatorapi.SetApplicationSpec(obj, &operatorapi.ApplicationSpec{App: "demo"})
+	obj, _ = resources.Update(ctx, obj, metav1.UpdateOptions{})
+	if err := controller.Reconcile(ctx, "team-a", "demo"); err == nil {
+		t.Error("expected error for spec without versions")
+	}
+	obj, _ = resources.Get(ctx, "demo", metav1.GetOptions{})
+	if status, _ := operatorapi.ApplicationStatusOf(obj); status.Phase != operatorapi.PhaseFailed {
+		t.Errorf("phase = %s, want Failed", status.Phase)
+	}
+
+	// 删除中的资源：删除应用后移除 finalizer
+	now := metav1.Now()
+	obj.SetDeletionTimestamp(&now)
+	if _, err := resources.Update(ctx, obj, metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := controller.Reconcile(ctx, "team-a", "demo"); err != nil {
+		t.Fatalf("Reconcile deletion: %v", err)
+	}
+	if _, err := clientset.AppsV1().Deployments("team-a").Get(ctx, "demo-v1", metav1.GetOptions{}); err == nil {
+		t.Error("deployment should be deleted with the BoreasApplication")
+	}
+	obj, _ = resources.Get(ctx, "demo", metav1.GetOptions{})
+	if hasFinalizer(obj) {
+		t.Error("finalizer should be removed")
+	}
+}
+
+func TestController_KeepsFinalizerWhenDeletionCannotBeRouted(t *testing.T) {
+	ctx := context.Background()
+	clientset := fake.NewSimpleClientset()
+	router := NewClusterRouterWithClientsets(map[string]kubernetes.Interface{"prod": clientset}, "prod", "default", 5)
+	router.namespaces = map[string]bool{"default": true}
+	cr, err := operatorapi.NewApplicationObject("demo", "team-a", &operatorapi.ApplicationSpec{Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 1, Package: models.DeploymentPackage{Type: "docker", Image: "demo:v1"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cr.SetFinalizers([]string{operatorapi.CRDFinalizer})
+	now := metav1.Now()
+	cr.SetDeletionTimestamp(&now)
+	dyn := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{operatorapi.ApplicationResource: operatorapi.CRDKind + "List"}, cr)
+	controller := NewController(dyn, router, "", time.Minute)
+
+	err = controller.Reconcile(ctx, "team-a", "demo")
+	if !errors.Is(err, operatorapi.ErrUnknownTarget) {
+		t.Fatalf("expected ErrUnknownTarget for an unroutable namespace, got %v", err)
+	}
+	obj, err := dyn.Resource(operatorapi.ApplicationResource).Namespace("team-a").Get(ctx, "demo", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasFinalizer(obj) {
+		t.Error("finalizer should be kept when the application cannot be deleted")
+	}
+	status, _ := operatorapi.ApplicationStatusOf(obj)
+	if status == nil || status.Phase != operatorapi.PhaseFailed || !strings.Contains(status.Message, "namespace team-a is not allowed") {
+		t.Errorf("status = %+v, want Failed with the routing error", status)
+	}
+}
@@ -0,0 +1,186 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boreas/internal/pkg/models"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/util/intstr"
+)
+
+// buildPodSpec 把部署包转换为 PodSpec
+// K8s 不支持的字段（cpu_set、host_path 卷等）返回错误，不会被静默忽略
+func buildPodSpec(app string, pkg models.DeploymentPackage) (corev1.PodSpec, error) {
+	container := corev1.Container{
+		Name:    app,
+		Image:   pkg.Image,
+		Command: pkg.Command,
+		Args:    pkg.Args,
+	}
+
+	// host_port 是生成的 Service 的端口（见 packageServicePorts），不映射为 Pod 的 hostPort
+	for _, p := range pkg.Ports {
+		if p.ContainerPort <= 0 || p.ContainerPort > 65535 {
+			return corev1.PodSpec{}, fmt.Errorf("container_port is required and must be at most 65535")
+		}
+		if p.HostPort < 0 || p.HostPort > 65535 {
+			return corev1.PodSpec{}, fmt.Errorf("invalid host_port %d", p.HostPort)
+		}
+		container.Ports = append(container.Ports, corev1.ContainerPort{
+			ContainerPort: int32(p.ContainerPort),
+			Protocol:      serviceProtocol(p.Protocol),
+		})
+	}
+
+	// 按名称排序，避免每次 Apply 因 map 顺序不同而触发滚动更新
+	keys := make([]string, 0, len(pkg.Environment))
+	for k := range pkg.Environment {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		container.Env = append(container.Env, corev1.EnvVar{Name: k, Value: pkg.Environment[k]})
+	}
+
+	resources, err := buildResources(pkg.Resources)
+	if err != nil {
+		return corev1.PodSpec{}, err
+	}
+	container.Resources = resources
+
+	if pkg.HealthCheck != nil {
+		probe, err := buildProbe(pkg.HealthCheck, pkg.Ports)
+		if err != nil {
+			return corev1.PodSpec{}, err
+		}
+		container.LivenessProbe = probe
+		container.ReadinessProbe = probe.DeepCopy()
+	}
+
+	podSpec := corev1.PodSpec{}
+	for i, v := range pkg.Volumes {
+		volume, err := buildVolume(fmt.Sprintf("volume-%d", i), v)
+		if err != nil {
+			return corev1.PodSpec{}, err
+		}
+		podSpec.Volumes = append(podSpec.Volumes, volume)
+		container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+			Name:      volume.Name,
+			MountPath: v.ContainerPath,
+			ReadOnly:  v.ReadOnly,
+		})
+	}
+
+	for _, name := range pkg.ImagePullSecrets {
+		if name == "" {
+			return corev1.PodSpec{}, fmt.Errorf("image pull secret name is required")
+		}
+		podSpec.ImagePullSecrets = append(podSpec.ImagePullSecrets, corev1.LocalObjectReference{Name: name})
+	}
+
+	podSpec.Containers = []corev1.Container{container}
+	return podSpec, nil
+}
+
+// buildResources 转换资源请求和限制
+func buildResources(r models.ResourceLimits) (corev1.ResourceRequirements, error) {
+	if r.CPUSet != "" {
+		return corev1.ResourceRequirements{}, fmt.Errorf("cpu_set is not supported on kubernetes")
+	}
+
+	var req corev1.ResourceRequirements
+	quantities := []struct {
+		field string
+		value string
+		list  *corev1.ResourceList
+		name  corev1.ResourceName
+	}{
+		{"cpu_request", r.CPURequest, &req.Requests, corev1.ResourceCPU},
+		{"cpu_limit", r.CPULimit, &req.Limits, corev1.ResourceCPU},
+		{"memory_request", r.MemoryRequest, &req.Requests, corev1.ResourceMemory},
+		{"memory_limit", r.MemoryLimit, &req.Limits, corev1.ResourceMemory},
+	}
+	for _, q := range quantities {
+		if q.value == "" {
+			continue
+		}
+		quantity, err := resource.ParseQuantity(q.value)
+		if err != nil {
+			return corev1.ResourceRequirements{}, fmt.Errorf("invalid %s %q: %w", q.field, q.value, err)
+		}
+		if *q.list == nil {
+			*q.list = corev1.ResourceList{}
+		}
+		(*q.list)[q.name] = quantity
+	}
+
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		request, hasRequest := req.Requests[name]
+		limit, hasLimit := req.Limits[name]
+		if hasRequest && hasLimit && request.Cmp(limit) > 0 {
+			return corev1.ResourceRequirements{}, fmt.Errorf("%s request %s exceeds limit %s", name, request.String(), limit.String())
+		}
+	}
+	return req, nil
+}
+
+// buildProbe 转换健康检查：Endpoint 为 HTTP 路径时使用 HTTP GET，为空时检查 TCP 端口
+func buildProbe(hc *models.HealthCheckConfig, ports []models.PortMapping) (*corev1.Probe, error) {
+	port := hc.Port
+	if port == 0 && len(ports) > 0 {
+		port = ports[0].ContainerPort
+	}
+	if port <= 0 {
+		return nil, fmt.Errorf("health check port is required when no container port is exposed")
+	}
+	if hc.Interval < 0 || hc.Timeout < 0 || hc.InitialDelay < 0 {
+		return nil, fmt.Errorf("health check interval, timeout and initial_delay must not be negative")
+	}
+
+	probe := &corev1.Probe{
+		InitialDelaySeconds: int32(hc.InitialDelay),
+		PeriodSeconds:       int32(hc.Interval),
+		TimeoutSeconds:      int32(hc.Timeout),
+	}
+	switch {
+	case hc.Endpoint == "":
+		probe.TCPSocket = &corev1.TCPSocketAction{Port: intstr.FromInt(port)}
+	case strings.HasPrefix(hc.Endpoint, "/"):
+		probe.HTTPGet = &corev1.HTTPGetAction{Path: hc.Endpoint, Port: intstr.FromInt(port)}
+	default:
+		return nil, fmt.Errorf("health check endpoint must be an HTTP path starting with /: %s", hc.Endpoint)
+	}
+	return probe, nil
+}
+
+// buildVolume 转换卷，支持 ConfigMap、Secret、emptyDir 和 PVC
+func buildVolume(name string, v models.VolumeMount) (corev1.Volume, error) {
+	if v.ContainerPath == "" {
+		return corev1.Volume{}, fmt.Errorf("container_path is required for volume")
+	}
+
+	volume := corev1.Volume{Name: name}
+	switch v.Type {
+	case models.VolumeTypeConfigMap:
+		volume.ConfigMap = &corev1.ConfigMapVolumeSource{LocalObjectReference: corev1.LocalObjectReference{Name: v.Source}}
+	case models.VolumeTypeSecret:
+		volume.Secret = &corev1.SecretVolumeSource{SecretName: v.Source}
+	case models.VolumeTypeEmptyDir:
+		volume.EmptyDir = &corev1.EmptyDirVolumeSource{}
+		return volume, nil
+	case models.VolumeTypePVC:
+		volume.PersistentVolumeClaim = &corev1.PersistentVolumeClaimVolumeSource{ClaimName: v.Source, ReadOnly: v.ReadOnly}
+	case "", models.VolumeTypeHostPath:
+		return corev1.Volume{}, fmt.Errorf("host_path volumes are not supported on kubernetes: %s", v.ContainerPath)
+	default:
+		return corev1.Volume{}, fmt.Errorf("unsupported volume type: %s", v.Type)
+	}
+
+	if v.Source == "" {
+		return corev1.Volume{}, fmt.Errorf("source is required for %s volume %s", v.Type, v.ContainerPath)
+	}
+	return volume, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+// Renderer 把 Helm Chart 和 Kustomize overlay 渲染为多文档 YAML
+type Renderer interface {
+	RenderHelm(ctx context.Context, release, namespace, chart string, values map[string]interface{}) ([]byte, error)
+	RenderKustomize(ctx context.Context, path string) ([]byte, error)
+}
+
+// CommandRenderer 调用本地的 helm 和 kustomize 命令渲染
+type CommandRenderer struct {
+	Helm      string   // helm 可执行文件，默认 helm
+	Kustomize string   // kustomize 可执行文件，默认 kustomize；设置为 kubectl 时使用 kubectl kustomize
+	Sources   []string // 允许的 Chart/overlay 来源：本地目录，或带 scheme 的仓库地址前缀（如 oci://registry/charts）
+}
+
+// NewCommandRenderer 创建命令行渲染器，sources 为空时拒绝所有 helm 和 kustomize 部署包
+func NewCommandRenderer(helm, kustomize string, sources []string) *CommandRenderer {
+	if helm == "" {
+		helm = "helm"
+	}
+	if kustomize == "" {
+		kustomize = "kustomize"
+	}
+	return &CommandRenderer{Helm: helm, Kustomize: kustomize, Sources: sources}
+}
+
+// checkArg 拒绝会被命令当作参数解析的值
+func checkArg(name, value string) error {
+	if value == "" || strings.HasPrefix(value, "-") {
+		return fmt.Errorf("invalid %s %q", name, value)
+	}
+	return nil
+}
+
+// checkSource 检查 Chart 或 overlay 位于允许的来源中
+// 仓库地址按前缀匹配；本地路径必须是绝对路径，解析符号链接后位于允许的目录下
+func (r *CommandRenderer) checkSource(name, ref string) error {
+	if err := checkArg(name, ref); err != nil {
+		return err
+	}
+	if strings.Contains(ref, "://") {
+		for _, src := range r.Sources {
+			if !strings.Contains(src, "://") {
+				continue
+			}
+			prefix := strings.TrimSuffix(src, "/")
+			if ref == prefix || strings.HasPrefix(ref, prefix+"/") {
+				return nil
+			}
+		}
+		return fmt.Errorf("%s %s is not in an allowed repository", name, ref)
+	}
+
+	if !filepath.IsAbs(ref) {
+		return fmt.Errorf("%s %s must be an absolute path", name, ref)
+	}
+	path := filepath.Clean(ref)
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		path = resolved
+	}
+	for _, src := range r.Sources {
+		if strings.Contains(src, "://") {
+			continue
+		}
+		dir := filepath.Clean(src)
+		if resolved, err := filepath.EvalSymlinks(dir); err == nil {
+			dir = resolved
+		}
+		if rel, err := filepath.Rel(dir, path); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s %s is not in an allowed directory", name, ref)
+}
+
+// RenderHelm 执行 helm template，values 写入临时文件（JSON 是合法的 YAML）
+func (r *CommandRenderer) RenderHelm(ctx context.Context, release, namespace, chart string, values map[string]interface{}) ([]byte, error) {
+	if err := checkArg("release", release); err != nil {
+		return nil, err
+	}
+	if err := checkArg("namespace", namespace); err != nil {
+		return nil, err
+	}
+	if err := r.checkSource("chart", chart); err != nil {
+		return nil, err
+	}
+	args := []string{"template", "--namespace", namespace}
+	if len(values) > 0 {
+		data, err := json.Marshal(values)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal helm values: %w", err)
+		}
+		f, err := os.CreateTemp("", "boreas-values-*.json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create values file: %w", err)
+		}
+		defer os.Remove(f.Name())
+		if _, err := f.Write(data); err != nil {
+			f.Close()
+			return nil, fmt.Errorf("failed to write values file: %w", err)
+		}
+		f.Close()
+		args = append(args, "--values", f.Name())
+	}
+	args = append(args, "--", release, chart)
+	return run(ctx, r.Helm, args...)
+}
+
+// RenderKustomize 执行 kustomize build
+func (r *CommandRenderer) RenderKustomize(ctx context.Context, path string) ([]byte, error) {
+	if err := r.checkSource("kustomization", path); err != nil {
+		return nil, err
+	}
+	if r.Kustomize == "kubectl" {
+		return run(ctx, "kubectl", "kustomize", "--", path)
+	}
+	return run(ctx, r.Kustomize, "build", "--", path)
+}
+
+func run(ctx context.Context, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("%s %s failed: %w: %s", name, args[0], err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// isManifestPackage 是否为清单类部署包
+func isManifestPackage(pkgType string) bool {
+	switch pkgType {
+	case models.PackageTypeManifest, models.PackageTypeHelm, models.PackageTypeKustomize:
+		return true
+	}
+	return false
+}
+
+// renderPackage 把清单类部署包渲染为 YAML
+func (s *OperatorK8sService) renderPackage(ctx context.Context, app string, pkg models.DeploymentPackage) ([]byte, error) {
+	switch pkg.Type {
+	case models.PackageTypeManifest:
+		if strings.TrimSpace(pkg.Manifests) == "" {
+			return nil, fmt.Errorf("manifests are required for manifest package")
+		}
+		return []byte(pkg.Manifests), nil
+	case models.PackageTypeHelm:
+		if pkg.Chart == "" {
+			return nil, fmt.Errorf("chart is required for helm package")
+		}
+		if s.renderer == nil {
+			return nil, fmt.Errorf("helm rendering is not configured")
+		}
+		return s.renderer.RenderHelm(ctx, app, s.namespace, pkg.Chart, pkg.Values)
+	case models.PackageTypeKustomize:
+		if pkg.Kustomization == "" {
+			return nil, fmt.Errorf("kustomization is required for kustomize package")
+		}
+		if s.renderer == nil {
+			return nil, fmt.Errorf("kustomize rendering is not configured")
+		}
+		return s.renderer.RenderKustomize(ctx, pkg.Kustomization)
+	default:
+		return nil, fmt.Errorf("unsupported package type: %s", pkg.Type)
+	}
+}
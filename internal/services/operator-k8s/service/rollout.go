@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/boreas/internal/pkg/models"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ErrRolloutFailed Deployment 的滚动更新失败（如超过 progressDeadlineSeconds）
+var ErrRolloutFailed = errors.New("rollout failed")
+
+// progressDeadlineExceeded Deployment Progressing 条件中表示滚动更新超时的原因
+const progressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+// rolloutFailedPrefix 滚动更新超时的版本在健康信息中的前缀
+const rolloutFailedPrefix = "Rollout failed: "
+
+// WatchRollout 监听应用所有版本 Deployment 的滚动更新，全部完成时返回 nil，
+// 任一 Deployment 超过 progressDeadlineSeconds 时返回 ErrRolloutFailed，ctx 结束时返回 ctx 的错误
+func (s *OperatorK8sService) WatchRollout(ctx context.Context, app string) error {
+	deployments := s.clientset.AppsV1().Deployments(s.namespace)
+	opts := metav1.ListOptions{LabelSelector: appSelector(app, "")}
+
+	list, err := deployments.List(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to list deployments: %w", err)
+	}
+	state := make(map[string]*appsv1.Deployment, len(list.Items))
+	for i := range list.Items {
+		state[list.Items[i].Name] = &list.Items[i]
+	}
+	if done, err := rolloutResult(state); done {
+		return err
+	}
+
+	opts.ResourceVersion = list.ResourceVersion
+	w, err := deployments.Watch(ctx, opts)
+	if err != nil {
+		return fmt.Errorf("failed to watch deployments: %w", err)
+	}
+	defer w.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case event, ok := <-w.ResultChan():
+			if !ok {
+				return fmt.Errorf("rollout watch of %s closed", app)
+			}
+			switch event.Type {
+			case watch.Added, watch.Modified:
+				if d, ok := event.Object.(*appsv1.Deployment); ok {
+					state[d.Name] = d
+				}
+			case watch.Deleted:
+				if d, ok := event.Object.(*appsv1.Deployment); ok {
+					delete(state, d.Name)
+				}
+			case watch.Error:
+				return fmt.Errorf("rollout watch of %s failed: %w", app, apierrors.FromObject(event.Object))
+			}
+			if done, err := rolloutResult(state); done {
+				return err
+			}
+		}
+	}
+}
+
+// rolloutResult 汇总所有 Deployment 的滚动更新状态：有失败时返回 (true, ErrRolloutFailed)，
+// 全部完成时返回 (true, nil)，否则 (false, nil)
+func rolloutResult(deployments map[string]*appsv1.Deployment) (bool, error) {
+	names := make([]string, 0, len(deployments))
+	for name := range deployments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	complete := true
+	for _, name := range names {
+		d := deployments[name]
+		if msg, failed := rolloutFailure(d); failed {
+			return true, fmt.Errorf("%w: %s: %s", ErrRolloutFailed, name, msg)
+		}
+		complete = complete && rolloutComplete(d)
+	}
+	return complete, nil
+}
+
+// rolloutComplete Deployment 的新副本已全部更新并可用
+func rolloutComplete(d *appsv1.Deployment) bool {
+	replicas := int32(1)
+	if d.Spec.Replicas != nil {
+		replicas = *d.Spec.Replicas
+	}
+	return d.Status.ObservedGeneration >= d.Generation &&
+		d.Status.UpdatedReplicas == replicas &&
+		d.Status.Replicas == replicas &&
+		d.Status.AvailableReplicas == replicas
+}
+
+// rolloutFailure Deployment 的 Progressing 条件是否因超时失败
+func rolloutFailure(d *appsv1.Deployment) (string, bool) {
+	for _, c := range d.Status.Conditions {
+		if c.Type == appsv1.DeploymentProgressing && c.Status == corev1.ConditionFalse && c.Reason == progressDeadlineExceeded {
+			return c.Message, true
+		}
+	}
+	return "", false
+}
+
+// podNodeStatus 把 Pod 转换为实例状态：就绪为 100，其他为 0 并说明原因
+func podNodeStatus(p *corev1.Pod) models.NodeStatus {
+	status := models.NodeStatus{
+		Node:     p.Spec.NodeName,
+		Instance: p.Name,
+	}
+	var reasons []string
+	for _, c := range p.Status.ContainerStatuses {
+		status.Restarts += int(c.RestartCount)
+		if c.State.Waiting != nil && c.State.Waiting.Reason != "" {
+			reasons = append(reasons, c.State.Waiting.Reason)
+		}
+	}
+
+	switch {
+	case p.DeletionTimestamp != nil:
+		status.Status = models.InstanceStatusTerminating
+	case p.Status.Phase == corev1.PodPending:
+		status.Status = models.InstanceStatusPending
+	case p.Status.Phase == corev1.PodFailed || p.Status.Phase == corev1.PodSucceeded:
+		status.Status = models.InstanceStatusFailed
+		if p.Status.Reason != "" {
+			reasons = append(reasons, p.Status.Reason)
+		}
+	case podReady(p):
+		status.Status = models.InstanceStatusReady
+	default:
+		status.Status = models.InstanceStatusNotReady
+	}
+
+	if status.Status == models.InstanceStatusReady {
+		status.Healthy = models.HealthInfo{Level: 100, Msg: "Ready"}
+	} else {
+		msg := strings.ReplaceAll(status.Status, "_", " ")
+		if len(reasons) > 0 {
+			msg += ": " + strings.Join(reasons, ", ")
+		}
+		status.Healthy = models.HealthInfo{Level: 0, Msg: msg}
+	}
+	return status
+}
+
+func podReady(p *corev1.Pod) bool {
+	for _, c := range p.Status.Conditions {
+		if c.Type == corev1.PodReady {
+			return c.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// podVersion Pod 所属的版本，没有 version 标签时取镜像
+func podVersion(p *corev1.Pod) string {
+	if v := p.Labels[LabelVersion]; v != "" {
+		return v
+	}
+	if len(p.Spec.Containers) > 0 {
+		return p.Spec.Containers[0].Image
+	}
+	return ""
+}
@@ -0,0 +1,310 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"go.uber.org/zap"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// InClusterName 未配置 kubeconfig 时唯一可用的集群名称
+const InClusterName = "in-cluster"
+
+// RouterConfig 集群路由配置
+type RouterConfig struct {
+	Kubeconfig string   // kubeconfig 文件，为空时使用集群内配置（只有一个集群）
+	Context    string   // 默认上下文，为空时使用 kubeconfig 的 current-context
+	Namespace  string   // 默认命名空间
+	Clusters   []string // 允许使用的上下文，为空时允许 kubeconfig 中的所有上下文
+	Namespaces []string // 允许使用的命名空间，为空时不限制
+	Timeout    int      // 操作超时（秒）
+	Renderer   Renderer // Helm/Kustomize 渲染器，为空时不支持这两种部署包
+}
+
+// ClusterHealth 集群的连通性
+type ClusterHealth struct {
+	Cluster   string    `json:"cluster"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// clusterClients 一个集群的客户端
+type clusterClients struct {
+	clientset kubernetes.Interface
+	dynamic   dynamic.Interface // 为 nil 时不支持清单类部署包
+	mapper    meta.RESTMapper
+}
+
+// ClusterRouter 按 kubeconfig 上下文和命名空间选择部署目标
+// 每个集群的客户端在首次使用时创建并缓存，健康状态按集群分别记录
+type ClusterRouter struct {
+	defaultCluster   string
+	defaultNamespace string
+	clusters         map[string]bool // 可用的集群
+	namespaces       map[string]bool // 允许的命名空间，为空时不限制
+	timeout          int
+	renderer         Renderer
+	newClients       func(cluster string) (*clusterClients, error)
+
+	mu      sync.Mutex
+	clients map[string]*clusterClients
+	health  map[string]*ClusterHealth
+}
+
+// NewClusterRouter 读取 kubeconfig 中的上下文创建路由，并立即创建默认集群的客户端
+func NewClusterRouter(cfg RouterConfig) (*ClusterRouter, error) {
+	r := &ClusterRouter{
+		defaultNamespace: cfg.Namespace,
+		clusters:         make(map[string]bool),
+		namespaces:       toSet(cfg.Namespaces),
+		timeout:          cfg.Timeout,
+		renderer:         cfg.Renderer,
+		clients:          make(map[string]*clusterClients),
+		health:           make(map[string]*ClusterHealth),
+	}
+
+	if cfg.Kubeconfig == "" {
+		if cfg.Context != "" || len(cfg.Clusters) > 0 {
+			return nil, fmt.Errorf("kubeconfig is required to select kubernetes contexts")
+		}
+		r.defaultCluster = InClusterName
+		r.clusters[InClusterName] = true
+		r.newClients = func(string) (*clusterClients, error) {
+			config, err := rest.InClusterConfig()
+			if err != nil {
+				return nil, fmt.Errorf("failed to build kubernetes config: %w", err)
+			}
+			return newClusterClients(config)
+		}
+	} else {
+		raw, err := clientcmd.LoadFromFile(cfg.Kubeconfig)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig %s: %w", cfg.Kubeconfig, err)
+		}
+		allowed := toSet(cfg.Clusters)
+		for name := range raw.Contexts {
+			if len(allowed) == 0 || allowed[name] {
+				r.clusters[name] = true
+			}
+		}
+		for name := range allowed {
+			if raw.Contexts[name] == nil {
+				return nil, fmt.Errorf("context %s not found in kubeconfig", name)
+			}
+		}
+
+		r.defaultCluster = cfg.Context
+		if r.defaultCluster == "" {
+			r.defaultCluster = raw.CurrentContext
+		}
+		if !r.clusters[r.defaultCluster] {
+			return nil, fmt.Errorf("default context %q is not available", r.defaultCluster)
+		}
+		r.newClients = func(cluster string) (*clusterClients, error) {
+			config, err := clientcmd.NewNonInteractiveClientConfig(*raw, cluster, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+			if err != nil {
+				return nil, fmt.Errorf("failed to build kubernetes config for context %s: %w", cluster, err)
+			}
+			return newClusterClients(config)
+		}
+	}
+
+	if _, err := r.clusterClients(r.defaultCluster); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// newClusterClients 创建集群的 clientset、dynamic client 和基于发现接口的 RESTMapper
+func newClusterClients(config *rest.Config) (*clusterClients, error) {
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	dyn, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+	return &clusterClients{clientset: clientset, dynamic: dyn, mapper: mapper}, nil
+}
+
+// NewClusterRouterWithClientsets 使用已有的 clientset 创建路由（测试中传入 fake clientset）
+func NewClusterRouterWithClientsets(clientsets map[string]kubernetes.Interface, defaultCluster, namespace string, timeout int) *ClusterRouter {
+	r := &ClusterRouter{
+		defaultCluster:   defaultCluster,
+		defaultNamespace: namespace,
+		clusters:         make(map[string]bool),
+		namespaces:       map[string]bool{},
+		timeout:          timeout,
+		clients:          make(map[string]*clusterClients),
+		health:           make(map[string]*ClusterHealth),
+		newClients: func(cluster string) (*clusterClients, error) {
+			return nil, fmt.Errorf("%w: cluster %s", operatorapi.ErrUnknownTarget, cluster)
+		},
+	}
+	for name, cs := range clientsets {
+		r.clusters[name] = true
+		r.clients[name] = &clusterClients{clientset: cs}
+	}
+	return r
+}
+
+// Service 返回部署目标的服务，cluster/namespace 为空时使用默认值
+// 集群不存在或命名空间不允许时返回 operatorapi.ErrUnknownTarget
+func (r *ClusterRouter) Service(cluster, namespace string) (*OperatorK8sService, error) {
+	if cluster == "" {
+		cluster = r.defaultCluster
+	}
+	if namespace == "" {
+		namespace = r.defaultNamespace
+	}
+	if len(r.namespaces) > 0 && !r.namespaces[namespace] {
+		return nil, fmt.Errorf("%w: namespace %s is not allowed", operatorapi.ErrUnknownTarget, namespace)
+	}
+
+	clients, err := r.clusterClients(cluster)
+	if err != nil {
+		return nil, err
+	}
+	svc := NewOperatorK8sServiceWithClientset(clients.clientset, namespace, r.timeout)
+	if clients.dynamic != nil {
+		svc.WithDynamicClient(clients.dynamic, clients.mapper)
+	}
+	return svc.WithRenderer(r.renderer), nil
+}
+
+// DynamicClient 集群的 dynamic client，cluster 为空时使用默认集群
+func (r *ClusterRouter) DynamicClient(cluster string) (dynamic.Interface, error) {
+	if cluster == "" {
+		cluster = r.defaultCluster
+	}
+	clients, err := r.clusterClients(cluster)
+	if err != nil {
+		return nil, err
+	}
+	if clients.dynamic == nil {
+		return nil, fmt.Errorf("no dynamic client for cluster %s", cluster)
+	}
+	return clients.dynamic, nil
+}
+
+// DefaultCluster 默认集群名称
+func (r *ClusterRouter) DefaultCluster() string {
+	return r.defaultCluster
+}
+
+// Clusters 可用的集群名称
+func (r *ClusterRouter) Clusters() []string {
+	names := make([]string, 0, len(r.clusters))
+	for name := range r.clusters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *ClusterRouter) clusterClients(cluster string) (*clusterClients, error) {
+	if !r.clusters[cluster] {
+		return nil, fmt.Errorf("%w: cluster %s", operatorapi.ErrUnknownTarget, cluster)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.clients[cluster]; ok {
+		return c, nil
+	}
+	c, err := r.newClients(cluster)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes clients for %s: %w", cluster, err)
+	}
+	r.clients[cluster] = c
+	return c, nil
+}
+
+// CheckHealth 检查所有集群的连通性并记录结果
+func (r *ClusterRouter) CheckHealth(ctx context.Context) []ClusterHealth {
+	result := make([]ClusterHealth, 0, len(r.clusters))
+	for _, cluster := range r.Clusters() {
+		h := ClusterHealth{Cluster: cluster, CheckedAt: time.Now()}
+		c, err := r.clusterClients(cluster)
+		if err == nil {
+			_, err = c.clientset.Discovery().ServerVersion()
+		}
+		if err != nil {
+			h.Error = err.Error()
+		} else {
+			h.Healthy = true
+		}
+
+		r.mu.Lock()
+		prev := r.health[cluster]
+		r.health[cluster] = &h
+		r.mu.Unlock()
+		if prev != nil && prev.Healthy != h.Healthy {
+			logger.GetLogger().Info("Cluster health changed",
+				zap.String("cluster", cluster),
+				zap.Bool("healthy", h.Healthy),
+				zap.String("error", h.Error))
+		}
+		result = append(result, h)
+	}
+	return result
+}
+
+// Health 最近一次检查的集群健康状态，未检查过的集群 CheckedAt 为零值
+func (r *ClusterRouter) Health() []ClusterHealth {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := make([]ClusterHealth, 0, len(r.clusters))
+	for _, cluster := range r.Clusters() {
+		if h, ok := r.health[cluster]; ok {
+			result = append(result, *h)
+		} else {
+			result = append(result, ClusterHealth{Cluster: cluster})
+		}
+	}
+	return result
+}
+
+// StartHealthCheck 在后台按间隔检查所有集群，ctx 结束时停止
+func (r *ClusterRouter) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			r.CheckHealth(ctx)
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func toSet(items []string) map[string]bool {
+	set := make(map[string]bool, len(items))
+	for _, item := range items {
+		if item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
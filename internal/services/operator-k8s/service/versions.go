@@ -0,0 +1,83 @@
+package service
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/boreas/internal/pkg/models"
+)
+
+var invalidNameChars = regexp.MustCompile(`[^a-z0-9.-]+`)
+
+// deploymentName 版本 Deployment 的名称 <app>-<version>，按 DNS-1123 规范转为小写并替换非法字符
+func deploymentName(app, version string) string {
+	name := invalidNameChars.ReplaceAllString(strings.ToLower(app+"-"+version), "-")
+	if len(name) > 253 {
+		name = name[:253]
+	}
+	return strings.Trim(name, "-.")
+}
+
+// totalReplicas 应用的总副本数，取各版本 Package.Replicas 的最大值，都未设置时为 1
+func totalReplicas(versions []models.VersionDeployment) int {
+	total := 0
+	for _, v := range versions {
+		if v.Package.Replicas > total {
+			total = v.Package.Replicas
+		}
+	}
+	if total == 0 {
+		total = 1
+	}
+	return total
+}
+
+// versionReplicas 按 Percent 把总副本数分配给各版本（最大余数法，总和等于 total * ΣPercent 取整）
+// Percent > 0 的版本至少分到 1 个副本，保证灰度版本真正运行；Percent 为 0 的版本为 0
+func versionReplicas(total int, versions []models.VersionDeployment) map[string]int32 {
+	type share struct {
+		version   string
+		replicas  int
+		remainder float64
+	}
+
+	shares := make([]share, 0, len(versions))
+	var percentSum float64
+	assigned := 0
+	for _, v := range versions {
+		exact := v.Percent * float64(total)
+		floor := int(math.Floor(exact + 1e-9))
+		shares = append(shares, share{version: v.Version, replicas: floor, remainder: exact - float64(floor)})
+		percentSum += v.Percent
+		assigned += floor
+	}
+
+	target := int(math.Round(percentSum * float64(total)))
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return shares[order[i]].remainder > shares[order[j]].remainder
+	})
+	for _, i := range order {
+		if assigned >= target {
+			break
+		}
+		if shares[i].remainder > 1e-9 {
+			shares[i].replicas++
+			assigned++
+		}
+	}
+
+	result := make(map[string]int32, len(shares))
+	for i, sh := range shares {
+		if sh.replicas == 0 && versions[i].Percent > 0 && total > 0 {
+			sh.replicas = 1
+		}
+		result[sh.version] = int32(sh.replicas)
+	}
+	return result
+}
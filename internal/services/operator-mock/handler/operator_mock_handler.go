@@ -1,16 +1,28 @@
 package handler
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"sort"
 
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/pkg/utils"
 	"github.com/boreas/internal/services/operator-mock/service"
 	"github.com/gin-gonic/gin"
 )
 
+// capabilities Mock Operator 声明的能力
+var capabilities = operatorapi.NewCapabilities("mock",
+	operatorapi.FeatureMultiVersion,
+	operatorapi.FeatureVersionStatus,
+	operatorapi.FeatureScale,
+)
+
 type OperatorMockHandler struct {
 	mockService *service.MockDeploymentClient
+	notifier    *operatorapi.Notifier
 }
 
 func NewOperatorMockHandler(mockService *service.MockDeploymentClient) *OperatorMockHandler {
@@ -19,34 +31,109 @@ func NewOperatorMockHandler(mockService *service.MockDeploymentClient) *Operator
 	}
 }
 
+// WithNotifier 设置状态回调推送器，Apply 成功后向 Master 推送状态变化
+func (h *OperatorMockHandler) WithNotifier(notifier *operatorapi.Notifier) *OperatorMockHandler {
+	h.notifier = notifier
+	return h
+}
+
 func (h *OperatorMockHandler) RegisterRoutes(r *gin.Engine) {
-	api := r.Group("/v1")
+	api := r.Group("/" + operatorapi.Version)
+	api.Use(operatorapi.RequireVersion())
 	{
+		api.GET("/health", h.HealthCheck)
+		api.GET("/capabilities", h.Capabilities)
 		api.POST("/apply", h.ApplyDeployment)
 		api.GET("/status", h.GetStatus)
+		api.GET("/status/:app", h.GetApplicationStatus)
+		api.DELETE("/apps/:app", h.Delete)
+		api.POST("/scale", h.Scale)
+		api.POST("/restart", h.Restart)
+		api.GET("/logs/:app", h.GetLogs)
+		api.GET("/events/:app", h.GetEvents)
 	}
 }
 
+// HealthCheck 健康检查
+func (h *OperatorMockHandler) HealthCheck(c *gin.Context) {
+	utils.Success(c, gin.H{
+		"status":  "healthy",
+		"service": "operator-mock",
+	})
+}
+
+// Capabilities 协议版本和能力
+func (h *OperatorMockHandler) Capabilities(c *gin.Context) {
+	utils.Success(c, capabilities)
+}
+
+// ApplyDeployment 按请求中的版本列表部署，不在列表中的版本会被移除
 func (h *OperatorMockHandler) ApplyDeployment(c *gin.Context) {
-	var req struct {
-		App     string                   `json:"app" binding:"required"`
-		Version string                   `json:"version" binding:"required"`
-		Package models.DeploymentPackage `json:"package" binding:"required"`
-	}
+	var req models.ApplyDeploymentRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
 		return
 	}
+	if err := operatorapi.ValidateApplyRequest(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	versions := make([]string, 0, len(req.Versions))
+	for _, v := range req.Versions {
+		if _, err := h.mockService.Apply(c.Request.Context(), req.App, v.Version, v.Package); err != nil {
+			utils.Error(c, http.StatusInternalServerError, "Failed to apply deployment: "+err.Error())
+			return
+		}
+		versions = append(versions, v.Version)
+	}
+	h.mockService.Prune(c.Request.Context(), req.App, versions)
+	h.notifier.ApplyFinished(req.App, "Deployment applied successfully (mock)")
 
-	result, err := h.mockService.Apply(c.Request.Context(), req.App, req.Version, req.Package)
+	utils.Success(c, models.ApplyDeploymentResponse{
+		App:     req.App,
+		Message: "Deployment applied successfully (mock)",
+		Success: true,
+	})
+}
+
+// GetApplicationStatus 获取应用状态，按版本分组
+func (h *OperatorMockHandler) GetApplicationStatus(c *gin.Context) {
+	status, err := h.ApplicationStatus(c.Request.Context(), c.Param("app"))
 	if err != nil {
-		utils.Error(c, http.StatusInternalServerError, "Failed to apply deployment: "+err.Error())
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get application status: "+err.Error())
 		return
 	}
 
-	utils.Success(c, result)
+	utils.Success(c, status)
+}
+
+// ApplicationStatus 汇总应用各版本的状态，应用不存在时返回 operatorapi.ErrAppNotFound
+func (h *OperatorMockHandler) ApplicationStatus(ctx context.Context, appName string) (*models.ApplicationStatusResponse, error) {
+	statuses, err := h.mockService.AppStatus(ctx, appName)
+	if err != nil {
+		return nil, err
+	}
+	if len(statuses) == 0 {
+		return nil, operatorapi.ErrAppNotFound
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Version < statuses[j].Version })
+
+	resp := &models.ApplicationStatusResponse{App: appName}
+	healthSum := 0
+	for _, s := range statuses {
+		resp.Versions = append(resp.Versions, models.VersionStatus{
+			Version: s.Version,
+			Healthy: s.Healthy,
+			Nodes:   []models.NodeStatus{},
+		})
+		healthSum += s.Healthy.Level
+	}
+	resp.Healthy = models.HealthInfo{Level: healthSum / len(statuses)}
+	return resp, nil
 }
 
+// GetStatus 获取实例状态，app 参数为空时返回全部
 func (h *OperatorMockHandler) GetStatus(c *gin.Context) {
 	appName := c.Query("app")
 
@@ -73,3 +160,76 @@ func (h *OperatorMockHandler) GetStatus(c *gin.Context) {
 		Apps: allStatuses,
 	})
 }
+
+// Delete 删除应用的所有版本
+func (h *OperatorMockHandler) Delete(c *gin.Context) {
+	app := c.Param("app")
+
+	if err := h.mockService.Delete(c.Request.Context(), app); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to delete: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: app, Message: "Application deleted"})
+}
+
+// Scale 调整副本数
+func (h *OperatorMockHandler) Scale(c *gin.Context) {
+	var req models.ScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.App == "" {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: app and non-negative replicas are required")
+		return
+	}
+
+	if err := h.mockService.Scale(c.Request.Context(), &req); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to scale: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: req.App, Message: fmt.Sprintf("Scaled to %d replicas", req.Replicas)})
+}
+
+// Restart 重启应用
+func (h *OperatorMockHandler) Restart(c *gin.Context) {
+	var req models.RestartRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.App == "" {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: app is required")
+		return
+	}
+
+	if err := h.mockService.Restart(c.Request.Context(), &req); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to restart: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: req.App, Message: "Restart triggered"})
+}
+
+// GetLogs 获取实例日志
+func (h *OperatorMockHandler) GetLogs(c *gin.Context) {
+	var req models.LogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	req.App = c.Param("app")
+
+	logs, err := h.mockService.GetLogs(c.Request.Context(), &req)
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get logs: "+err.Error())
+		return
+	}
+
+	utils.Success(c, logs)
+}
+
+// GetEvents 获取应用事件
+func (h *OperatorMockHandler) GetEvents(c *gin.Context) {
+	events, err := h.mockService.GetEvents(c.Request.Context(), c.Param("app"))
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get events: "+err.Error())
+		return
+	}
+
+	utils.Success(c, events)
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi/operatorapitest"
+	"github.com/boreas/internal/services/operator-mock/service"
+	"github.com/gin-gonic/gin"
+)
+
+func TestOperatorMockConformance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewOperatorMockHandler(service.NewMockDeploymentClient()).RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	operatorapitest.Run(t, operatorapitest.Target{
+		BaseURL: srv.URL,
+		App:     "demo",
+		Package: models.DeploymentPackage{Type: "docker", Image: "demo:latest", Replicas: 1},
+	})
+}
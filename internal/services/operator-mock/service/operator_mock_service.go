@@ -2,12 +2,14 @@ package service
 
 import (
 	"context"
+	"fmt"
 	"strconv"
 	"sync"
 	"time"
 
 	"github.com/boreas/internal/pkg/logger"
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"go.uber.org/zap"
 )
 
@@ -16,11 +18,13 @@ var MockAgent = NewMockDeploymentClient()
 type MockDeploymentClient struct {
 	sync.RWMutex
 	Instances map[string]models.AgentAppStatus
+	events    *operatorapi.EventLog
 }
 
 func NewMockDeploymentClient() *MockDeploymentClient {
 	return &MockDeploymentClient{
 		Instances: make(map[string]models.AgentAppStatus),
+		events:    operatorapi.NewEventLog(0),
 	}
 }
 
@@ -48,6 +52,8 @@ func (c *MockDeploymentClient) Apply(ctx context.Context, app string, version st
 		Updated: time.Now(),
 	}
 
+	c.events.Record(app, models.EventTypeNormal, "Applied", key, "Version "+version+" applied")
+
 	return models.ApplyResponse{
 		Success: true,
 	}, nil
@@ -86,3 +92,113 @@ func (c *MockDeploymentClient) AllStatus(ctx context.Context) ([]models.AgentApp
 
 	return statuses, nil
 }
+
+// Prune 删除应用中不在 keep 列表里的版本，使 Apply 的版本列表成为期望状态
+func (c *MockDeploymentClient) Prune(ctx context.Context, app string, keep []string) {
+	c.Lock()
+	defer c.Unlock()
+
+	kept := make(map[string]bool, len(keep))
+	for _, v := range keep {
+		kept[v] = true
+	}
+	for key, status := range c.Instances {
+		if status.App == app && !kept[status.Version] {
+			delete(c.Instances, key)
+		}
+	}
+}
+
+// Delete 删除应用的所有实例
+func (c *MockDeploymentClient) Delete(ctx context.Context, app string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	found := false
+	for key, status := range c.Instances {
+		if status.App == app {
+			delete(c.Instances, key)
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, app)
+	}
+	c.events.Record(app, models.EventTypeNormal, "Deleted", app, "Application deleted")
+	return nil
+}
+
+// Scale 修改实例的副本数，version 为空时修改所有版本
+func (c *MockDeploymentClient) Scale(ctx context.Context, req *models.ScaleRequest) error {
+	c.Lock()
+	defer c.Unlock()
+
+	found := false
+	for key, status := range c.Instances {
+		if status.App != req.App || (req.Version != "" && status.Version != req.Version) {
+			continue
+		}
+		status.Replicas = req.Replicas
+		status.Updated = time.Now()
+		c.Instances[key] = status
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, req.App)
+	}
+	c.events.Record(req.App, models.EventTypeNormal, "Scaled", req.App, fmt.Sprintf("Scaled to %d replicas", req.Replicas))
+	return nil
+}
+
+// Restart 重启实例（刷新更新时间）
+func (c *MockDeploymentClient) Restart(ctx context.Context, req *models.RestartRequest) error {
+	c.Lock()
+	defer c.Unlock()
+
+	found := false
+	for key, status := range c.Instances {
+		if status.App != req.App || (req.Version != "" && status.Version != req.Version) {
+			continue
+		}
+		status.Updated = time.Now()
+		c.Instances[key] = status
+		found = true
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, req.App)
+	}
+	c.events.Record(req.App, models.EventTypeNormal, "Restarted", req.App, "Application restarted")
+	return nil
+}
+
+// GetLogs 返回模拟日志，每个实例一行
+func (c *MockDeploymentClient) GetLogs(ctx context.Context, req *models.LogsRequest) (*models.LogsResponse, error) {
+	c.RLock()
+	defer c.RUnlock()
+
+	resp := &models.LogsResponse{App: req.App, Instances: []models.InstanceLogs{}}
+	found := false
+	for key, status := range c.Instances {
+		if status.App != req.App {
+			continue
+		}
+		found = true
+		if (req.Version != "" && status.Version != req.Version) || (req.Node != "" && key != req.Node) {
+			continue
+		}
+		resp.Instances = append(resp.Instances, models.InstanceLogs{
+			Node:    key,
+			Version: status.Version,
+			Lines:   []string{fmt.Sprintf("%s %s %s %s (mock)", status.Updated.Format(time.RFC3339), status.App, status.Version, status.Status)},
+		})
+	}
+	if !found {
+		return nil, fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, req.App)
+	}
+	return resp, nil
+}
+
+// GetEvents 返回记录的事件
+func (c *MockDeploymentClient) GetEvents(ctx context.Context, app string) (*models.EventsResponse, error) {
+	return &models.EventsResponse{App: app, Events: c.events.List(app)}, nil
+}
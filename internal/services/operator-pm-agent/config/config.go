@@ -31,12 +31,22 @@ type LogConfig struct {
 
 // AgentConfig Agent配置
 type AgentConfig struct {
-	ID       string            `mapstructure:"id"`
-	Hostname string            `mapstructure:"hostname"`
-	WorkDir  string            `mapstructure:"work_dir"`
-	Docker   DockerConfig      `mapstructure:"docker"`
-	Health   HealthConfig      `mapstructure:"health"`
-	Config   map[string]string `mapstructure:"config"`
+	ID           string             `mapstructure:"id"`
+	Hostname     string             `mapstructure:"hostname"`
+	IP           string             `mapstructure:"ip"` // 注册时上报的 IP，为空时自动检测访问 Operator 使用的本机 IP
+	Labels       map[string]string  `mapstructure:"labels"`
+	WorkDir      string             `mapstructure:"work_dir"`
+	Docker       DockerConfig       `mapstructure:"docker"`
+	Health       HealthConfig       `mapstructure:"health"`
+	Registration RegistrationConfig `mapstructure:"registration"`
+	Config       map[string]string  `mapstructure:"config"`
+}
+
+// RegistrationConfig 向 Operator PM 注册的配置
+type RegistrationConfig struct {
+	OperatorURL       string `mapstructure:"operator_url"`       // Operator PM 地址，为空时不注册
+	Token             string `mapstructure:"token"`              // 与 Operator 的 pm.registration.token 一致
+	HeartbeatInterval int    `mapstructure:"heartbeat_interval"` // 秒
 }
 
 // DockerConfig Docker配置
@@ -148,6 +158,10 @@ func setDefaults() {
 	viper.SetDefault("agent.health.check_interval", 30)
 	viper.SetDefault("agent.health.timeout", 10)
 	viper.SetDefault("agent.health.retry_count", 3)
+	viper.SetDefault("agent.ip", "")
+	viper.SetDefault("agent.registration.operator_url", "")
+	viper.SetDefault("agent.registration.token", "")
+	viper.SetDefault("agent.registration.heartbeat_interval", 30)
 }
 
 // overrideFromEnv 从环境变量覆盖配置
@@ -210,6 +224,20 @@ func overrideFromEnv(cfg *Config) {
 			cfg.Agent.Health.RetryCount = retry
 		}
 	}
+	if ip := os.Getenv("AGENT_IP"); ip != "" {
+		cfg.Agent.IP = ip
+	}
+	if operatorURL := os.Getenv("AGENT_OPERATOR_URL"); operatorURL != "" {
+		cfg.Agent.Registration.OperatorURL = operatorURL
+	}
+	if token := os.Getenv("AGENT_REGISTRATION_TOKEN"); token != "" {
+		cfg.Agent.Registration.Token = token
+	}
+	if heartbeatInterval := os.Getenv("AGENT_HEARTBEAT_INTERVAL"); heartbeatInterval != "" {
+		if interval, err := strconv.Atoi(heartbeatInterval); err == nil {
+			cfg.Agent.Registration.HeartbeatInterval = interval
+		}
+	}
 }
 
 // GetServerAddr 获取服务器地址
@@ -2,8 +2,10 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
 
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/pkg/utils"
 	"github.com/boreas/internal/services/operator-pm-agent/service"
 	"github.com/gin-gonic/gin"
@@ -29,6 +31,11 @@ func (h *AgentHandler) RegisterRoutes(r *gin.Engine) {
 		v1.GET("/status", h.GetAllStatus)
 		v1.GET("/status/:app", h.GetAppStatus)
 
+		// 运维接口
+		v1.POST("/restart/:app", h.RestartApp)
+		v1.DELETE("/apps/:app", h.DeleteApp)
+		v1.GET("/logs/:app", h.GetAppLogs)
+
 		// 健康检查接口
 		v1.GET("/health", h.HealthCheck)
 	}
@@ -73,13 +80,48 @@ func (h *AgentHandler) GetAppStatus(c *gin.Context) {
 
 	status, err := h.agentService.GetAppStatus(appName)
 	if err != nil {
-		utils.Error(c, http.StatusInternalServerError, "Failed to get app status: "+err.Error())
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get app status: "+err.Error())
 		return
 	}
 
 	utils.Success(c, status)
 }
 
+// RestartApp 重启应用
+func (h *AgentHandler) RestartApp(c *gin.Context) {
+	result, err := h.agentService.RestartApp(c.Param("app"))
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to restart app: "+err.Error())
+		return
+	}
+
+	utils.Success(c, result)
+}
+
+// DeleteApp 停止并删除应用
+func (h *AgentHandler) DeleteApp(c *gin.Context) {
+	appName := c.Param("app")
+	if err := h.agentService.DeleteApp(appName); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to delete app: "+err.Error())
+		return
+	}
+
+	utils.Success(c, gin.H{"app": appName, "message": "App deleted successfully"})
+}
+
+// GetAppLogs 获取应用最近日志，lines 参数指定行数
+func (h *AgentHandler) GetAppLogs(c *gin.Context) {
+	lines, _ := strconv.Atoi(c.Query("lines"))
+
+	logs, err := h.agentService.GetAppLogs(c.Param("app"), lines)
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get app logs: "+err.Error())
+		return
+	}
+
+	utils.Success(c, logs)
+}
+
 // HealthCheck 健康检查
 func (h *AgentHandler) HealthCheck(c *gin.Context) {
 	utils.Success(c, gin.H{
@@ -6,10 +6,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/services/operator-pm-agent/repository"
 )
 
@@ -163,7 +165,7 @@ func (s *AgentService) GetAppStatus(appName string) (*models.AppStatusResponse,
 
 	app, exists := s.apps[appName]
 	if !exists {
-		return nil, fmt.Errorf("app %s not found", appName)
+		return nil, fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, appName)
 	}
 
 	// 更新应用健康状态
@@ -176,6 +178,7 @@ func (s *AgentService) GetAppStatus(appName string) (*models.AppStatusResponse,
 		App:     app.App,
 		Version: app.Version,
 		Healthy: app.Healthy,
+		Status:  app.Status,
 	}, nil
 }
 
@@ -240,3 +243,100 @@ func (s *AgentService) restoreAppStates() error {
 
 	return nil
 }
+
+// RestartApp 按 current 版本的配置重启应用
+func (s *AgentService) RestartApp(appName string) (*models.ApplyResponse, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	app, exists := s.apps[appName]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, appName)
+	}
+
+	currentDir := filepath.Join(s.workDir, appName, "current")
+	configJSON, err := os.ReadFile(filepath.Join(currentDir, "config.json"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read app config: %w", err)
+	}
+	var pkg models.DeploymentPackage
+	if err := json.Unmarshal(configJSON, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to parse app config: %w", err)
+	}
+
+	// current 是指向版本目录的软链接，Runner 需要真实的版本目录
+	versionDir := filepath.Join(s.workDir, appName, app.Version)
+	if err := s.runner.Restart(context.Background(), versionDir, app.Version, &pkg); err != nil {
+		return nil, fmt.Errorf("failed to restart app: %w", err)
+	}
+
+	app.Status = "running"
+	app.Healthy = models.HealthInfo{Level: 100, Msg: "Restarted"}
+	app.Updated = time.Now()
+	if err := s.repository.SaveAppStatus(app); err != nil {
+		return nil, fmt.Errorf("failed to save app status: %w", err)
+	}
+
+	return &models.ApplyResponse{
+		Success: true,
+		Message: "App restarted successfully",
+		App:     appName,
+		Version: app.Version,
+	}, nil
+}
+
+// DeleteApp 停止应用并删除其所有版本目录和状态
+func (s *AgentService) DeleteApp(appName string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, exists := s.apps[appName]; !exists {
+		return fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, appName)
+	}
+
+	appDir := filepath.Join(s.workDir, appName)
+	if err := s.runner.Stop(context.Background(), appDir); err != nil {
+		return fmt.Errorf("failed to stop app: %w", err)
+	}
+	if err := os.RemoveAll(appDir); err != nil {
+		return fmt.Errorf("failed to remove app directory: %w", err)
+	}
+	if err := s.repository.DeleteAppStatus(appName); err != nil {
+		return fmt.Errorf("failed to delete app status: %w", err)
+	}
+
+	delete(s.apps, appName)
+	return nil
+}
+
+// GetAppLogs 返回应用日志文件的最后 lines 行
+func (s *AgentService) GetAppLogs(appName string, lines int) (*models.InstanceLogs, error) {
+	s.mutex.RLock()
+	app, exists := s.apps[appName]
+	s.mutex.RUnlock()
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", operatorapi.ErrAppNotFound, appName)
+	}
+	if lines <= 0 {
+		lines = operatorapi.DefaultLogLines
+	}
+
+	logs := &models.InstanceLogs{Version: app.Version, Lines: []string{}}
+	data, err := os.ReadFile(LogFile(filepath.Join(s.workDir, appName)))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return logs, nil
+		}
+		return nil, fmt.Errorf("failed to read log file: %w", err)
+	}
+
+	all := strings.Split(strings.TrimRight(string(data), "\n"), "\n")
+	if len(all) == 1 && all[0] == "" {
+		return logs, nil
+	}
+	if len(all) > lines {
+		all = all[len(all)-lines:]
+	}
+	logs.Lines = all
+	return logs, nil
+}
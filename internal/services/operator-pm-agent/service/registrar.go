@@ -0,0 +1,110 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"go.uber.org/zap"
+)
+
+// Registrar 启动时向 Operator PM 注册节点，之后按间隔发送心跳
+type Registrar struct {
+	operatorURL string
+	token       string
+	interval    time.Duration
+	reg         models.AgentRegistration
+	client      *http.Client
+}
+
+// NewRegistrar 创建注册器，operatorURL 为 Operator PM 地址（如 http://operator-pm:8080）
+func NewRegistrar(operatorURL, token string, interval time.Duration, reg models.AgentRegistration) *Registrar {
+	if interval <= 0 {
+		interval = 30 * time.Second
+	}
+	return &Registrar{
+		operatorURL: strings.TrimSuffix(operatorURL, "/"),
+		token:       token,
+		interval:    interval,
+		reg:         reg,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Run 注册失败时每个心跳间隔重试，注册成功后发送心跳，ctx 结束时返回
+// 心跳同样携带注册信息，Operator 重启后据此恢复节点，因此心跳失败只记录日志
+func (r *Registrar) Run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	registered := false
+	for {
+		if !registered {
+			if err := r.send(ctx, "/v1/agents/register"); err != nil {
+				logger.GetLogger().Warn("Failed to register with operator, will retry",
+					zap.String("operator", r.operatorURL), zap.Error(err))
+			} else {
+				registered = true
+				logger.GetLogger().Info("Registered with operator",
+					zap.String("operator", r.operatorURL), zap.String("node", r.reg.ID), zap.String("ip", r.reg.IP))
+			}
+		} else if err := r.send(ctx, "/v1/agents/"+url.PathEscape(r.reg.ID)+"/heartbeat"); err != nil {
+			logger.GetLogger().Warn("Failed to send heartbeat", zap.String("operator", r.operatorURL), zap.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Registrar) send(ctx context.Context, path string) error {
+	body, err := json.Marshal(r.reg)
+	if err != nil {
+		return fmt.Errorf("failed to marshal registration: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.operatorURL+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if r.token != "" {
+		req.Header.Set("Authorization", "Bearer "+r.token)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return operatorapi.DecodeResponse(resp, nil)
+}
+
+// DetectIP 返回访问 Operator 时使用的本机 IP（UDP 连接不发送数据，只选择路由）
+func DetectIP(operatorURL string) (string, error) {
+	u, err := url.Parse(operatorURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid operator url %s: %w", operatorURL, err)
+	}
+	host := u.Host
+	if u.Port() == "" {
+		host = net.JoinHostPort(u.Hostname(), "80")
+	}
+	conn, err := net.Dial("udp", host)
+	if err != nil {
+		return "", fmt.Errorf("failed to detect local ip: %w", err)
+	}
+	defer conn.Close()
+	return conn.LocalAddr().(*net.UDPAddr).IP.String(), nil
+}
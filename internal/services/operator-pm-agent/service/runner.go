@@ -29,6 +29,15 @@ type Runner interface {
 	// Status 获取应用状态
 	// appDir: 应用目录
 	Status(ctx context.Context, appDir string) (*AppStatus, error)
+
+	// Stop 停止应用（未运行时直接返回）
+	// appDir: 应用目录
+	Stop(ctx context.Context, appDir string) error
+}
+
+// LogFile 应用标准输出/错误的日志文件路径
+func LogFile(appDir string) string {
+	return filepath.Join(appDir, filepath.Base(appDir)+".log")
 }
 
 // SimpleRunner 简单的启动脚本运行器（独立运行，不依赖 agent）
@@ -45,21 +54,10 @@ func (r *SimpleRunner) Restart(ctx context.Context, currentDir, version string,
 	pidFile := filepath.Join(appDir, appName+".pid")
 
 	// 1. 停止旧进程（如果存在）
-	if pidData, err := os.ReadFile(pidFile); err == nil {
-		pid := strings.TrimSpace(string(pidData))
-		if pid != "" {
-			// 发送 SIGTERM
-			exec.Command("kill", "-TERM", pid).Run()
-			// 等待 2 秒
-			exec.Command("sleep", "2").Run()
-			// 如果还在运行，发送 SIGKILL
-			if exec.Command("kill", "-0", pid).Run() == nil {
-				exec.Command("kill", "-9", pid).Run()
-			}
-			// 删除 PID 文件
-			os.Remove(pidFile)
-		}
+	if err := r.Stop(ctx, appDir); err != nil {
+		return err
 	}
+	logFile := LogFile(appDir)
 
 	// 2. 生成启动脚本
 	var scriptContent string
@@ -74,9 +72,9 @@ func (r *SimpleRunner) Restart(ctx context.Context, currentDir, version string,
 
 		scriptContent = fmt.Sprintf(`#!/bin/bash
 cd %s
-nohup ./%s %s > /dev/null 2>&1 &
+nohup ./%s %s >> %s 2>&1 &
 echo $! > %s
-`, currentDir, binaryPath, args, pidFile)
+`, currentDir, binaryPath, args, logFile, pidFile)
 	} else if pkg.Type == "script" {
 		// Script 类型
 		script := strings.Join(pkg.Command, "\n")
@@ -85,10 +83,10 @@ cd %s
 cat > temp_script.sh << 'SCRIPTEOF'
 %s
 SCRIPTEOF
-nohup bash temp_script.sh > /dev/null 2>&1 &
+nohup bash temp_script.sh >> %s 2>&1 &
 echo $! > %s
 rm -f temp_script.sh
-`, currentDir, script, pidFile)
+`, currentDir, script, logFile, pidFile)
 	} else {
 		return fmt.Errorf("unsupported deployment type: %s", pkg.Type)
 	}
@@ -175,6 +173,35 @@ func (r *SimpleRunner) Status(ctx context.Context, appDir string) (*AppStatus, e
 	}, nil
 }
 
+// Stop 按 PID 文件停止进程：先 SIGTERM，2 秒后仍在运行则 SIGKILL
+func (r *SimpleRunner) Stop(ctx context.Context, appDir string) error {
+	appName := filepath.Base(appDir)
+	pidFile := filepath.Join(appDir, appName+".pid")
+
+	pidData, err := os.ReadFile(pidFile)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to read pid file: %w", err)
+	}
+
+	pid := strings.TrimSpace(string(pidData))
+	if pid != "" {
+		// 发送 SIGTERM
+		exec.Command("kill", "-TERM", pid).Run()
+		// 等待 2 秒
+		exec.Command("sleep", "2").Run()
+		// 如果还在运行，发送 SIGKILL
+		if exec.Command("kill", "-0", pid).Run() == nil {
+			exec.Command("kill", "-9", pid).Run()
+		}
+	}
+	// 删除 PID 文件
+	os.Remove(pidFile)
+	return nil
+}
+
 // SupervisorRunner 使用 supervisor 的托管模式
 type SupervisorRunner struct{}
 
@@ -193,6 +220,11 @@ func (r *SupervisorRunner) Status(ctx context.Context, appDir string) (*AppStatu
 	return nil, fmt.Errorf("supervisor runner not implemented yet")
 }
 
+func (r *SupervisorRunner) Stop(ctx context.Context, appDir string) error {
+	// TODO: 实现 supervisorctl stop
+	return fmt.Errorf("supervisor runner not implemented yet")
+}
+
 // SystemdRunner 使用 systemd 的托管模式
 type SystemdRunner struct{}
 
@@ -210,3 +242,8 @@ func (r *SystemdRunner) Status(ctx context.Context, appDir string) (*AppStatus,
 	// TODO: 实现 systemd 状态查询
 	return nil, fmt.Errorf("systemd runner not implemented yet")
 }
+
+func (r *SystemdRunner) Stop(ctx context.Context, appDir string) error {
+	// TODO: 实现 systemctl stop
+	return fmt.Errorf("systemd runner not implemented yet")
+}
@@ -5,9 +5,10 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"sync/atomic"
 
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/spf13/viper"
-	"gopkg.in/yaml.v3"
 )
 
 // Config Operator-PM 配置
@@ -16,6 +17,8 @@ type Config struct {
 	Database DatabaseConfig `mapstructure:"database"`
 	Log      LogConfig      `mapstructure:"log"`
 	PM       PMConfig       `mapstructure:"pm"`
+
+	Callback operatorapi.CallbackConfig `mapstructure:"callback"` // 向 Master 推送状态回调
 }
 
 // ServerConfig 服务器配置
@@ -43,14 +46,49 @@ type LogConfig struct {
 
 // PMConfig 物理机管理配置
 type PMConfig struct {
-	AgentTimeout int                 `mapstructure:"agent_timeout"` // Agent 通信超时（秒）
-	MaxRetries   int                 `mapstructure:"max_retries"`   // 最大重试次数
-	HealthCheck  HealthCheckConfig   `mapstructure:"health_check"`  // 健康检查配置
-	Deployment   DeploymentConfig    `mapstructure:"deployment"`    // 部署配置
-	ConfigPaths  ConfigPathsConfig   `mapstructure:"config_paths"`  // 配置文件路径配置
-	Agent        AgentConfig         `mapstructure:"agent"`         // Agent服务配置
-	AppToNodes   map[string][]string // 应用->机器节点映射（从独立配置文件加载）
-	NodeToIP     map[string]string   // 机器节点->IP地址映射（从独立配置文件加载）
+	AgentTimeout int                `mapstructure:"agent_timeout"` // Agent 通信超时（秒）
+	MaxRetries   int                `mapstructure:"max_retries"`   // 最大重试次数
+	HealthCheck  HealthCheckConfig  `mapstructure:"health_check"`  // 健康检查配置
+	Deployment   DeploymentConfig   `mapstructure:"deployment"`    // 部署配置
+	ConfigPaths  ConfigPathsConfig  `mapstructure:"config_paths"`  // 配置文件路径配置
+	Agent        AgentConfig        `mapstructure:"agent"`         // Agent服务配置
+	Registration RegistrationConfig `mapstructure:"registration"`  // Agent 注册和心跳配置
+
+	// 启动时从独立配置文件加载的映射；映射文件热加载后这些字段不再更新，运行中通过 Mappings() 读取
+	AppToNodes   map[string][]string          // 应用->机器节点映射
+	NodeToIP     map[string]string            // 机器节点->IP地址映射
+	AppPlacement map[string]PlacementConfig   // 应用->版本放置策略（app-to-nodes.yaml 的 app_placement）
+	NodeLabels   map[string]map[string]string // 节点->标签，如 zone、rack（node-to-ip.yaml 的 node_labels）
+
+	mappings atomic.Pointer[Mappings] // 当前生效的映射
+}
+
+// 版本放置策略：决定各版本运行在应用的哪些节点上，各版本的节点互不重叠
+const (
+	PlacementOrdered     = "ordered"      // 按 app_to_nodes 中的顺序选择节点（默认）
+	PlacementSpread      = "spread"       // 每个版本的节点尽量分散到 Label 的不同取值（如不同机房/机架）
+	PlacementLeastLoaded = "least_loaded" // 优先选择运行其他应用最少的节点
+	PlacementPinned      = "pinned"       // 每个版本只运行在 Pinned 指定的节点上，忽略 Percent
+)
+
+// DefaultSpreadLabel spread 策略默认使用的节点标签
+const DefaultSpreadLabel = "zone"
+
+// PlacementConfig 应用的版本放置策略
+type PlacementConfig struct {
+	Strategy string              `yaml:"strategy"` // 见 Placement*，为空时为 ordered
+	Label    string              `yaml:"label"`    // spread 使用的节点标签，默认 zone
+	Pinned   map[string][]string `yaml:"pinned"`   // 版本 -> 固定的节点；其他策略下这些节点也优先分配给该版本
+}
+
+// Validate 校验策略名称
+func (p PlacementConfig) Validate() error {
+	switch p.Strategy {
+	case "", PlacementOrdered, PlacementSpread, PlacementLeastLoaded, PlacementPinned:
+		return nil
+	default:
+		return fmt.Errorf("unknown placement strategy %q", p.Strategy)
+	}
 }
 
 // HealthCheckConfig 健康检查配置
@@ -71,6 +109,24 @@ type DeploymentConfig struct {
 type ConfigPathsConfig struct {
 	AppToNodes string `mapstructure:"app_to_nodes"` // 应用->节点映射配置文件路径
 	NodeToIP   string `mapstructure:"node_to_ip"`   // 节点->IP地址映射配置文件路径
+	// 通过 API 设置的应用节点和节点维护状态的保存文件，为空时只保存在内存中，重启后丢失
+	Assignments string `mapstructure:"assignments"`
+}
+
+// RegistrationConfig Agent 注册和心跳配置
+type RegistrationConfig struct {
+	Enabled        bool   `mapstructure:"enabled"`         // 是否接受 Agent 注册和心跳，启用时必须配置 Token
+	OfflineAfter   int    `mapstructure:"offline_after"`   // 超过该时间（秒）没有心跳的节点标记为离线
+	Token          string `mapstructure:"token"`           // Agent 注册和心跳时携带的 Bearer Token
+	OverrideStatic bool   `mapstructure:"override_static"` // 是否允许注册覆盖 node-to-ip.yaml 中同名节点的地址和标签
+}
+
+// Validate 启用注册时必须配置 Token
+func (r RegistrationConfig) Validate() error {
+	if r.Enabled && r.Token == "" {
+		return fmt.Errorf("pm.registration.token is required when agent registration is enabled")
+	}
+	return nil
 }
 
 // AgentConfig Agent服务配置
@@ -111,6 +167,9 @@ func loadConfigFromFile(configPath string) (*Config, error) {
 
 	// 从环境变量覆盖配置
 	overrideFromEnv(&cfg)
+	if err := cfg.PM.Registration.Validate(); err != nil {
+		return nil, err
+	}
 
 	// 加载独立配置文件
 	if err := loadMappingConfigs(&cfg); err != nil {
@@ -152,6 +211,9 @@ func loadConfigFromDefault() (*Config, error) {
 
 	// 从环境变量覆盖配置
 	overrideFromEnv(&cfg)
+	if err := cfg.PM.Registration.Validate(); err != nil {
+		return nil, err
+	}
 
 	// 加载独立配置文件
 	if err := loadMappingConfigs(&cfg); err != nil {
@@ -161,76 +223,17 @@ func loadConfigFromDefault() (*Config, error) {
 	return &cfg, nil
 }
 
-// loadMappingConfigs 加载映射配置文件
+// loadMappingConfigs 加载映射配置文件，文件不存在时使用空映射
 func loadMappingConfigs(cfg *Config) error {
-	// 初始化映射
-	cfg.PM.AppToNodes = make(map[string][]string)
-	cfg.PM.NodeToIP = make(map[string]string)
-
-	// 加载 app-to-nodes.yaml
-	if err := loadAppToNodesConfig(cfg); err != nil {
-		return fmt.Errorf("failed to load app-to-nodes config: %w", err)
-	}
-
-	// 加载 node-to-ip.yaml
-	if err := loadNodeToIPConfig(cfg); err != nil {
-		return fmt.Errorf("failed to load node-to-ip config: %w", err)
-	}
-
-	return nil
-}
-
-// loadAppToNodesConfig 加载应用->节点映射配置
-func loadAppToNodesConfig(cfg *Config) error {
-	// 使用配置中指定的路径
-	configPath := cfg.PM.ConfigPaths.AppToNodes
-	if configPath == "" {
-		// 如果配置中没有指定路径，使用默认路径
-		configPath = "./cmd/operator-pm/configs/app-to-nodes.yaml"
-	}
-
-	configData, err := os.ReadFile(configPath)
-	if err != nil {
-		// 配置文件不存在，使用空映射
-		return nil
-	}
-
-	var appToNodesConfig struct {
-		AppToNodes map[string][]string `yaml:"app_to_nodes"`
-	}
-
-	if err := yaml.Unmarshal(configData, &appToNodesConfig); err != nil {
-		return fmt.Errorf("failed to unmarshal app-to-nodes config: %w", err)
-	}
-
-	cfg.PM.AppToNodes = appToNodesConfig.AppToNodes
-	return nil
-}
-
-// loadNodeToIPConfig 加载节点->IP地址映射配置
-func loadNodeToIPConfig(cfg *Config) error {
-	// 使用配置中指定的路径
-	configPath := cfg.PM.ConfigPaths.NodeToIP
-	if configPath == "" {
-		// 如果配置中没有指定路径，使用默认路径
-		configPath = "./cmd/operator-pm/configs/node-to-ip.yaml"
-	}
-
-	configData, err := os.ReadFile(configPath)
+	m, err := readMappings(cfg.PM.ConfigPaths, false)
 	if err != nil {
-		// 配置文件不存在，使用空映射
-		return nil
-	}
-
-	var nodeToIPConfig struct {
-		NodeToIP map[string]string `yaml:"node_to_ip"`
-	}
-
-	if err := yaml.Unmarshal(configData, &nodeToIPConfig); err != nil {
-		return fmt.Errorf("failed to unmarshal node-to-ip config: %w", err)
+		return err
 	}
-
-	cfg.PM.NodeToIP = nodeToIPConfig.NodeToIP
+	cfg.PM.AppToNodes = m.AppToNodes
+	cfg.PM.AppPlacement = m.AppPlacement
+	cfg.PM.NodeToIP = m.NodeToIP
+	cfg.PM.NodeLabels = m.NodeLabels
+	cfg.PM.mappings.Store(m)
 	return nil
 }
 
@@ -270,6 +273,18 @@ func setDefaults() {
 	// Agent服务配置
 	viper.SetDefault("pm.agent.port", 8081)
 	viper.SetDefault("pm.agent.path", "/v1")
+
+	// Agent 注册配置
+	viper.SetDefault("pm.registration.enabled", false)
+	viper.SetDefault("pm.registration.offline_after", 90)
+	viper.SetDefault("pm.registration.token", "")
+	viper.SetDefault("pm.registration.override_static", false)
+
+	// 状态回调配置
+	viper.SetDefault("callback.url", "")
+	viper.SetDefault("callback.secret", "")
+	viper.SetDefault("callback.interval", 5)
+	viper.SetDefault("callback.timeout", 600)
 }
 
 // overrideFromEnv 从环境变量覆盖配置
@@ -317,6 +332,14 @@ func overrideFromEnv(cfg *Config) {
 		cfg.Log.Output = output
 	}
 
+	// 状态回调配置
+	if url := os.Getenv("PM_CALLBACK_URL"); url != "" {
+		cfg.Callback.URL = url
+	}
+	if secret := os.Getenv("PM_CALLBACK_SECRET"); secret != "" {
+		cfg.Callback.Secret = secret
+	}
+
 	// PM 配置
 	if timeout := os.Getenv("PM_AGENT_TIMEOUT"); timeout != "" {
 		if t, err := strconv.Atoi(timeout); err == nil {
@@ -348,6 +371,27 @@ func overrideFromEnv(cfg *Config) {
 			cfg.PM.Deployment.MaxConcurrent = c
 		}
 	}
+	if interval := os.Getenv("PM_DEPLOYMENT_RETRY_INTERVAL"); interval != "" {
+		if i, err := strconv.Atoi(interval); err == nil {
+			cfg.PM.Deployment.RetryInterval = i
+		}
+	}
+	if offlineAfter := os.Getenv("PM_REGISTRATION_OFFLINE_AFTER"); offlineAfter != "" {
+		if o, err := strconv.Atoi(offlineAfter); err == nil {
+			cfg.PM.Registration.OfflineAfter = o
+		}
+	}
+	if enabled := os.Getenv("PM_REGISTRATION_ENABLED"); enabled != "" {
+		if b, err := strconv.ParseBool(enabled); err == nil {
+			cfg.PM.Registration.Enabled = b
+		}
+	}
+	if token := os.Getenv("PM_REGISTRATION_TOKEN"); token != "" {
+		cfg.PM.Registration.Token = token
+	}
+	if path := os.Getenv("PM_ASSIGNMENTS_PATH"); path != "" {
+		cfg.PM.ConfigPaths.Assignments = path
+	}
 }
 
 // GetDSN 获取数据库连接字符串
@@ -369,7 +413,7 @@ func (c *Config) GetServerAddr() string {
 
 // GetAgentURL 根据节点名获取Agent服务URL
 func (c *Config) GetAgentURL(nodeName string) (string, bool) {
-	ip, exists := c.PM.NodeToIP[nodeName]
+	ip, exists := c.PM.Mappings().NodeToIP[nodeName]
 	if !exists {
 		return "", false
 	}
@@ -0,0 +1,213 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// 映射配置文件的默认路径
+const (
+	DefaultAppToNodesPath = "./cmd/operator-pm/configs/app-to-nodes.yaml"
+	DefaultNodeToIPPath   = "./cmd/operator-pm/configs/node-to-ip.yaml"
+)
+
+// Mappings app-to-nodes.yaml 和 node-to-ip.yaml 的内容，热加载时整体替换；替换后不再修改，可在一次操作中作为快照使用
+type Mappings struct {
+	AppToNodes   map[string][]string
+	AppPlacement map[string]PlacementConfig
+	NodeToIP     map[string]string
+	NodeLabels   map[string]map[string]string
+}
+
+// Mappings 当前生效的映射
+func (p *PMConfig) Mappings() *Mappings {
+	if m := p.mappings.Load(); m != nil {
+		return m
+	}
+	// 没有通过 Load 加载（如测试中直接设置字段）时使用字段中的映射
+	p.mappings.CompareAndSwap(nil, &Mappings{
+		AppToNodes:   p.AppToNodes,
+		AppPlacement: p.AppPlacement,
+		NodeToIP:     p.NodeToIP,
+		NodeLabels:   p.NodeLabels,
+	})
+	return p.mappings.Load()
+}
+
+// SetMappings 替换生效的映射，正在进行的操作继续使用替换前的快照
+func (p *PMConfig) SetMappings(m *Mappings) {
+	p.mappings.Store(m)
+}
+
+// AppToNodesPath app-to-nodes.yaml 的路径
+func (c ConfigPathsConfig) AppToNodesPath() string {
+	if c.AppToNodes == "" {
+		return DefaultAppToNodesPath
+	}
+	return c.AppToNodes
+}
+
+// NodeToIPPath node-to-ip.yaml 的路径
+func (c ConfigPathsConfig) NodeToIPPath() string {
+	if c.NodeToIP == "" {
+		return DefaultNodeToIPPath
+	}
+	return c.NodeToIP
+}
+
+// LoadMappings 重新读取映射配置文件，文件不存在或无法解析时返回错误（避免编辑过程中的中间状态清空映射）
+func LoadMappings(paths ConfigPathsConfig) (*Mappings, error) {
+	return readMappings(paths, true)
+}
+
+// readMappings 读取映射配置文件，required 为 false 时文件不存在视为空映射
+func readMappings(paths ConfigPathsConfig, required bool) (*Mappings, error) {
+	m := &Mappings{
+		AppToNodes: make(map[string][]string),
+		NodeToIP:   make(map[string]string),
+	}
+
+	var appToNodesConfig struct {
+		AppToNodes   map[string][]string        `yaml:"app_to_nodes"`
+		AppPlacement map[string]PlacementConfig `yaml:"app_placement"`
+	}
+	if ok, err := readYAML(paths.AppToNodesPath(), required, &appToNodesConfig); err != nil {
+		return nil, fmt.Errorf("failed to load app-to-nodes config: %w", err)
+	} else if ok {
+		for app, placement := range appToNodesConfig.AppPlacement {
+			if err := placement.Validate(); err != nil {
+				return nil, fmt.Errorf("failed to load app-to-nodes config: app %s: %w", app, err)
+			}
+		}
+		m.AppToNodes = appToNodesConfig.AppToNodes
+		m.AppPlacement = appToNodesConfig.AppPlacement
+	}
+
+	var nodeToIPConfig struct {
+		NodeToIP   map[string]string            `yaml:"node_to_ip"`
+		NodeLabels map[string]map[string]string `yaml:"node_labels"`
+	}
+	if ok, err := readYAML(paths.NodeToIPPath(), required, &nodeToIPConfig); err != nil {
+		return nil, fmt.Errorf("failed to load node-to-ip config: %w", err)
+	} else if ok {
+		m.NodeToIP = nodeToIPConfig.NodeToIP
+		m.NodeLabels = nodeToIPConfig.NodeLabels
+	}
+	return m, nil
+}
+
+// readYAML 解析 YAML 文件，文件不存在且非必需时返回 false
+func readYAML(path string, required bool, out interface{}) (bool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if !required && os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, err
+	}
+	if err := yaml.Unmarshal(data, out); err != nil {
+		return false, fmt.Errorf("failed to unmarshal %s: %w", path, err)
+	}
+	return true, nil
+}
+
+// Validate 校验映射：节点必须有 IP（known 返回 true 的节点除外，如已自注册的节点），IP 不能重复
+func (m *Mappings) Validate(known func(node string) bool) error {
+	var problems []string
+	exists := func(node string) bool {
+		if _, ok := m.NodeToIP[node]; ok {
+			return true
+		}
+		return known != nil && known(node)
+	}
+
+	byIP := make(map[string]string, len(m.NodeToIP))
+	for _, node := range sortedKeys(m.NodeToIP) {
+		ip := m.NodeToIP[node]
+		if ip == "" {
+			problems = append(problems, fmt.Sprintf("node %s has no ip", node))
+			continue
+		}
+		if other, ok := byIP[ip]; ok {
+			problems = append(problems, fmt.Sprintf("nodes %s and %s have the same ip %s", other, node, ip))
+			continue
+		}
+		byIP[ip] = node
+	}
+	for _, app := range sortedKeys(m.AppToNodes) {
+		for _, node := range m.AppToNodes[app] {
+			if !exists(node) {
+				problems = append(problems, fmt.Sprintf("app %s references unknown node %s", app, node))
+			}
+		}
+	}
+	for _, app := range sortedKeys(m.AppPlacement) {
+		pinned := m.AppPlacement[app].Pinned
+		for _, version := range sortedKeys(pinned) {
+			for _, node := range pinned[version] {
+				if !exists(node) {
+					problems = append(problems, fmt.Sprintf("app %s pins version %s to unknown node %s", app, version, node))
+				}
+			}
+		}
+	}
+	for _, node := range sortedKeys(m.NodeLabels) {
+		if !exists(node) {
+			problems = append(problems, fmt.Sprintf("labels set for unknown node %s", node))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid mappings: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// Diff 与 old 相比的变化，每项一行，如 "app nodes my-app: [node-1] -> [node-1 node-2]"
+func (m *Mappings) Diff(old *Mappings) []string {
+	var changes []string
+	changes = append(changes, diffMap("app nodes", old.AppToNodes, m.AppToNodes)...)
+	changes = append(changes, diffMap("app placement", old.AppPlacement, m.AppPlacement)...)
+	changes = append(changes, diffMap("node ip", old.NodeToIP, m.NodeToIP)...)
+	changes = append(changes, diffMap("node labels", old.NodeLabels, m.NodeLabels)...)
+	return changes
+}
+
+func diffMap[V any](kind string, old, cur map[string]V) []string {
+	keys := sortedKeys(old)
+	for k := range cur {
+		if _, ok := old[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	var changes []string
+	for _, k := range keys {
+		ov, inOld := old[k]
+		nv, inCur := cur[k]
+		switch {
+		case !inOld:
+			changes = append(changes, fmt.Sprintf("%s %s added: %v", kind, k, nv))
+		case !inCur:
+			changes = append(changes, fmt.Sprintf("%s %s removed", kind, k))
+		case !reflect.DeepEqual(ov, nv):
+			changes = append(changes, fmt.Sprintf("%s %s: %v -> %v", kind, k, ov, nv))
+		}
+	}
+	return changes
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -1,16 +1,29 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
 
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/pkg/utils"
 	"github.com/boreas/internal/services/operator-pm/service"
 	"github.com/gin-gonic/gin"
 )
 
+// capabilities PM Operator 声明的能力：多个版本可按比例部署到不同节点，状态按节点上实际运行的版本分组，
+// 大量节点的 Apply 可异步执行
+var capabilities = operatorapi.NewCapabilities(models.EnvironmentTypePhysical,
+	operatorapi.FeatureMultiVersion,
+	operatorapi.FeatureVersionStatus,
+	operatorapi.FeatureNodeStatus,
+	operatorapi.FeatureAsyncApply,
+)
+
 type OperatorPMHandler struct {
 	operatorService *service.OperatorPMService
+	notifier        *operatorapi.Notifier
+	token           string // Agent 注册和心跳的 Bearer Token，为空时不接受注册和心跳
 }
 
 func NewOperatorPMHandler(operatorService *service.OperatorPMService) *OperatorPMHandler {
@@ -19,16 +32,49 @@ func NewOperatorPMHandler(operatorService *service.OperatorPMService) *OperatorP
 	}
 }
 
+// WithNotifier 设置状态回调推送器，Apply 成功后向 Master 推送状态变化
+func (h *OperatorPMHandler) WithNotifier(notifier *operatorapi.Notifier) *OperatorPMHandler {
+	h.notifier = notifier
+	return h
+}
+
+// WithRegistrationToken 设置 Agent 注册和心跳时需要携带的 Bearer Token，设置后才接受注册和心跳
+func (h *OperatorPMHandler) WithRegistrationToken(token string) *OperatorPMHandler {
+	h.token = token
+	return h
+}
+
 func (h *OperatorPMHandler) RegisterRoutes(r *gin.Engine) {
-	api := r.Group("/v1")
+	api := r.Group("/" + operatorapi.Version)
+	api.Use(operatorapi.RequireVersion())
 	{
 		// 健康检查
 		api.GET("/health", h.HealthCheck)
 		api.GET("/ready", h.ReadyCheck)
 
 		// 核心API
+		api.GET("/capabilities", h.Capabilities)
 		api.POST("/apply", h.ApplyDeployment)
 		api.GET("/status/:app", h.GetApplicationStatus)
+		api.GET("/operations/:id", h.GetOperation)
+
+		// 运维API
+		api.DELETE("/apps/:app", h.Delete)
+		api.POST("/scale", h.Scale)
+		api.POST("/restart", h.Restart)
+		api.GET("/logs/:app", h.GetLogs)
+		api.GET("/events/:app", h.GetEvents)
+
+		// 节点清单API
+		api.POST("/agents/register", h.requireToken, h.RegisterAgent)
+		api.POST("/agents/:id/heartbeat", h.requireToken, h.Heartbeat)
+		api.GET("/nodes", h.ListNodes)
+		api.POST("/nodes/:node/cordon", h.Cordon)
+		api.POST("/nodes/:node/drain", h.Drain)
+		api.POST("/nodes/:node/uncordon", h.Uncordon)
+		api.GET("/assignments", h.ListAssignments)
+		api.PUT("/assignments/:app", h.SetAssignment)
+		api.DELETE("/assignments/:app", h.DeleteAssignment)
 	}
 }
 
@@ -54,6 +100,11 @@ func (h *OperatorPMHandler) ReadyCheck(c *gin.Context) {
 	})
 }
 
+// Capabilities 协议版本和能力
+func (h *OperatorPMHandler) Capabilities(c *gin.Context) {
+	utils.Success(c, capabilities)
+}
+
 // ApplyDeployment 应用部署 - 核心API
 func (h *OperatorPMHandler) ApplyDeployment(c *gin.Context) {
 	var req models.ApplyDeploymentRequest
@@ -61,17 +112,43 @@ func (h *OperatorPMHandler) ApplyDeployment(c *gin.Context) {
 		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
 		return
 	}
+	if err := operatorapi.ValidateApplyRequest(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
 
-	// 执行部署
-	result, err := h.operatorService.ApplyDeployment(&req)
+	// 开始部署，async=true 时立即返回操作，否则等待下发完成
+	op, done, err := h.operatorService.StartApplyDeployment(&req)
 	if err != nil {
-		utils.Error(c, http.StatusInternalServerError, "Failed to apply deployment: "+err.Error())
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to apply deployment: "+err.Error())
+		return
+	}
+
+	if c.Query(operatorapi.QueryAsync) == "true" {
+		go func() {
+			result := <-done
+			h.notifier.ApplyFinished(req.App, result.Message)
+		}()
+		utils.Accepted(c, op)
 		return
 	}
 
+	result := <-done
+	h.notifier.ApplyFinished(req.App, result.Message)
 	utils.Success(c, result)
 }
 
+// GetOperation 查询异步 Apply 的进度和结果
+func (h *OperatorPMHandler) GetOperation(c *gin.Context) {
+	op, err := h.operatorService.GetOperation(c.Param("id"))
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get operation: "+err.Error())
+		return
+	}
+
+	utils.Success(c, op)
+}
+
 // GetApplicationStatus 获取应用状态 - 核心API
 func (h *OperatorPMHandler) GetApplicationStatus(c *gin.Context) {
 	appName := c.Param("app")
@@ -83,9 +160,207 @@ func (h *OperatorPMHandler) GetApplicationStatus(c *gin.Context) {
 	// 获取应用状态
 	status, err := h.operatorService.GetApplicationStatus(appName)
 	if err != nil {
-		utils.Error(c, http.StatusInternalServerError, "Failed to get application status: "+err.Error())
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get application status: "+err.Error())
 		return
 	}
 
 	utils.Success(c, status)
 }
+
+// Delete 删除应用的所有版本
+func (h *OperatorPMHandler) Delete(c *gin.Context) {
+	app := c.Param("app")
+
+	if err := h.operatorService.Delete(app); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to delete: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: app, Message: "Application deleted"})
+}
+
+// Scale 调整副本数
+func (h *OperatorPMHandler) Scale(c *gin.Context) {
+	var req models.ScaleRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.App == "" {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: app and non-negative replicas are required")
+		return
+	}
+
+	if err := h.operatorService.Scale(&req); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to scale: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: req.App, Message: fmt.Sprintf("Scaled to %d replicas", req.Replicas)})
+}
+
+// Restart 重启应用
+func (h *OperatorPMHandler) Restart(c *gin.Context) {
+	var req models.RestartRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.App == "" {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: app is required")
+		return
+	}
+
+	if err := h.operatorService.Restart(&req); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to restart: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: req.App, Message: "Restart triggered"})
+}
+
+// GetLogs 获取实例日志
+func (h *OperatorPMHandler) GetLogs(c *gin.Context) {
+	var req models.LogsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	req.App = c.Param("app")
+
+	logs, err := h.operatorService.GetLogs(&req)
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get logs: "+err.Error())
+		return
+	}
+
+	utils.Success(c, logs)
+}
+
+// GetEvents 获取应用事件
+func (h *OperatorPMHandler) GetEvents(c *gin.Context) {
+	events, err := h.operatorService.GetEvents(c.Param("app"))
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to get events: "+err.Error())
+		return
+	}
+
+	utils.Success(c, events)
+}
+
+// requireToken 校验 Agent 请求的 Authorization 头，没有配置注册 Token 时拒绝所有注册和心跳
+func (h *OperatorPMHandler) requireToken(c *gin.Context) {
+	if h.token == "" {
+		utils.Error(c, http.StatusForbidden, "Agent registration is disabled")
+		c.Abort()
+		return
+	}
+	if c.GetHeader("Authorization") != "Bearer "+h.token {
+		utils.Error(c, http.StatusUnauthorized, "Invalid registration token")
+		c.Abort()
+		return
+	}
+	c.Next()
+}
+
+// RegisterAgent Agent 启动时注册节点
+func (h *OperatorPMHandler) RegisterAgent(c *gin.Context) {
+	var req models.AgentRegistration
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+
+	node, err := h.operatorService.RegisterAgent(&req)
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to register agent: "+err.Error())
+		return
+	}
+
+	utils.Success(c, node)
+}
+
+// Heartbeat Agent 定期心跳，携带与注册相同的信息
+func (h *OperatorPMHandler) Heartbeat(c *gin.Context) {
+	req := models.AgentRegistration{ID: c.Param("id")}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	if req.ID != c.Param("id") {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: node id does not match the path")
+		return
+	}
+
+	node, err := h.operatorService.Heartbeat(&req)
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to record heartbeat: "+err.Error())
+		return
+	}
+
+	utils.Success(c, node)
+}
+
+// ListNodes 节点清单及在线状态
+func (h *OperatorPMHandler) ListNodes(c *gin.Context) {
+	utils.Success(c, h.operatorService.ListNodes())
+}
+
+// Cordon 节点进入维护状态，不再接收新的部署
+func (h *OperatorPMHandler) Cordon(c *gin.Context) {
+	resp, err := h.operatorService.Cordon(c.Param("node"))
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to cordon node: "+err.Error())
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// Drain 节点进入维护状态，并把节点上的应用迁移到其他节点
+func (h *OperatorPMHandler) Drain(c *gin.Context) {
+	resp, err := h.operatorService.Drain(c.Param("node"))
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to drain node: "+err.Error())
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// Uncordon 结束节点的维护状态
+func (h *OperatorPMHandler) Uncordon(c *gin.Context) {
+	resp, err := h.operatorService.Uncordon(c.Param("node"))
+	if err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to uncordon node: "+err.Error())
+		return
+	}
+
+	utils.Success(c, resp)
+}
+
+// ListAssignments 通过 API 设置的应用节点
+func (h *OperatorPMHandler) ListAssignments(c *gin.Context) {
+	utils.Success(c, h.operatorService.ListAssignments())
+}
+
+// SetAssignment 设置应用部署到的节点或标签
+func (h *OperatorPMHandler) SetAssignment(c *gin.Context) {
+	var req models.AppAssignment
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.Error(c, http.StatusBadRequest, "Invalid request: "+err.Error())
+		return
+	}
+	req.App = c.Param("app")
+
+	if err := h.operatorService.SetAssignment(&req); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to set assignment: "+err.Error())
+		return
+	}
+
+	utils.Success(c, req)
+}
+
+// DeleteAssignment 删除应用的节点设置，恢复使用 app-to-nodes.yaml
+func (h *OperatorPMHandler) DeleteAssignment(c *gin.Context) {
+	app := c.Param("app")
+
+	if err := h.operatorService.DeleteAssignment(app); err != nil {
+		utils.Error(c, operatorapi.StatusCode(err), "Failed to delete assignment: "+err.Error())
+		return
+	}
+
+	utils.Success(c, operatorapi.ActionResponse{App: app, Message: "Assignment deleted"})
+}
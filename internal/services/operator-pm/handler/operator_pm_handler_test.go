@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boreas/internal/pkg/client/operator"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi/operatorapitest"
+	"github.com/boreas/internal/services/operator-pm/config"
+	"github.com/boreas/internal/services/operator-pm/service"
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAgents 模拟各节点的 Agent，按请求的主机（节点 IP）分别记录每个节点上运行的应用版本
+type fakeAgents struct {
+	mu   sync.Mutex
+	apps map[string]map[string]string // 节点 IP -> 应用 -> 版本
+}
+
+func (f *fakeAgents) RoundTrip(r *http.Request) (*http.Response, error) {
+	w := httptest.NewRecorder()
+	f.serve(w, r)
+	return w.Result(), nil
+}
+
+func (f *fakeAgents) serve(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	host := r.URL.Hostname()
+	if f.apps[host] == nil {
+		f.apps[host] = make(map[string]string)
+	}
+	apps := f.apps[host]
+
+	path := strings.TrimPrefix(r.URL.Path, "/v1")
+	app := path[strings.LastIndex(path, "/")+1:]
+	var data interface{}
+	switch {
+	case r.Method == http.MethodPost && path == "/apply":
+		var req models.ApplyRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		apps[req.App] = req.Version
+		data = models.ApplyResponse{Success: true, App: req.App, Version: req.Version}
+	case path == "/health":
+		data = gin.H{"status": "healthy"}
+	case path == "/status":
+		status := models.StatusResponse{Apps: []models.AgentAppStatus{}}
+		for app, version := range apps {
+			status.Apps = append(status.Apps, models.AgentAppStatus{App: app, Version: version})
+		}
+		data = status
+	case apps[app] == "":
+		w.WriteHeader(http.StatusNotFound)
+		_ = json.NewEncoder(w).Encode(gin.H{"code": http.StatusNotFound, "message": "app not found"})
+		return
+	case strings.HasPrefix(path, "/status/"):
+		data = models.AppStatusResponse{App: app, Version: apps[app], Healthy: models.HealthInfo{Level: 100}, Status: "running"}
+	case strings.HasPrefix(path, "/apps/"):
+		delete(apps, app)
+	case strings.HasPrefix(path, "/logs/"):
+		data = models.InstanceLogs{Version: apps[app], Lines: []string{}}
+	}
+	_ = json.NewEncoder(w).Encode(gin.H{"code": 0, "message": "success", "data": data})
+}
+
+func newTestService() *service.OperatorPMService {
+	cfg := &config.Config{}
+	cfg.PM.AgentTimeout = 5
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1", "node-2"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1", "node-2": "10.0.0.2"}
+
+	agents := &fakeAgents{apps: make(map[string]map[string]string)}
+	return service.NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents})
+}
+
+func TestOperatorPMConformance(t *testing.T) {
+	svc := newTestService()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewOperatorPMHandler(svc).RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	operatorapitest.Run(t, operatorapitest.Target{
+		BaseURL: srv.URL,
+		App:     "demo",
+		Package: models.DeploymentPackage{Type: "docker", Image: "demo:latest", Replicas: 1},
+	})
+}
+
+func TestOperatorPM_AsyncApply(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewOperatorPMHandler(newTestService()).RegisterRoutes(r)
+	srv := httptest.NewServer(r)
+	defer srv.Close()
+
+	client := operator.NewPMClient(srv.URL).WithPollInterval(10 * time.Millisecond)
+	resp, err := client.Apply(context.Background(), &models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 0.5, Package: models.DeploymentPackage{Type: "docker", Image: "demo:v1"}},
+		{Version: "v2", Percent: 0.5, Package: models.DeploymentPackage{Type: "docker", Image: "demo:v2"}},
+	}})
+	if err != nil || !resp.Success || resp.OperationID == "" || len(resp.Nodes) != 2 {
+		t.Fatalf("Apply = %+v, %v", resp, err)
+	}
+
+	op, err := client.GetOperation(context.Background(), resp.OperationID)
+	if err != nil || op.State != models.OperationStateSucceeded || op.FinishedAt == nil {
+		t.Fatalf("GetOperation = %+v, %v", op, err)
+	}
+	for _, node := range op.Nodes {
+		if node.Status != models.AssignmentStatusApplied || node.Attempts != 1 {
+			t.Errorf("node %+v, want applied in one attempt", node)
+		}
+	}
+
+	if _, err := client.GetOperation(context.Background(), "missing"); err == nil {
+		t.Error("GetOperation of unknown id succeeded")
+	}
+}
+
+func TestOperatorPM_RegistrationAndAssignments(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewOperatorPMHandler(newTestService()).WithRegistrationToken("secret").RegisterRoutes(r)
+
+	do := func(method, path, token, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	reg := `{"id":"node-3","ip":"10.0.0.3","labels":{"zone":"b"}}`
+	if w := do(http.MethodPost, "/v1/agents/register", "wrong", reg); w.Code != http.StatusUnauthorized {
+		t.Errorf("register with wrong token = %d, want 401", w.Code)
+	}
+	if w := do(http.MethodPost, "/v1/agents/register", "secret", `{"id":"node-1","ip":"10.9.9.9"}`); w.Code != http.StatusConflict {
+		t.Errorf("register over a static node = %d, want 409", w.Code)
+	}
+
+	// 没有配置 Token 时不接受注册
+	disabled := gin.New()
+	NewOperatorPMHandler(newTestService()).RegisterRoutes(disabled)
+	req := httptest.NewRequest(http.MethodPost, "/v1/agents/register", strings.NewReader(reg))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	disabled.ServeHTTP(w, req)
+	if w.Code != http.StatusForbidden {
+		t.Errorf("register without a configured token = %d, want 403", w.Code)
+	}
+	if w := do(http.MethodPost, "/v1/agents/register", "secret", reg); w.Code != http.StatusOK {
+		t.Fatalf("register = %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPost, "/v1/agents/node-4/heartbeat", "secret", reg); w.Code != http.StatusBadRequest {
+		t.Errorf("heartbeat with mismatched id = %d, want 400", w.Code)
+	}
+
+	if w := do(http.MethodPut, "/v1/assignments/web", "", `{"selector":{"zone":"b"}}`); w.Code != http.StatusOK {
+		t.Fatalf("set assignment = %d: %s", w.Code, w.Body)
+	}
+	if w := do(http.MethodPost, "/v1/apply", "", `{"app":"web","versions":[{"version":"v1","percent":1,"package":{"type":"docker","image":"web:v1"}}]}`); w.Code != http.StatusOK ||
+		!strings.Contains(w.Body.String(), `"node":"node-3"`) {
+		t.Errorf("apply to assigned nodes = %d: %s", w.Code, w.Body)
+	}
+
+	var resp struct {
+		Data []models.AgentNode `json:"data"`
+	}
+	w = do(http.MethodGet, "/v1/nodes", "", "")
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Data) != 3 || resp.Data[2].Status != models.AgentNodeOnline {
+		t.Errorf("nodes = %s", w.Body)
+	}
+	if w := do(http.MethodDelete, "/v1/assignments/web", "", ""); w.Code != http.StatusOK {
+		t.Errorf("delete assignment = %d", w.Code)
+	}
+	if w := do(http.MethodDelete, "/v1/assignments/web", "", ""); w.Code != http.StatusNotFound {
+		t.Errorf("delete missing assignment = %d, want 404", w.Code)
+	}
+}
+
+func TestOperatorPM_NodeMaintenance(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	NewOperatorPMHandler(newTestService()).RegisterRoutes(r)
+
+	post := func(path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+		return w
+	}
+	if w := post("/v1/nodes/node-2/cordon"); w.Code != http.StatusOK {
+		t.Fatalf("cordon = %d: %s", w.Code, w.Body)
+	}
+	if w := post("/v1/nodes/node-9/cordon"); w.Code != http.StatusNotFound {
+		t.Errorf("cordon unknown node = %d, want 404", w.Code)
+	}
+
+	// node-2 维护中，Apply 只使用 node-1
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/v1/apply",
+		strings.NewReader(`{"app":"demo","versions":[{"version":"v1","percent":1,"package":{"type":"docker","image":"demo:v1"}}]}`)))
+	if w.Code != http.StatusOK || strings.Contains(w.Body.String(), `"node":"node-2"`) {
+		t.Errorf("apply with cordoned node = %d: %s", w.Code, w.Body)
+	}
+
+	if w := post("/v1/nodes/node-2/drain"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cordoned":true`) {
+		t.Errorf("drain = %d: %s", w.Code, w.Body)
+	}
+	if w := post("/v1/nodes/node-2/uncordon"); w.Code != http.StatusOK || !strings.Contains(w.Body.String(), `"cordoned":false`) {
+		t.Errorf("uncordon = %d: %s", w.Code, w.Body)
+	}
+}
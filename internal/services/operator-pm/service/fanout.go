@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+)
+
+// fanOut 并发向各节点下发，并发数不超过 Deployment.MaxConcurrent；每个节点失败后间隔 Deployment.RetryInterval
+// 最多重试 MaxRetries 次（Agent 返回 4xx 时不重试）。整体超过 Deployment.Timeout 后，尚未开始的节点标记为 skipped，
+// 正在重试的节点以最近一次错误标记为 failed。只下发 Status 为空或 pending 的分配；
+// 每个节点的状态变化时调用 progress（可为 nil）
+func (s *OperatorPMService) fanOut(assignments []models.NodeAssignment, send func(ctx context.Context, a *models.NodeAssignment) error,
+	progress func(i int, a models.NodeAssignment)) {
+	if progress == nil {
+		progress = func(int, models.NodeAssignment) {}
+	}
+	ctx := context.Background()
+	if s.cfg.PM.Deployment.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(s.cfg.PM.Deployment.Timeout)*time.Second)
+		defer cancel()
+	}
+
+	limit := s.cfg.PM.Deployment.MaxConcurrent
+	if limit <= 0 {
+		limit = 1
+	}
+	sem := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i := range assignments {
+		a := &assignments[i]
+		if a.Status != "" && a.Status != models.AssignmentStatusPending {
+			continue
+		}
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			a.Status = models.AssignmentStatusSkipped
+			a.Error = "deployment timeout reached before the node was started"
+			progress(i, *a)
+			continue
+		}
+
+		wg.Add(1)
+		go func(i int) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			a.Status = models.AssignmentStatusApplying
+			progress(i, *a)
+			s.sendWithRetry(ctx, a, send, func() { progress(i, *a) })
+			progress(i, *a)
+		}(i)
+	}
+	wg.Wait()
+}
+
+// sendWithRetry 向一个节点下发，记录尝试次数和结果；每次失败后等待重试前调用 report 上报最近一次错误
+func (s *OperatorPMService) sendWithRetry(ctx context.Context, a *models.NodeAssignment,
+	send func(ctx context.Context, a *models.NodeAssignment) error, report func()) {
+	interval := time.Duration(s.cfg.PM.Deployment.RetryInterval) * time.Second
+	for {
+		a.Attempts++
+		err := send(ctx, a)
+		if err == nil {
+			a.Status = models.AssignmentStatusApplied
+			a.Error = ""
+			return
+		}
+		a.Error = err.Error()
+		if a.Attempts > s.cfg.PM.MaxRetries || !retryable(ctx, err) {
+			a.Status = models.AssignmentStatusFailed
+			return
+		}
+		report()
+
+		select {
+		case <-ctx.Done():
+			a.Status = models.AssignmentStatusFailed
+			a.Error = fmt.Sprintf("%s (deployment timeout reached after %d attempts)", a.Error, a.Attempts)
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+// retryable 部署超时（ctx 结束）后和 Agent 拒绝请求（4xx）时不重试；网络错误、单次请求超过 AgentTimeout 和 5xx 重试
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *operatorapi.Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode < http.StatusBadRequest || apiErr.StatusCode >= http.StatusInternalServerError
+	}
+	return true
+}
@@ -0,0 +1,281 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"go.uber.org/zap"
+)
+
+// inventory 自注册节点的清单、通过 API 设置的应用节点和节点维护（cordon）状态
+// 节点最近一次注册或心跳超过 offlineAfter 后标记为离线；设置了保存文件时应用节点和维护状态每次修改后写入文件
+type inventory struct {
+	offlineAfter time.Duration
+	path         string
+	now          func() time.Time
+
+	mu          sync.Mutex
+	nodes       map[string]*models.AgentNode
+	assignments map[string]*models.AppAssignment
+	cordoned    map[string]bool // 处于维护状态的节点，包括只在 node-to-ip.yaml 中配置的节点
+}
+
+// assignmentsFile 保存文件的内容
+type assignmentsFile struct {
+	Assignments []models.AppAssignment `json:"assignments"`
+	Cordoned    []string               `json:"cordoned,omitempty"`
+}
+
+func newInventory(offlineAfter time.Duration, path string) *inventory {
+	if offlineAfter <= 0 {
+		offlineAfter = 90 * time.Second
+	}
+	return &inventory{
+		offlineAfter: offlineAfter,
+		path:         path,
+		now:          time.Now,
+		nodes:        make(map[string]*models.AgentNode),
+		assignments:  make(map[string]*models.AppAssignment),
+		cordoned:     make(map[string]bool),
+	}
+}
+
+// record 记录注册或心跳；心跳来自未注册的节点（如 Operator 重启后）时视为注册
+func (inv *inventory) record(reg *models.AgentRegistration, registering bool) models.AgentNode {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	now := inv.now()
+	node, ok := inv.nodes[reg.ID]
+	if !ok || registering {
+		if !ok {
+			node = &models.AgentNode{}
+			inv.nodes[reg.ID] = node
+		}
+		node.RegisteredAt = &now
+		logger.GetLogger().Info("Agent registered",
+			zap.String("node", reg.ID), zap.String("ip", reg.IP), zap.Any("labels", reg.Labels))
+	} else if node.Status == models.AgentNodeOffline {
+		logger.GetLogger().Info("Agent back online", zap.String("node", reg.ID))
+	}
+	node.AgentRegistration = *reg
+	node.Status = models.AgentNodeOnline
+	node.LastSeen = &now
+	return inv.copyNode(node)
+}
+
+// refresh 把心跳超时的节点标记为离线
+func (inv *inventory) refresh() {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	now := inv.now()
+	for id, node := range inv.nodes {
+		if node.Status == models.AgentNodeOnline && now.Sub(*node.LastSeen) > inv.offlineAfter {
+			node.Status = models.AgentNodeOffline
+			logger.GetLogger().Warn("Agent missed heartbeats, marking node offline",
+				zap.String("node", id), zap.Time("last_seen", *node.LastSeen))
+		}
+	}
+}
+
+// node 返回注册的节点
+func (inv *inventory) node(id string) (models.AgentNode, bool) {
+	inv.refresh()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	node, ok := inv.nodes[id]
+	if !ok {
+		return models.AgentNode{}, false
+	}
+	return inv.copyNode(node), true
+}
+
+// list 按节点名排列的注册节点
+func (inv *inventory) list() []models.AgentNode {
+	inv.refresh()
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	result := make([]models.AgentNode, 0, len(inv.nodes))
+	for _, node := range inv.nodes {
+		result = append(result, inv.copyNode(node))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+	return result
+}
+
+// assignment 返回应用通过 API 设置的节点
+func (inv *inventory) assignment(app string) (models.AppAssignment, bool) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	a, ok := inv.assignments[app]
+	if !ok {
+		return models.AppAssignment{}, false
+	}
+	return *a, true
+}
+
+// listAssignments 按应用名排列的应用节点设置
+func (inv *inventory) listAssignments() []models.AppAssignment {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.sortedAssignments()
+}
+
+// setAssignment 设置应用的节点并保存
+func (inv *inventory) setAssignment(a models.AppAssignment) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	prev, existed := inv.assignments[a.App]
+	inv.assignments[a.App] = &a
+	if err := inv.save(); err != nil {
+		if existed {
+			inv.assignments[a.App] = prev
+		} else {
+			delete(inv.assignments, a.App)
+		}
+		return err
+	}
+	return nil
+}
+
+// deleteAssignment 删除应用的节点设置，应用恢复使用 app-to-nodes.yaml 中的节点
+func (inv *inventory) deleteAssignment(app string) error {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	prev, ok := inv.assignments[app]
+	if !ok {
+		return fmt.Errorf("%w: no assignment for %s", operatorapi.ErrAppNotFound, app)
+	}
+	delete(inv.assignments, app)
+	if err := inv.save(); err != nil {
+		inv.assignments[app] = prev
+		return err
+	}
+	return nil
+}
+
+// isCordoned 节点是否处于维护状态
+func (inv *inventory) isCordoned(node string) bool {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	return inv.cordoned[node]
+}
+
+// setCordoned 设置节点的维护状态并保存，返回设置前的状态
+func (inv *inventory) setCordoned(node string, cordoned bool) (bool, error) {
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	prev := inv.cordoned[node]
+	if prev == cordoned {
+		return prev, nil
+	}
+	if cordoned {
+		inv.cordoned[node] = true
+	} else {
+		delete(inv.cordoned, node)
+	}
+	if err := inv.save(); err != nil {
+		if prev {
+			inv.cordoned[node] = true
+		} else {
+			delete(inv.cordoned, node)
+		}
+		return prev, err
+	}
+	return prev, nil
+}
+
+// load 从保存文件恢复应用节点设置和节点维护状态，文件不存在时忽略
+func (inv *inventory) load() error {
+	if inv.path == "" {
+		return nil
+	}
+	data, err := os.ReadFile(inv.path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read assignments %s: %w", inv.path, err)
+	}
+	var file assignmentsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to parse assignments %s: %w", inv.path, err)
+	}
+
+	inv.mu.Lock()
+	defer inv.mu.Unlock()
+	for i := range file.Assignments {
+		a := file.Assignments[i]
+		inv.assignments[a.App] = &a
+	}
+	for _, node := range file.Cordoned {
+		inv.cordoned[node] = true
+	}
+	return nil
+}
+
+// save 写入保存文件（先写临时文件再重命名），调用方持有锁
+func (inv *inventory) save() error {
+	if inv.path == "" {
+		return nil
+	}
+	cordoned := make([]string, 0, len(inv.cordoned))
+	for node := range inv.cordoned {
+		cordoned = append(cordoned, node)
+	}
+	sort.Strings(cordoned)
+	data, err := json.MarshalIndent(assignmentsFile{Assignments: inv.sortedAssignments(), Cordoned: cordoned}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal assignments: %w", err)
+	}
+	tmp := inv.path + ".tmp"
+	if err := os.MkdirAll(filepath.Dir(inv.path), 0755); err != nil {
+		return fmt.Errorf("failed to save assignments: %w", err)
+	}
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("failed to save assignments: %w", err)
+	}
+	if err := os.Rename(tmp, inv.path); err != nil {
+		return fmt.Errorf("failed to save assignments: %w", err)
+	}
+	return nil
+}
+
+func (inv *inventory) sortedAssignments() []models.AppAssignment {
+	result := make([]models.AppAssignment, 0, len(inv.assignments))
+	for _, a := range inv.assignments {
+		result = append(result, *a)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].App < result[j].App })
+	return result
+}
+
+// copyNode 返回节点的副本并填入维护状态，调用方持有锁
+func (inv *inventory) copyNode(node *models.AgentNode) models.AgentNode {
+	c := *node
+	c.Cordoned = inv.cordoned[node.ID]
+	if node.Labels != nil {
+		c.Labels = make(map[string]string, len(node.Labels))
+		for k, v := range node.Labels {
+			c.Labels[k] = v
+		}
+	}
+	return c
+}
+
+// matchLabels 节点标签是否包含 selector 中的所有键值
+func matchLabels(labels, selector map[string]string) bool {
+	for k, v := range selector {
+		if labels[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+
+	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"github.com/boreas/internal/services/operator-pm/config"
+	"go.uber.org/zap"
+)
+
+// Cordon 把节点设为维护状态：节点上已运行的实例不受影响，之后的 Apply 不再把版本放置到该节点
+func (s *OperatorPMService) Cordon(nodeName string) (*models.NodeMaintenanceResponse, error) {
+	if _, ok := s.agentURL(s.cfg.PM.Mappings(), nodeName); !ok {
+		return nil, fmt.Errorf("%w: %s", operatorapi.ErrNodeNotFound, nodeName)
+	}
+	prev, err := s.inventory.setCordoned(nodeName, true)
+	if err != nil {
+		return nil, err
+	}
+	message := "Node already cordoned"
+	if !prev {
+		message = "Node cordoned"
+		logger.GetLogger().Info("Node cordoned", zap.String("node", nodeName))
+	}
+	return &models.NodeMaintenanceResponse{Node: nodeName, Cordoned: true, Message: message}, nil
+}
+
+// Uncordon 结束节点的维护状态，之后的 Apply 重新使用该节点
+func (s *OperatorPMService) Uncordon(nodeName string) (*models.NodeMaintenanceResponse, error) {
+	if _, ok := s.agentURL(s.cfg.PM.Mappings(), nodeName); !ok && !s.inventory.isCordoned(nodeName) {
+		return nil, fmt.Errorf("%w: %s", operatorapi.ErrNodeNotFound, nodeName)
+	}
+	prev, err := s.inventory.setCordoned(nodeName, false)
+	if err != nil {
+		return nil, err
+	}
+	message := "Node was not cordoned"
+	if prev {
+		message = "Node uncordoned"
+		logger.GetLogger().Info("Node uncordoned", zap.String("node", nodeName))
+	}
+	return &models.NodeMaintenanceResponse{Node: nodeName, Cordoned: false, Message: message}, nil
+}
+
+// Drain 把节点设为维护状态，并把节点上运行的每个应用迁移到该应用的其他可用节点：
+// 先在目标节点上部署相同版本，成功后停止并删除节点上的实例；迁移失败的应用保留在节点上，结果见 Apps。
+// 迁移使用最近一次 Apply 的部署包，Operator 重启后尚未重新 Apply 的应用无法迁移
+func (s *OperatorPMService) Drain(nodeName string) (*models.NodeMaintenanceResponse, error) {
+	if _, err := s.Cordon(nodeName); err != nil {
+		return nil, err
+	}
+	m := s.cfg.PM.Mappings()
+	agentURL, _ := s.agentURL(m, nodeName)
+
+	var status models.StatusResponse
+	if err := s.callAgent(http.MethodGet, agentURL+"/status", nil, &status); err != nil {
+		// 不包装 Agent 的错误，避免 Agent 返回的 404 被当作节点不存在
+		return nil, fmt.Errorf("node %s is cordoned but its applications could not be listed: %v", nodeName, err)
+	}
+	sort.Slice(status.Apps, func(i, j int) bool { return status.Apps[i].App < status.Apps[j].App })
+
+	resp := &models.NodeMaintenanceResponse{Node: nodeName, Cordoned: true, Apps: []models.DrainedApp{}}
+	stopped := 0
+	for _, app := range status.Apps {
+		result := s.drainApp(m, nodeName, agentURL, app.App, app.Version)
+		if result.Stopped {
+			stopped++
+		}
+		resp.Apps = append(resp.Apps, result)
+	}
+
+	resp.Message = fmt.Sprintf("Node cordoned, drained %d/%d applications", stopped, len(resp.Apps))
+	logger.GetLogger().Info("Node drained", zap.String("node", nodeName), zap.String("result", resp.Message))
+	return resp, nil
+}
+
+// drainApp 把一个应用从节点迁移到其他节点，与该应用的 Apply 互斥
+func (s *OperatorPMService) drainApp(m *config.Mappings, nodeName, agentURL, app, version string) models.DrainedApp {
+	result := models.DrainedApp{App: app, Version: version}
+	fail := func(err error) models.DrainedApp {
+		result.Error = err.Error()
+		s.events.Record(app, models.EventTypeWarning, "FailedDrain", nodeName, result.Error)
+		return result
+	}
+
+	s.mu.Lock()
+	pkg, ok := s.packages[app][version]
+	s.mu.Unlock()
+	if !ok {
+		return fail(fmt.Errorf("deployment package of version %s is unknown, apply the application again before draining", version))
+	}
+	target, err := s.drainTarget(m, app, nodeName)
+	if err != nil {
+		return fail(err)
+	}
+
+	assignment := models.NodeAssignment{Node: target, Version: version, Status: models.AssignmentStatusPending}
+	op, err := s.operations.start("drain", app, []models.NodeAssignment{assignment})
+	if err != nil {
+		return fail(err)
+	}
+	assignments := []models.NodeAssignment{assignment}
+	s.fanOut(assignments, func(ctx context.Context, a *models.NodeAssignment) error {
+		targetURL, _ := s.agentURL(m, a.Node)
+		agentReq := map[string]interface{}{"app": app, "version": version, "package": pkg}
+		return s.callAgentContext(ctx, http.MethodPost, targetURL+"/apply", agentReq, nil)
+	}, func(i int, a models.NodeAssignment) {
+		s.operations.update(op.ID, i, a)
+	})
+	migrated := assignments[0].Status == models.AssignmentStatusApplied
+
+	opResult := &models.ApplyDeploymentResponse{App: app, Success: migrated, Nodes: assignments, OperationID: op.ID}
+	if !migrated {
+		opResult.Message = fmt.Sprintf("Failed to migrate %s from %s to %s: %s", version, nodeName, target, assignments[0].Error)
+		s.operations.finish(op.ID, opResult)
+		return fail(errors.New(opResult.Message))
+	}
+	result.Target = target
+
+	// 目标节点部署成功后停止节点上的实例
+	s.mu.Lock()
+	if placement, ok := s.placements[app]; ok {
+		updated := make(map[string]string, len(placement))
+		for node, v := range placement {
+			updated[node] = v
+		}
+		delete(updated, nodeName)
+		updated[target] = version
+		s.placements[app] = updated
+	}
+	s.mu.Unlock()
+
+	err = s.callAgent(http.MethodDelete, agentURL+"/apps/"+url.PathEscape(app), nil, nil)
+	if err != nil && !errors.Is(err, operatorapi.ErrAppNotFound) {
+		opResult.Success = false
+		opResult.Message = fmt.Sprintf("Migrated %s to %s but failed to stop it on %s: %v", version, target, nodeName, err)
+		s.operations.finish(op.ID, opResult)
+		return fail(errors.New(opResult.Message))
+	}
+	result.Stopped = true
+	opResult.Message = fmt.Sprintf("Migrated %s from %s to %s", version, nodeName, target)
+	s.operations.finish(op.ID, opResult)
+	s.events.Record(app, models.EventTypeNormal, "Drained", nodeName, opResult.Message)
+	return result
+}
+
+// drainTarget 选择迁移的目标节点：应用的其他可用节点中尚未运行该应用、运行其他应用最少的节点
+func (s *OperatorPMService) drainTarget(m *config.Mappings, app, nodeName string) (string, error) {
+	nodes, err := s.appNodes(m, app)
+	if err != nil {
+		return "", err
+	}
+	nodes, _ = s.schedulable(nodes, config.PlacementConfig{})
+	current := s.currentPlacement(m, app, nodes)
+	load := s.nodeLoad(m, app)
+
+	var candidates []string
+	for _, node := range nodes {
+		if node == nodeName || current[node] != "" {
+			continue
+		}
+		if registered, ok := s.inventory.node(node); ok && registered.Status == models.AgentNodeOffline {
+			continue
+		}
+		if _, ok := s.agentURL(m, node); ok {
+			candidates = append(candidates, node)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", fmt.Errorf("%w: no other schedulable node without %s to migrate to", operatorapi.ErrPlacement, app)
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return load[candidates[i]] < load[candidates[j]] })
+	return candidates[0], nil
+}
+
+// schedulable 去掉处于维护状态的节点，同时从放置策略的固定节点中去掉这些节点
+func (s *OperatorPMService) schedulable(nodes []string, policy config.PlacementConfig) ([]string, config.PlacementConfig) {
+	result := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if !s.inventory.isCordoned(node) {
+			result = append(result, node)
+		}
+	}
+	if len(policy.Pinned) > 0 {
+		pinned := make(map[string][]string, len(policy.Pinned))
+		for version, pinnedNodes := range policy.Pinned {
+			for _, node := range pinnedNodes {
+				if !s.inventory.isCordoned(node) {
+					pinned[version] = append(pinned[version], node)
+				}
+			}
+		}
+		policy.Pinned = pinned
+	}
+	return result, policy
+}
@@ -0,0 +1,204 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"github.com/boreas/internal/services/operator-pm/config"
+)
+
+// RegisterAgent 记录 Agent 注册，同名节点的地址和标签以最新注册为准；
+// 节点已在 node-to-ip.yaml 中配置且不允许注册覆盖时返回 operatorapi.ErrNodeConflict
+func (s *OperatorPMService) RegisterAgent(reg *models.AgentRegistration) (models.AgentNode, error) {
+	if err := s.checkRegistration(reg.ID); err != nil {
+		return models.AgentNode{}, err
+	}
+	return s.inventory.record(reg, true), nil
+}
+
+// Heartbeat 记录 Agent 心跳，未注册的节点视为注册，限制与 RegisterAgent 相同
+func (s *OperatorPMService) Heartbeat(reg *models.AgentRegistration) (models.AgentNode, error) {
+	if err := s.checkRegistration(reg.ID); err != nil {
+		return models.AgentNode{}, err
+	}
+	return s.inventory.record(reg, false), nil
+}
+
+// checkRegistration 不允许注册覆盖 node-to-ip.yaml 中的节点，避免持有 Token 的 Agent 把已有节点的部署引到其他地址
+func (s *OperatorPMService) checkRegistration(nodeName string) error {
+	if s.cfg.PM.Registration.OverrideStatic {
+		return nil
+	}
+	if _, configured := s.cfg.PM.Mappings().NodeToIP[nodeName]; configured {
+		return fmt.Errorf("%w: %s is configured in node-to-ip.yaml", operatorapi.ErrNodeConflict, nodeName)
+	}
+	return nil
+}
+
+// registered 节点的注册信息；节点在 node-to-ip.yaml 中配置且不允许注册覆盖时忽略注册信息（如映射热加载后新配置的节点）
+func (s *OperatorPMService) registered(m *config.Mappings, nodeName string) (models.AgentNode, bool) {
+	if _, configured := m.NodeToIP[nodeName]; configured && !s.cfg.PM.Registration.OverrideStatic {
+		return models.AgentNode{}, false
+	}
+	return s.inventory.node(nodeName)
+}
+
+// ListNodes 节点清单：自注册的节点（online/offline）和只在 node-to-ip.yaml 中配置的节点（static），按节点名排列
+func (s *OperatorPMService) ListNodes() []models.AgentNode {
+	return s.listNodes(s.cfg.PM.Mappings())
+}
+
+func (s *OperatorPMService) listNodes(m *config.Mappings) []models.AgentNode {
+	nodes := make([]models.AgentNode, 0)
+	registered := make(map[string]bool)
+	for _, node := range s.inventory.list() {
+		if _, ok := s.registered(m, node.ID); ok {
+			nodes = append(nodes, node)
+			registered[node.ID] = true
+		}
+	}
+	for name, ip := range m.NodeToIP {
+		if registered[name] {
+			continue
+		}
+		nodes = append(nodes, models.AgentNode{
+			AgentRegistration: models.AgentRegistration{ID: name, IP: ip, Port: s.cfg.PM.Agent.Port, Labels: m.NodeLabels[name]},
+			Status:            models.AgentNodeStatic,
+			Cordoned:          s.inventory.isCordoned(name),
+		})
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].ID < nodes[j].ID })
+	return nodes
+}
+
+// StartNodeMonitor 按间隔检查心跳，把超时的节点标记为离线，ctx 结束时停止
+func (s *OperatorPMService) StartNodeMonitor(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				s.inventory.refresh()
+			}
+		}
+	}()
+}
+
+// RestoreAssignments 从保存文件恢复通过 API 设置的应用节点
+func (s *OperatorPMService) RestoreAssignments() error {
+	return s.inventory.load()
+}
+
+// ListAssignments 通过 API 设置的应用节点
+func (s *OperatorPMService) ListAssignments() []models.AppAssignment {
+	return s.inventory.listAssignments()
+}
+
+// SetAssignment 设置应用部署到的节点或标签，代替 app-to-nodes.yaml 中的配置；指定的节点必须已注册或在 node-to-ip.yaml 中
+func (s *OperatorPMService) SetAssignment(a *models.AppAssignment) error {
+	if a.App == "" {
+		return fmt.Errorf("%w: app is required", operatorapi.ErrPlacement)
+	}
+	if len(a.Nodes) == 0 && len(a.Selector) == 0 {
+		return fmt.Errorf("%w: nodes or selector is required", operatorapi.ErrPlacement)
+	}
+	m := s.cfg.PM.Mappings()
+	for _, node := range a.Nodes {
+		if _, ok := s.agentURL(m, node); !ok {
+			return fmt.Errorf("%w: node %s is neither registered nor configured", operatorapi.ErrPlacement, node)
+		}
+	}
+	return s.inventory.setAssignment(*a)
+}
+
+// DeleteAssignment 删除应用的节点设置，应用恢复使用 app-to-nodes.yaml 中的节点
+func (s *OperatorPMService) DeleteAssignment(app string) error {
+	return s.inventory.deleteAssignment(app)
+}
+
+// appNodes 返回应用的节点：通过 API 设置过时为指定的节点加上标签匹配的在线/静态节点，否则为 app-to-nodes.yaml 中的节点；
+// 没有节点时返回 operatorapi.ErrAppNotFound
+func (s *OperatorPMService) appNodes(m *config.Mappings, appName string) ([]string, error) {
+	a, ok := s.inventory.assignment(appName)
+	if !ok {
+		nodes := m.AppToNodes[appName]
+		if len(nodes) == 0 {
+			return nil, fmt.Errorf("%w: no nodes configured for application %s", operatorapi.ErrAppNotFound, appName)
+		}
+		return nodes, nil
+	}
+
+	nodes := append([]string(nil), a.Nodes...)
+	seen := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		seen[node] = true
+	}
+	if len(a.Selector) > 0 {
+		for _, node := range s.listNodes(m) {
+			if node.Status != models.AgentNodeOffline && !seen[node.ID] && matchLabels(node.Labels, a.Selector) {
+				nodes = append(nodes, node.ID)
+				seen[node.ID] = true
+			}
+		}
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("%w: no nodes match the assignment of application %s", operatorapi.ErrAppNotFound, appName)
+	}
+	return nodes, nil
+}
+
+// appNames 配置了节点的应用：app-to-nodes.yaml 中的应用和通过 API 设置的应用
+func (s *OperatorPMService) appNames(m *config.Mappings) []string {
+	names := make([]string, 0, len(m.AppToNodes))
+	seen := make(map[string]bool)
+	for app := range m.AppToNodes {
+		names = append(names, app)
+		seen[app] = true
+	}
+	for _, a := range s.inventory.listAssignments() {
+		if !seen[a.App] {
+			names = append(names, a.App)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// agentURL 节点的 Agent 地址：已注册的节点使用注册的 IP 和端口，否则使用 node-to-ip.yaml；
+// 不允许注册覆盖时 node-to-ip.yaml 中的节点始终使用配置的 IP
+func (s *OperatorPMService) agentURL(m *config.Mappings, nodeName string) (string, bool) {
+	ip, port := "", s.cfg.PM.Agent.Port
+	if node, ok := s.registered(m, nodeName); ok {
+		ip = node.IP
+		if node.Port != 0 {
+			port = node.Port
+		}
+	} else if ip, ok = m.NodeToIP[nodeName]; !ok {
+		return "", false
+	}
+	return fmt.Sprintf("http://%s:%d%s", ip, port, s.cfg.PM.Agent.Path), true
+}
+
+// nodeLabels 节点标签：node-to-ip.yaml 的 node_labels，已注册的节点使用注册时上报的标签（限制同 agentURL）
+func (s *OperatorPMService) nodeLabels(m *config.Mappings) map[string]map[string]string {
+	labels := make(map[string]map[string]string, len(m.NodeLabels))
+	for node, l := range m.NodeLabels {
+		labels[node] = l
+	}
+	for _, node := range s.inventory.list() {
+		if _, ok := s.registered(m, node.ID); ok && node.Labels != nil {
+			labels[node.ID] = node.Labels
+		}
+	}
+	return labels
+}
@@ -0,0 +1,113 @@
+package service
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"github.com/google/uuid"
+)
+
+// maxOperations 保留的操作记录数，超出时删除最早结束的操作
+const maxOperations = 100
+
+// operationStore 记录 Apply 和 drain 迁移操作的进度和结果，同一应用同时只有一个进行中的操作
+type operationStore struct {
+	mu      sync.Mutex
+	ops     map[string]*models.Operation
+	order   []string          // 按创建顺序排列的操作 ID
+	running map[string]string // 应用 -> 进行中的操作 ID
+}
+
+func newOperationStore() *operationStore {
+	return &operationStore{
+		ops:     make(map[string]*models.Operation),
+		running: make(map[string]string),
+	}
+}
+
+// start 创建 opType（apply、drain）类型的操作，应用已有进行中的操作时返回 operatorapi.ErrOperationInProgress
+func (o *operationStore) start(opType, app string, nodes []models.NodeAssignment) (*models.Operation, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if id, ok := o.running[app]; ok {
+		return nil, fmt.Errorf("%w: %s %s of %s", operatorapi.ErrOperationInProgress, o.ops[id].Type, id, app)
+	}
+
+	op := &models.Operation{
+		ID:        uuid.New().String(),
+		Type:      opType,
+		App:       app,
+		State:     models.OperationStateRunning,
+		Nodes:     append([]models.NodeAssignment(nil), nodes...),
+		CreatedAt: time.Now(),
+	}
+	o.ops[op.ID] = op
+	o.order = append(o.order, op.ID)
+	o.running[app] = op.ID
+	o.evict()
+	return copyOperation(op), nil
+}
+
+// update 更新节点进度
+func (o *operationStore) update(id string, i int, node models.NodeAssignment) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if op, ok := o.ops[id]; ok && i < len(op.Nodes) {
+		op.Nodes[i] = node
+	}
+}
+
+// finish 记录结果并结束操作
+func (o *operationStore) finish(id string, result *models.ApplyDeploymentResponse) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	op, ok := o.ops[id]
+	if !ok {
+		return
+	}
+	now := time.Now()
+	op.State = models.OperationStateSucceeded
+	if !result.Success {
+		op.State = models.OperationStateFailed
+	}
+	op.Message = result.Message
+	op.Nodes = append([]models.NodeAssignment(nil), result.Nodes...)
+	op.Result = result
+	op.FinishedAt = &now
+	if o.running[op.App] == id {
+		delete(o.running, op.App)
+	}
+}
+
+// get 返回操作的副本，不存在时返回 operatorapi.ErrOperationNotFound
+func (o *operationStore) get(id string) (*models.Operation, error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	op, ok := o.ops[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", operatorapi.ErrOperationNotFound, id)
+	}
+	return copyOperation(op), nil
+}
+
+// evict 记录数超过 maxOperations 时删除最早结束的操作，进行中的操作保留
+func (o *operationStore) evict() {
+	for i := 0; len(o.ops) > maxOperations && i < len(o.order); {
+		id := o.order[i]
+		if o.ops[id].State == models.OperationStateRunning {
+			i++
+			continue
+		}
+		delete(o.ops, id)
+		o.order = append(o.order[:i], o.order[i+1:]...)
+	}
+}
+
+func copyOperation(op *models.Operation) *models.Operation {
+	c := *op
+	c.Nodes = append([]models.NodeAssignment(nil), op.Nodes...)
+	return &c
+}
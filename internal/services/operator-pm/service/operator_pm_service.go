@@ -2,32 +2,59 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
 	"github.com/boreas/internal/services/operator-pm/config"
 )
 
 type OperatorPMService struct {
-	cfg    *config.Config
-	client *http.Client
+	cfg        *config.Config
+	client     *http.Client
+	events     *operatorapi.EventLog // 物理机没有原生事件源，记录本服务执行的操作
+	operations *operationStore       // Apply 操作的进度和结果
+	inventory  *inventory            // 自注册的节点和通过 API 设置的应用节点
+	reloadMu   sync.Mutex            // 串行化映射文件的重新加载
+
+	mu         sync.Mutex
+	placements map[string]map[string]string                   // 应用 -> 节点 -> 最近一次 Apply 分配的版本
+	packages   map[string]map[string]models.DeploymentPackage // 应用 -> 版本 -> 最近一次 Apply 的部署包，drain 时用于迁移
 }
 
 func NewOperatorPMService(cfg *config.Config) *OperatorPMService {
 	return &OperatorPMService{
-		cfg:    cfg,
-		client: &http.Client{Timeout: time.Duration(cfg.PM.AgentTimeout) * time.Second},
+		cfg:        cfg,
+		client:     &http.Client{Timeout: time.Duration(cfg.PM.AgentTimeout) * time.Second},
+		events:     operatorapi.NewEventLog(0),
+		operations: newOperationStore(),
+		inventory:  newInventory(time.Duration(cfg.PM.Registration.OfflineAfter)*time.Second, cfg.PM.ConfigPaths.Assignments),
+		placements: make(map[string]map[string]string),
+		packages:   make(map[string]map[string]models.DeploymentPackage),
 	}
 }
 
+// WithHTTPClient 设置访问 Agent 的 HTTP 客户端（测试中传入按节点路由到模拟 Agent 的客户端）
+func (s *OperatorPMService) WithHTTPClient(client *http.Client) *OperatorPMService {
+	s.client = client
+	return s
+}
+
 // CheckPMConnection 检查物理机连接状态
 func (s *OperatorPMService) CheckPMConnection() error {
 	// 检查所有配置的节点连接状态
-	for nodeName := range s.cfg.PM.NodeToIP {
-		agentURL, exists := s.cfg.GetAgentURL(nodeName)
+	m := s.cfg.PM.Mappings()
+	for nodeName := range m.NodeToIP {
+		agentURL, exists := s.agentURL(m, nodeName)
 		if !exists {
 			return fmt.Errorf("node %s not found in IP mapping", nodeName)
 		}
@@ -44,171 +71,481 @@ func (s *OperatorPMService) CheckPMConnection() error {
 	return nil
 }
 
-// ApplyDeployment 应用部署 - 核心API
+// ApplyDeployment 应用部署 - 核心API，等待下发完成后返回每个节点的结果
 func (s *OperatorPMService) ApplyDeployment(req *models.ApplyDeploymentRequest) (*models.ApplyDeploymentResponse, error) {
-	// 1. 获取应用对应的节点列表
-	nodes, exists := s.cfg.PM.AppToNodes[req.App]
-	if !exists || len(nodes) == 0 {
-		return nil, fmt.Errorf("no nodes configured for application %s", req.App)
+	_, done, err := s.StartApplyDeployment(req)
+	if err != nil {
+		return nil, err
 	}
+	return <-done, nil
+}
 
-	// 2. 为每个版本选择合适的节点进行部署
-	var successCount int
-	var totalCount int
+// StartApplyDeployment 开始应用部署并立即返回操作，下发在后台进行，结束时从 done 返回结果
+// 按应用的放置策略把节点分配给各版本（见 placeVersions），并发向每个节点下发分配到的版本（见 fanOut），
+// 之前运行该应用、本次没有分配版本的节点停止应用（维护中的节点除外）；
+// 无法放置或应用已有进行中的 Apply 时直接返回错误。整个操作使用开始时的映射快照，下发过程中热加载的映射不影响本次操作
+func (s *OperatorPMService) StartApplyDeployment(req *models.ApplyDeploymentRequest) (*models.Operation, <-chan *models.ApplyDeploymentResponse, error) {
+	// 1. 获取应用对应的节点列表，处于维护状态的节点不参与放置
+	m := s.cfg.PM.Mappings()
+	nodes, err := s.appNodes(m, req.App)
+	if err != nil {
+		return nil, nil, err
+	}
+	nodes, policy := s.schedulable(nodes, m.AppPlacement[req.App])
+	if len(nodes) == 0 {
+		return nil, nil, fmt.Errorf("%w: all nodes of application %s are cordoned", operatorapi.ErrPlacement, req.App)
+	}
 
-	for _, version := range req.Versions {
-		// 计算需要部署的节点数量
-		nodeCount := int(float64(len(nodes)) * version.Percent)
-		if nodeCount == 0 {
-			nodeCount = 1 // 至少部署一个节点
+	// 2. 计算每个节点运行的版本，以及需要停止应用的节点
+	current := s.currentPlacement(m, req.App, nodes)
+	assignments, err := placeVersions(placementRequest{
+		nodes:    nodes,
+		versions: req.Versions,
+		current:  current,
+		policy:   policy,
+		labels:   s.nodeLabels(m),
+		load:     s.nodeLoad(m, req.App),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	assignments = append(assignments, releasedNodes(current, assignments, s.inventory.isCordoned)...)
+	for i := range assignments {
+		a := &assignments[i]
+		a.Status = models.AssignmentStatusPending
+		if _, exists := s.agentURL(m, a.Node); !exists {
+			a.Status = models.AssignmentStatusFailed
+			a.Error = "node not found in IP mapping"
 		}
+	}
 
-		// 选择前nodeCount个节点
-		selectedNodes := nodes[:nodeCount]
-		totalCount += len(selectedNodes)
+	op, err := s.operations.start("apply", req.App, assignments)
+	if err != nil {
+		return nil, nil, err
+	}
 
-		// 向选中的节点发送部署请求
-		for _, nodeName := range selectedNodes {
-			agentURL, exists := s.cfg.GetAgentURL(nodeName)
-			if !exists {
-				continue // 跳过无效节点
-			}
+	// 3. 在后台下发
+	done := make(chan *models.ApplyDeploymentResponse, 1)
+	go func() {
+		result := s.rollout(m, req, current, assignments, func(i int, a models.NodeAssignment) {
+			s.operations.update(op.ID, i, a)
+		})
+		result.OperationID = op.ID
+		s.operations.finish(op.ID, result)
+		done <- result
+	}()
+	return op, done, nil
+}
 
-			// 构建Agent的apply请求
-			agentReq := map[string]interface{}{
-				"app":     req.App,
-				"version": version.Version,
-				"package": version.Package,
-			}
+// GetOperation 查询操作的进度和结果，不存在时返回 operatorapi.ErrOperationNotFound
+func (s *OperatorPMService) GetOperation(id string) (*models.Operation, error) {
+	return s.operations.get(id)
+}
 
-			// 发送请求到Agent
-			if err := s.sendToAgent(agentURL+"/apply", agentReq); err != nil {
-				// 记录错误但继续处理其他节点
-				continue
+// rollout 并发向节点发送部署或停止请求并汇总结果，有节点失败或跳过时 Success 为 false，Master 从 Nodes 中获取每个节点的结果。
+// 放置记录从 current 开始，只按成功的节点更新，失败或跳过的节点仍记录为原来运行的版本
+func (s *OperatorPMService) rollout(m *config.Mappings, req *models.ApplyDeploymentRequest, current map[string]string,
+	assignments []models.NodeAssignment, progress func(i int, a models.NodeAssignment)) *models.ApplyDeploymentResponse {
+	packages := make(map[string]models.DeploymentPackage, len(req.Versions))
+	for _, v := range req.Versions {
+		packages[v.Version] = v.Package
+	}
+
+	s.fanOut(assignments, func(ctx context.Context, a *models.NodeAssignment) error {
+		agentURL, _ := s.agentURL(m, a.Node)
+		if a.Action == models.AssignmentActionStop {
+			err := s.callAgentContext(ctx, http.MethodDelete, agentURL+"/apps/"+url.PathEscape(req.App), nil, nil)
+			if errors.Is(err, operatorapi.ErrAppNotFound) {
+				return nil
 			}
+			return err
+		}
+		agentReq := map[string]interface{}{
+			"app":     req.App,
+			"version": a.Version,
+			"package": packages[a.Version],
+		}
+		return s.callAgentContext(ctx, http.MethodPost, agentURL+"/apply", agentReq, nil)
+	}, progress)
 
-			successCount++
+	placement := make(map[string]string, len(current)+len(assignments))
+	for node, version := range current {
+		if _, exists := s.agentURL(m, node); exists { // 已不在映射中的节点无法再访问，不再记录
+			placement[node] = version
 		}
 	}
-
-	// 3. 返回结果
-	success := successCount > 0
-	message := fmt.Sprintf("Deployed to %d/%d nodes", successCount, totalCount)
-	if !success {
-		message = "Failed to deploy to any nodes"
+	for _, a := range assignments {
+		if a.Status != models.AssignmentStatusApplied {
+			continue
+		}
+		if a.Action == models.AssignmentActionStop {
+			delete(placement, a.Node)
+		} else {
+			placement[a.Node] = a.Version
+		}
+	}
+	s.mu.Lock()
+	s.placements[req.App] = placement
+	s.packages[req.App] = packages
+	s.mu.Unlock()
+
+	var deploys, applied, stops, stopped, failed, skipped int
+	for _, a := range assignments {
+		stop := a.Action == models.AssignmentActionStop
+		if stop {
+			stops++
+		} else {
+			deploys++
+		}
+		switch a.Status {
+		case models.AssignmentStatusApplied:
+			if stop {
+				stopped++
+			} else {
+				applied++
+			}
+		case models.AssignmentStatusSkipped:
+			skipped++
+		default:
+			failed++
+			if stop {
+				s.events.Record(req.App, models.EventTypeWarning, "FailedStop", a.Node,
+					fmt.Sprintf("Failed to stop version %s: %s", a.Version, a.Error))
+			} else {
+				s.events.Record(req.App, models.EventTypeWarning, "FailedApply", a.Node,
+					fmt.Sprintf("Failed to deploy version %s: %s", a.Version, a.Error))
+			}
+		}
+	}
+	totalCount := len(assignments)
+	message := fmt.Sprintf("Deployed to %d/%d nodes", applied, deploys)
+	if stops > 0 {
+		message += fmt.Sprintf(", stopped on %d/%d nodes", stopped, stops)
+	}
+	if failed > 0 || skipped > 0 {
+		message += fmt.Sprintf(", %d failed, %d skipped", failed, skipped)
+	}
+	eventType := models.EventTypeNormal
+	if applied+stopped < totalCount {
+		eventType = models.EventTypeWarning
 	}
+	s.events.Record(req.App, eventType, "Applied", req.App, message)
 
 	return &models.ApplyDeploymentResponse{
 		App:     req.App,
 		Message: message,
-		Success: success,
-	}, nil
+		Success: applied+stopped == totalCount,
+		Nodes:   assignments,
+	}
+}
+
+// currentPlacement 节点上当前运行的版本：优先使用最近一次 Apply 的分配，
+// 没有记录时（如 Operator 重启后）向各节点 Agent 查询，查询失败的节点视为未运行
+func (s *OperatorPMService) currentPlacement(m *config.Mappings, app string, nodes []string) map[string]string {
+	s.mu.Lock()
+	placement, ok := s.placements[app]
+	s.mu.Unlock()
+	if ok {
+		return placement
+	}
+
+	placement = make(map[string]string)
+	for _, nodeName := range nodes {
+		agentURL, exists := s.agentURL(m, nodeName)
+		if !exists {
+			continue
+		}
+		var status models.AppStatusResponse
+		if err := s.callAgent(http.MethodGet, agentURL+"/status/"+url.PathEscape(app), nil, &status); err == nil && status.Version != "" {
+			placement[nodeName] = status.Version
+		}
+	}
+	return placement
+}
+
+// nodeLoad 每个节点上运行的其他应用数，用于 least_loaded 策略；没有放置记录的应用按其配置的节点计入
+func (s *OperatorPMService) nodeLoad(m *config.Mappings, app string) map[string]int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	load := make(map[string]int)
+	for _, other := range s.appNames(m) {
+		if other == app {
+			continue
+		}
+		if placement, ok := s.placements[other]; ok {
+			for node := range placement {
+				load[node]++
+			}
+			continue
+		}
+		nodes, _ := s.appNodes(m, other)
+		for _, node := range nodes {
+			load[node]++
+		}
+	}
+	return load
 }
 
 // GetApplicationStatus 获取应用状态 - 核心API
+// 向每个节点的 Agent 查询 /status/:app，按节点上实际运行的版本分组；没有运行该应用的节点不计入，
+// 不可达的节点健康度为 0，归入最近一次 Apply 分配给它的版本（没有记录时只计入整体健康度）；
+// 处于维护状态的节点标记 Cordoned，Master 计算覆盖率时不计入
 func (s *OperatorPMService) GetApplicationStatus(appName string) (*models.ApplicationStatusResponse, error) {
 	// 1. 获取应用对应的节点列表
-	nodes, exists := s.cfg.PM.AppToNodes[appName]
-	if !exists || len(nodes) == 0 {
-		return nil, fmt.Errorf("no nodes configured for application %s", appName)
+	m := s.cfg.PM.Mappings()
+	nodes, err := s.appNodes(m, appName)
+	if err != nil {
+		return nil, err
 	}
 
-	// 2. 从所有节点收集状态信息
-	var allNodeStatuses []models.NodeStatus
-	var healthSum int = 0
-	var healthCount int = 0
+	s.mu.Lock()
+	placement := s.placements[appName]
+	s.mu.Unlock()
 
+	// 2. 从所有节点收集状态信息，按版本分组（版本按首次出现的节点顺序排列）
+	var versions []models.VersionStatus
+	versionIndex := make(map[string]int)
+	var healthSum, healthCount, unreachable int
 	for _, nodeName := range nodes {
-		agentURL, exists := s.cfg.GetAgentURL(nodeName)
+		agentURL, exists := s.agentURL(m, nodeName)
 		if !exists {
 			continue
 		}
 
-		// 获取节点状态
-		nodeStatus, err := s.getNodeStatus(agentURL, nodeName)
+		version, nodeStatus, err := s.getNodeStatus(agentURL, nodeName, appName)
+		if errors.Is(err, operatorapi.ErrAppNotFound) {
+			continue
+		}
 		if err != nil {
 			// 节点不可用，健康度为0
-			allNodeStatuses = append(allNodeStatuses, models.NodeStatus{
+			unreachable++
+			version = placement[nodeName]
+			nodeStatus = &models.NodeStatus{
 				Node:    nodeName,
-				Healthy: models.HealthInfo{Level: 0, Msg: "Node unreachable"},
-			})
-			healthSum += 0
-			healthCount++
-		} else {
-			allNodeStatuses = append(allNodeStatuses, *nodeStatus)
-			healthSum += nodeStatus.Healthy.Level
-			healthCount++
+				Healthy: models.HealthInfo{Level: 0, Msg: "Node unreachable: " + err.Error()},
+				Status:  models.InstanceStatusFailed,
+			}
+		}
+		nodeStatus.Cordoned = s.inventory.isCordoned(nodeName)
+		healthSum += nodeStatus.Healthy.Level
+		healthCount++
+		if version == "" {
+			continue
+		}
+
+		i, ok := versionIndex[version]
+		if !ok {
+			i = len(versions)
+			versionIndex[version] = i
+			versions = append(versions, models.VersionStatus{Version: version})
+		}
+		versions[i].Nodes = append(versions[i].Nodes, *nodeStatus)
+	}
+
+	// 3. 计算各版本和整体的平均健康度（所有节点权重相同）
+	for i := range versions {
+		sum := 0
+		for _, node := range versions[i].Nodes {
+			sum += node.Healthy.Level
+		}
+		level := sum / len(versions[i].Nodes)
+		versions[i].Healthy = models.HealthInfo{
+			Level: level,
+			Msg:   fmt.Sprintf("Average health: %d%% (%d nodes)", level, len(versions[i].Nodes)),
 		}
 	}
 
-	// 3. 计算平均健康度（加权平均，这里所有节点权重相同）
-	var overallHealthy int = 0
+	overall := models.HealthInfo{Msg: "Application is not running on any node"}
 	if healthCount > 0 {
-		overallHealthy = healthSum / healthCount
+		overall.Level = healthSum / healthCount
+		overall.Msg = fmt.Sprintf("Average health: %d%% (%d/%d nodes)", overall.Level, healthCount, len(nodes))
+		if unreachable > 0 {
+			overall.Msg += fmt.Sprintf(", %d unreachable", unreachable)
+		}
 	}
 
 	// 4. 构建响应
-	response := &models.ApplicationStatusResponse{
-		App: appName,
-		Healthy: models.HealthInfo{
-			Level: overallHealthy,
-			Msg:   fmt.Sprintf("Average health: %d%% (%d/%d nodes)", overallHealthy, healthCount, len(nodes)),
-		},
-		Versions: []models.VersionStatus{
-			{
-				Version: "latest", // 简化版本，实际应该从Agent获取
-				Healthy: models.HealthInfo{Level: overallHealthy},
-				Nodes:   allNodeStatuses,
-			},
-		},
-	}
-
-	return response, nil
-}
-
-// sendToAgent 发送请求到Agent
-func (s *OperatorPMService) sendToAgent(url string, data interface{}) error {
-	jsonData, err := json.Marshal(data)
+	if versions == nil {
+		versions = []models.VersionStatus{}
+	}
+	return &models.ApplicationStatusResponse{
+		App:      appName,
+		Healthy:  overall,
+		Versions: versions,
+	}, nil
+}
+
+// getNodeStatus 查询节点上应用的状态，返回实际运行的版本；节点上没有该应用时错误满足 errors.Is(err, operatorapi.ErrAppNotFound)
+func (s *OperatorPMService) getNodeStatus(agentURL, nodeName, appName string) (string, *models.NodeStatus, error) {
+	var status models.AppStatusResponse
+	if err := s.callAgent(http.MethodGet, agentURL+"/status/"+url.PathEscape(appName), nil, &status); err != nil {
+		return "", nil, fmt.Errorf("failed to get status from node %s: %w", nodeName, err)
+	}
+	return status.Version, &models.NodeStatus{
+		Node:    nodeName,
+		Healthy: status.Healthy,
+		Status:  instanceStatus(&status),
+	}, nil
+}
+
+// instanceStatus 把 Agent 的应用状态转换为实例状态
+func instanceStatus(status *models.AppStatusResponse) string {
+	switch {
+	case status.Status == "error":
+		return models.InstanceStatusFailed
+	case status.Status == "stopped":
+		return models.InstanceStatusNotReady
+	case status.Healthy.Level >= 100:
+		return models.InstanceStatusReady
+	default:
+		return models.InstanceStatusNotReady
+	}
+}
+
+// Scale 物理机上每个节点运行一个实例，实例数由节点映射决定，不支持调整副本数
+func (s *OperatorPMService) Scale(req *models.ScaleRequest) error {
+	return fmt.Errorf("%w: physical operator instance count is defined by the app-to-nodes mapping", operatorapi.ErrFeatureNotSupported)
+}
+
+// Delete 在应用的所有节点上停止并删除应用，节点上本就没有该应用时视为成功
+func (s *OperatorPMService) Delete(appName string) error {
+	m := s.cfg.PM.Mappings()
+	nodes, err := s.appNodes(m, appName)
 	if err != nil {
-		return fmt.Errorf("failed to marshal request: %w", err)
+		return err
+	}
+
+	var failed []string
+	for _, nodeName := range nodes {
+		agentURL, exists := s.agentURL(m, nodeName)
+		if !exists {
+			continue
+		}
+		err := s.callAgent(http.MethodDelete, agentURL+"/apps/"+url.PathEscape(appName), nil, nil)
+		if err != nil && !errors.Is(err, operatorapi.ErrAppNotFound) {
+			s.events.Record(appName, models.EventTypeWarning, "FailedDelete", nodeName, err.Error())
+			failed = append(failed, nodeName)
+		}
 	}
 
-	resp, err := s.client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to delete application on %d/%d nodes: %s", len(failed), len(nodes), strings.Join(failed, ", "))
+	}
+	s.events.Record(appName, models.EventTypeNormal, "Deleted", appName, fmt.Sprintf("Deleted from %d nodes", len(nodes)))
+	return nil
+}
+
+// Restart 重启应用所在节点上的进程，指定版本时只重启运行该版本的节点
+func (s *OperatorPMService) Restart(req *models.RestartRequest) error {
+	m := s.cfg.PM.Mappings()
+	nodes, err := s.appNodes(m, req.App)
 	if err != nil {
-		return fmt.Errorf("failed to send request to agent: %w", err)
+		return err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("agent returned status %d", resp.StatusCode)
+	var restarted int
+	var failed []string
+	for _, nodeName := range nodes {
+		agentURL, exists := s.agentURL(m, nodeName)
+		if !exists {
+			continue
+		}
+		if req.Version != "" {
+			var status models.AppStatusResponse
+			if err := s.callAgent(http.MethodGet, agentURL+"/status/"+url.PathEscape(req.App), nil, &status); err != nil || status.Version != req.Version {
+				continue
+			}
+		}
+		if err := s.callAgent(http.MethodPost, agentURL+"/restart/"+url.PathEscape(req.App), nil, nil); err != nil {
+			if errors.Is(err, operatorapi.ErrAppNotFound) {
+				continue
+			}
+			s.events.Record(req.App, models.EventTypeWarning, "FailedRestart", nodeName, err.Error())
+			failed = append(failed, nodeName)
+			continue
+		}
+		restarted++
 	}
 
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to restart application on %d nodes: %s", len(failed), strings.Join(failed, ", "))
+	}
+	s.events.Record(req.App, models.EventTypeNormal, "Restarted", req.App, fmt.Sprintf("Restarted on %d nodes", restarted))
 	return nil
 }
 
-// getNodeStatus 获取节点状态
-func (s *OperatorPMService) getNodeStatus(agentURL, nodeName string) (*models.NodeStatus, error) {
-	resp, err := s.client.Get(agentURL + "/status")
+// GetLogs 从各节点 Agent 获取应用日志，节点不可达时记录在该实例的 Error 中
+func (s *OperatorPMService) GetLogs(req *models.LogsRequest) (*models.LogsResponse, error) {
+	m := s.cfg.PM.Mappings()
+	nodes, err := s.appNodes(m, req.App)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get status from node %s: %w", nodeName, err)
+		return nil, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("node %s returned status %d", nodeName, resp.StatusCode)
+	resp := &models.LogsResponse{App: req.App, Instances: []models.InstanceLogs{}}
+	for _, nodeName := range nodes {
+		if req.Node != "" && nodeName != req.Node {
+			continue
+		}
+		agentURL, exists := s.agentURL(m, nodeName)
+		if !exists {
+			continue
+		}
+
+		var logs models.InstanceLogs
+		path := fmt.Sprintf("%s/logs/%s?lines=%d", agentURL, url.PathEscape(req.App), req.Lines)
+		if err := s.callAgent(http.MethodGet, path, nil, &logs); err != nil {
+			if errors.Is(err, operatorapi.ErrAppNotFound) {
+				continue
+			}
+			logs = models.InstanceLogs{Lines: []string{}, Error: err.Error()}
+		}
+		if req.Version != "" && logs.Error == "" && logs.Version != req.Version {
+			continue
+		}
+		logs.Node = nodeName
+		resp.Instances = append(resp.Instances, logs)
 	}
+	return resp, nil
+}
 
-	// 解析响应
-	var status struct {
-		Healthy models.HealthInfo `json:"healthy"`
+// GetEvents 返回本服务记录的应用操作事件
+func (s *OperatorPMService) GetEvents(appName string) (*models.EventsResponse, error) {
+	if _, err := s.appNodes(s.cfg.PM.Mappings(), appName); err != nil {
+		return nil, err
 	}
+	return &models.EventsResponse{App: appName, Events: s.events.List(appName)}, nil
+}
+
+// callAgent 调用 Agent 接口并解析统一响应信封，Agent 返回 404 时错误满足 errors.Is(err, operatorapi.ErrAppNotFound)
+func (s *OperatorPMService) callAgent(method, target string, body, out interface{}) error {
+	return s.callAgentContext(context.Background(), method, target, body, out)
+}
 
-	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
-		return nil, fmt.Errorf("failed to decode status response: %w", err)
+// callAgentContext 同 callAgent，ctx 结束时取消请求
+func (s *OperatorPMService) callAgentContext(ctx context.Context, method, target string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		jsonData, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(jsonData)
 	}
 
-	return &models.NodeStatus{
-		Node:    nodeName,
-		Healthy: status.Healthy,
-	}, nil
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send request to agent: %w", err)
+	}
+	defer resp.Body.Close()
+
+	return operatorapi.DecodeResponse(resp, out)
 }
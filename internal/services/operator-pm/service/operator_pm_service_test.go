@@ -0,0 +1,688 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"github.com/boreas/internal/services/operator-pm/config"
+)
+
+// placementOf 把分配结果转换为 版本 -> 节点列表
+func placementOf(assignments []models.NodeAssignment) map[string][]string {
+	result := make(map[string][]string)
+	for _, a := range assignments {
+		result[a.Version] = append(result[a.Version], a.Node)
+	}
+	return result
+}
+
+func currentOf(assignments []models.NodeAssignment) map[string]string {
+	current := make(map[string]string)
+	for _, a := range assignments {
+		current[a.Node] = a.Version
+	}
+	return current
+}
+
+func TestPlaceVersions(t *testing.T) {
+	nodes := []string{"node-1", "node-2", "node-3", "node-4"}
+	half := []models.VersionDeployment{{Version: "v1", Percent: 0.5}, {Version: "v2", Percent: 0.5}}
+
+	// 两个版本各 50% 时节点互不重叠
+	got, err := placeVersions(placementRequest{nodes: nodes, versions: half})
+	if err != nil {
+		t.Fatalf("placeVersions: %v", err)
+	}
+	want := map[string][]string{"v1": {"node-1", "node-2"}, "v2": {"node-3", "node-4"}}
+	if !reflect.DeepEqual(placementOf(got), want) {
+		t.Fatalf("ordered = %v, want %v", placementOf(got), want)
+	}
+
+	// 调整比例时保留已有的节点，只移动差额
+	got, err = placeVersions(placementRequest{
+		nodes:    nodes,
+		versions: []models.VersionDeployment{{Version: "v1", Percent: 0.25}, {Version: "v2", Percent: 0.75}},
+		current:  map[string]string{"node-1": "v2", "node-2": "v1", "node-3": "v1", "node-4": "v2"},
+	})
+	if err != nil {
+		t.Fatalf("placeVersions: %v", err)
+	}
+	want = map[string][]string{"v1": {"node-2"}, "v2": {"node-1", "node-3", "node-4"}}
+	if !reflect.DeepEqual(placementOf(got), want) {
+		t.Errorf("stable = %v, want %v", placementOf(got), want)
+	}
+
+	// spread：每个版本分散到不同机房，重复 Apply 结果不变
+	spread := placementRequest{
+		nodes:    nodes,
+		versions: half,
+		policy:   config.PlacementConfig{Strategy: config.PlacementSpread},
+		labels: map[string]map[string]string{
+			"node-1": {"zone": "a"}, "node-2": {"zone": "a"}, "node-3": {"zone": "b"}, "node-4": {"zone": "b"},
+		},
+	}
+	got, err = placeVersions(spread)
+	if err != nil {
+		t.Fatalf("placeVersions spread: %v", err)
+	}
+	want = map[string][]string{"v1": {"node-1", "node-3"}, "v2": {"node-2", "node-4"}}
+	if !reflect.DeepEqual(placementOf(got), want) {
+		t.Errorf("spread = %v, want %v", placementOf(got), want)
+	}
+	spread.current = currentOf(got)
+	again, _ := placeVersions(spread)
+	if !reflect.DeepEqual(again, got) {
+		t.Errorf("spread is not stable: %v then %v", got, again)
+	}
+
+	// least_loaded：优先选择其他应用少的节点
+	got, err = placeVersions(placementRequest{
+		nodes:    nodes,
+		versions: []models.VersionDeployment{{Version: "v1", Percent: 0.5}},
+		policy:   config.PlacementConfig{Strategy: config.PlacementLeastLoaded},
+		load:     map[string]int{"node-1": 3, "node-2": 1, "node-3": 0, "node-4": 2},
+	})
+	if err != nil {
+		t.Fatalf("placeVersions least_loaded: %v", err)
+	}
+	if want := map[string][]string{"v1": {"node-2", "node-3"}}; !reflect.DeepEqual(placementOf(got), want) {
+		t.Errorf("least_loaded = %v, want %v", placementOf(got), want)
+	}
+
+	// pinned：只运行在固定节点上；缺少固定节点时报错
+	pinned := config.PlacementConfig{Strategy: config.PlacementPinned, Pinned: map[string][]string{"v1": {"node-4"}, "v2": {"node-1", "node-2"}}}
+	got, err = placeVersions(placementRequest{nodes: nodes, versions: half, policy: pinned})
+	if err != nil {
+		t.Fatalf("placeVersions pinned: %v", err)
+	}
+	if want := map[string][]string{"v1": {"node-4"}, "v2": {"node-1", "node-2"}}; !reflect.DeepEqual(placementOf(got), want) {
+		t.Errorf("pinned = %v, want %v", placementOf(got), want)
+	}
+	_, err = placeVersions(placementRequest{nodes: nodes, versions: []models.VersionDeployment{{Version: "v3", Percent: 1}}, policy: pinned})
+	if !errors.Is(err, operatorapi.ErrPlacement) {
+		t.Errorf("unpinned version error = %v, want ErrPlacement", err)
+	}
+
+	// 灰度版本至少 1 个节点，版本数多于节点数时报错
+	got, _ = placeVersions(placementRequest{nodes: nodes, versions: []models.VersionDeployment{{Version: "v1", Percent: 0.95}, {Version: "v2", Percent: 0.05}}})
+	if p := placementOf(got); len(p["v1"]) != 3 || len(p["v2"]) != 1 {
+		t.Errorf("canary = %v", p)
+	}
+	_, err = placeVersions(placementRequest{nodes: nodes[:1], versions: half})
+	if !errors.Is(err, operatorapi.ErrPlacement) {
+		t.Errorf("too many versions error = %v, want ErrPlacement", err)
+	}
+}
+
+// agentFunc 以函数实现 http.RoundTripper，模拟各节点的 Agent
+type agentFunc func(r *http.Request) (*http.Response, error)
+
+func (f agentFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+func TestGetApplicationStatus_GroupsNodesByVersion(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1", "node-2", "node-3", "node-4"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1", "node-2": "10.0.0.2", "node-3": "10.0.0.3", "node-4": "10.0.0.4"}
+
+	running := map[string]string{"10.0.0.1": "v1", "10.0.0.2": "v2"}
+	agents := agentFunc(func(r *http.Request) (*http.Response, error) {
+		if r.URL.Path != "/v1/status/demo" {
+			t.Errorf("unexpected agent request %s", r.URL.Path)
+		}
+		w := httptest.NewRecorder()
+		switch host := r.URL.Hostname(); {
+		case host == "10.0.0.4":
+			return nil, errors.New("connection refused")
+		case running[host] == "":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":404,"message":"not found"}`))
+		default:
+			_, _ = fmt.Fprintf(w, `{"code":0,"data":{"app":"demo","version":%q,"healthy":{"level":100},"status":"running"}}`, running[host])
+		}
+		return w.Result(), nil
+	})
+	svc := NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents})
+	svc.placements["demo"] = map[string]string{"node-1": "v1", "node-2": "v2", "node-4": "v2"}
+
+	status, err := svc.GetApplicationStatus("demo")
+	if err != nil {
+		t.Fatalf("GetApplicationStatus: %v", err)
+	}
+	got := make(map[string][]string)
+	for _, v := range status.Versions {
+		for _, n := range v.Nodes {
+			got[v.Version] = append(got[v.Version], n.Node)
+		}
+	}
+	// node-3 没有运行应用不计入；不可达的 node-4 归入最近分配给它的 v2
+	want := map[string][]string{"v1": {"node-1"}, "v2": {"node-2", "node-4"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("versions = %v, want %v", got, want)
+	}
+	if status.Versions[1].Healthy.Level != 50 || status.Healthy.Level != 66 {
+		t.Errorf("health = v2 %d, overall %d, want 50 and 66", status.Versions[1].Healthy.Level, status.Healthy.Level)
+	}
+	if s := status.Versions[1].Nodes[1].Status; s != models.InstanceStatusFailed {
+		t.Errorf("unreachable node status = %q, want %q", s, models.InstanceStatusFailed)
+	}
+}
+
+func TestApplyDeployment_FanOutWithRetries(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PM.MaxRetries = 2
+	cfg.PM.Deployment = config.DeploymentConfig{Timeout: 10, MaxConcurrent: 2}
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1", "node-2", "node-3", "node-4", "node-5"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1", "node-2": "10.0.0.2", "node-3": "10.0.0.3", "node-4": "10.0.0.4"}
+
+	var mu sync.Mutex
+	calls := make(map[string]int)
+	running, maxRunning := 0, 0
+	agents := agentFunc(func(r *http.Request) (*http.Response, error) {
+		host := r.URL.Hostname()
+		w := httptest.NewRecorder()
+		if r.Method == http.MethodGet { // 查询当前放置：节点上都没有应用
+			w.WriteHeader(http.StatusNotFound)
+			return w.Result(), nil
+		}
+		mu.Lock()
+		calls[host]++
+		n := calls[host]
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+
+		switch {
+		case host == "10.0.0.2" && n < 3: // 前两次失败，第三次成功
+			w.WriteHeader(http.StatusInternalServerError)
+			_, _ = w.Write([]byte(`{"code":500,"message":"runner busy"}`))
+		case host == "10.0.0.3": // 部署包无效，不重试
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"message":"invalid package"}`))
+		case host == "10.0.0.4":
+			return nil, errors.New("connection refused")
+		default:
+			_, _ = w.Write([]byte(`{"code":0,"data":{"success":true}}`))
+		}
+		return w.Result(), nil
+	})
+	svc := NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents})
+
+	resp, err := svc.ApplyDeployment(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v1", Percent: 1}}})
+	if err != nil {
+		t.Fatalf("ApplyDeployment: %v", err)
+	}
+	if resp.Success {
+		t.Errorf("Success = true with failed nodes, message %q", resp.Message)
+	}
+	type result struct {
+		status   string
+		attempts int
+	}
+	got := make(map[string]result)
+	for _, a := range resp.Nodes {
+		got[a.Node] = result{a.Status, a.Attempts}
+	}
+	want := map[string]result{
+		"node-1": {models.AssignmentStatusApplied, 1},
+		"node-2": {models.AssignmentStatusApplied, 3},
+		"node-3": {models.AssignmentStatusFailed, 1},
+		"node-4": {models.AssignmentStatusFailed, 3},
+		"node-5": {models.AssignmentStatusFailed, 0}, // 没有 IP 映射
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nodes = %+v, want %+v", got, want)
+	}
+	if maxRunning > 2 {
+		t.Errorf("max concurrent requests = %d, want at most 2", maxRunning)
+	}
+}
+
+func TestApplyDeployment_RetriesAgentTimeout(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PM.MaxRetries = 1
+	cfg.PM.Deployment = config.DeploymentConfig{Timeout: 10, MaxConcurrent: 1}
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1"}
+
+	// 第一次部署请求超过 Agent 超时，第二次成功
+	var calls int
+	agents := agentFunc(func(r *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return w.Result(), nil
+		}
+		if calls++; calls == 1 {
+			<-r.Context().Done()
+			return nil, r.Context().Err()
+		}
+		_, _ = w.Write([]byte(`{"code":0}`))
+		return w.Result(), nil
+	})
+	svc := NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents, Timeout: 50 * time.Millisecond})
+
+	resp, err := svc.ApplyDeployment(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v1", Percent: 1}}})
+	if err != nil {
+		t.Fatalf("ApplyDeployment: %v", err)
+	}
+	if !resp.Success || resp.Nodes[0].Attempts != 2 {
+		t.Errorf("nodes = %+v, want node-1 applied on the second attempt", resp.Nodes)
+	}
+}
+
+func TestApplyDeployment_SkipsNodesAfterTimeout(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PM.Deployment = config.DeploymentConfig{Timeout: 1, MaxConcurrent: 1}
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1", "node-2"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1", "node-2": "10.0.0.2"}
+
+	// Agent 的部署接口一直不响应，直到部署超时
+	agents := agentFunc(func(r *http.Request) (*http.Response, error) {
+		if r.Method == http.MethodGet {
+			w := httptest.NewRecorder()
+			w.WriteHeader(http.StatusNotFound)
+			return w.Result(), nil
+		}
+		<-r.Context().Done()
+		return nil, r.Context().Err()
+	})
+	svc := NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents})
+
+	resp, err := svc.ApplyDeployment(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v1", Percent: 1}}})
+	if err != nil {
+		t.Fatalf("ApplyDeployment: %v", err)
+	}
+	if len(resp.Nodes) != 2 || resp.Nodes[0].Status != models.AssignmentStatusFailed || resp.Nodes[1].Status != models.AssignmentStatusSkipped {
+		t.Errorf("nodes = %+v, want node-1 failed and node-2 skipped", resp.Nodes)
+	}
+}
+
+func TestApplyDeployment_StopsReleasedNodes(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PM.Deployment = config.DeploymentConfig{Timeout: 10, MaxConcurrent: 2}
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1", "node-2", "node-3"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1", "node-2": "10.0.0.2", "node-3": "10.0.0.3"}
+
+	var mu sync.Mutex
+	var deleted []string
+	agents := agentFunc(func(r *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		switch {
+		case r.Method == http.MethodGet:
+			w.WriteHeader(http.StatusNotFound)
+		case r.Method == http.MethodDelete && r.URL.Hostname() == "10.0.0.3":
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"code":400,"message":"process is stuck"}`))
+		case r.Method == http.MethodDelete:
+			mu.Lock()
+			deleted = append(deleted, r.URL.Hostname())
+			mu.Unlock()
+			_, _ = w.Write([]byte(`{"code":0}`))
+		default:
+			_, _ = w.Write([]byte(`{"code":0}`))
+		}
+		return w.Result(), nil
+	})
+	svc := NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents})
+
+	if resp, err := svc.ApplyDeployment(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v1", Percent: 1}}}); err != nil || !resp.Success {
+		t.Fatalf("ApplyDeployment = %+v, %v", resp, err)
+	}
+
+	// 缩小到一个节点：node-2、node-3 停止应用，node-3 停止失败
+	resp, err := svc.ApplyDeployment(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v2", Percent: 0.34}}})
+	if err != nil {
+		t.Fatalf("ApplyDeployment: %v", err)
+	}
+	type result struct{ version, action, status string }
+	got := make(map[string]result)
+	for _, a := range resp.Nodes {
+		got[a.Node] = result{a.Version, a.Action, a.Status}
+	}
+	want := map[string]result{
+		"node-1": {"v2", "", models.AssignmentStatusApplied},
+		"node-2": {"v1", models.AssignmentActionStop, models.AssignmentStatusApplied},
+		"node-3": {"v1", models.AssignmentActionStop, models.AssignmentStatusFailed},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nodes = %+v, want %+v", got, want)
+	}
+	if resp.Success {
+		t.Errorf("Success = true with a failed stop, message %q", resp.Message)
+	}
+	if !reflect.DeepEqual(deleted, []string{"10.0.0.2"}) {
+		t.Errorf("deleted on %v, want only 10.0.0.2", deleted)
+	}
+	// 停止失败的节点仍记录为原来运行的版本
+	if placement := svc.placements["demo"]; !reflect.DeepEqual(placement, map[string]string{"node-1": "v2", "node-3": "v1"}) {
+		t.Errorf("placement = %v", placement)
+	}
+}
+
+func TestStartApplyDeployment_RejectsConcurrentApply(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PM.Deployment = config.DeploymentConfig{Timeout: 10, MaxConcurrent: 1}
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1"}
+
+	release := make(chan struct{})
+	agents := agentFunc(func(r *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return w.Result(), nil
+		}
+		<-release
+		_, _ = w.Write([]byte(`{"code":0}`))
+		return w.Result(), nil
+	})
+	svc := NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents})
+	req := &models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v1", Percent: 1}}}
+
+	op, done, err := svc.StartApplyDeployment(req)
+	if err != nil || op.State != models.OperationStateRunning {
+		t.Fatalf("StartApplyDeployment = %+v, %v", op, err)
+	}
+	if _, _, err := svc.StartApplyDeployment(req); !errors.Is(err, operatorapi.ErrOperationInProgress) {
+		t.Fatalf("second apply error = %v, want ErrOperationInProgress", err)
+	}
+
+	close(release)
+	if result := <-done; !result.Success || result.OperationID != op.ID {
+		t.Fatalf("result = %+v", result)
+	}
+	if got, _ := svc.GetOperation(op.ID); got.State != models.OperationStateSucceeded {
+		t.Errorf("operation state = %s, want succeeded", got.State)
+	}
+	if _, _, err := svc.StartApplyDeployment(req); err != nil {
+		t.Errorf("apply after the first finished: %v", err)
+	}
+}
+
+func TestInventory_RegistrationAndAssignments(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PM.Registration.OfflineAfter = 60
+	cfg.PM.ConfigPaths.Assignments = filepath.Join(t.TempDir(), "assignments.json")
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1"}
+	cfg.PM.NodeLabels = map[string]map[string]string{"node-1": {"zone": "a"}}
+	svc := NewOperatorPMService(cfg)
+	now := time.Now()
+	svc.inventory.now = func() time.Time { return now }
+
+	_, _ = svc.RegisterAgent(&models.AgentRegistration{ID: "node-2", IP: "10.0.1.2", Port: 9000, Labels: map[string]string{"zone": "a"}})
+	_, _ = svc.RegisterAgent(&models.AgentRegistration{ID: "node-3", IP: "10.0.1.3", Labels: map[string]string{"zone": "b"}})
+	if url, _ := svc.agentURL(cfg.PM.Mappings(), "node-2"); url != "http://10.0.1.2:9000/v1" {
+		t.Errorf("agentURL(node-2) = %s", url)
+	}
+
+	// node-to-ip.yaml 中的节点不能被注册覆盖，除非配置允许
+	hijack := &models.AgentRegistration{ID: "node-1", IP: "10.9.9.9", Labels: map[string]string{"zone": "b"}}
+	if _, err := svc.RegisterAgent(hijack); !errors.Is(err, operatorapi.ErrNodeConflict) {
+		t.Errorf("registering a static node error = %v, want ErrNodeConflict", err)
+	}
+	if _, err := svc.Heartbeat(hijack); !errors.Is(err, operatorapi.ErrNodeConflict) {
+		t.Errorf("heartbeat of a static node error = %v, want ErrNodeConflict", err)
+	}
+	if url, _ := svc.agentURL(cfg.PM.Mappings(), "node-1"); url != "http://10.0.0.1:8081/v1" {
+		t.Errorf("agentURL(node-1) = %s, want the configured IP", url)
+	}
+	cfg.PM.Registration.OverrideStatic = true
+	if _, err := svc.RegisterAgent(hijack); err != nil {
+		t.Errorf("registering a static node with override_static: %v", err)
+	}
+	if url, _ := svc.agentURL(cfg.PM.Mappings(), "node-1"); url != "http://10.9.9.9:8081/v1" {
+		t.Errorf("agentURL(node-1) with override_static = %s", url)
+	}
+	// 关闭覆盖后已记录的注册信息不再生效
+	cfg.PM.Registration.OverrideStatic = false
+	if url, _ := svc.agentURL(cfg.PM.Mappings(), "node-1"); url != "http://10.0.0.1:8081/v1" {
+		t.Errorf("agentURL(node-1) after disabling override_static = %s", url)
+	}
+
+	// 标签选择在线节点和静态节点，加上指定的节点
+	if err := svc.SetAssignment(&models.AppAssignment{App: "web", Nodes: []string{"node-3"}, Selector: map[string]string{"zone": "a"}}); err != nil {
+		t.Fatalf("SetAssignment: %v", err)
+	}
+	if nodes, _ := svc.appNodes(cfg.PM.Mappings(), "web"); !reflect.DeepEqual(nodes, []string{"node-3", "node-1", "node-2"}) {
+		t.Errorf("appNodes(web) = %v", nodes)
+	}
+	if err := svc.SetAssignment(&models.AppAssignment{App: "web", Nodes: []string{"node-9"}}); !errors.Is(err, operatorapi.ErrPlacement) {
+		t.Errorf("assigning unknown node error = %v, want ErrPlacement", err)
+	}
+
+	// 超过 offline_after 没有心跳的节点离线，不再被选择；心跳后重新上线
+	now = now.Add(2 * time.Minute)
+	_, _ = svc.Heartbeat(&models.AgentRegistration{ID: "node-3", IP: "10.0.1.3", Labels: map[string]string{"zone": "b"}})
+	status := make(map[string]string)
+	for _, node := range svc.ListNodes() {
+		status[node.ID] = node.Status
+	}
+	want := map[string]string{"node-1": models.AgentNodeStatic, "node-2": models.AgentNodeOffline, "node-3": models.AgentNodeOnline}
+	if !reflect.DeepEqual(status, want) {
+		t.Errorf("node status = %v, want %v", status, want)
+	}
+	if nodes, _ := svc.appNodes(cfg.PM.Mappings(), "web"); !reflect.DeepEqual(nodes, []string{"node-3", "node-1"}) {
+		t.Errorf("appNodes(web) after node-2 went offline = %v", nodes)
+	}
+
+	// 设置保存到文件，重启后恢复
+	restarted := NewOperatorPMService(cfg)
+	if err := restarted.RestoreAssignments(); err != nil {
+		t.Fatalf("RestoreAssignments: %v", err)
+	}
+	if got := restarted.ListAssignments(); len(got) != 1 || got[0].App != "web" {
+		t.Errorf("restored assignments = %+v", got)
+	}
+	if err := restarted.DeleteAssignment("web"); err != nil {
+		t.Fatalf("DeleteAssignment: %v", err)
+	}
+	if _, err := restarted.appNodes(cfg.PM.Mappings(), "web"); !errors.Is(err, operatorapi.ErrAppNotFound) {
+		t.Errorf("appNodes after delete error = %v, want ErrAppNotFound", err)
+	}
+}
+
+func TestReloadMappings(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("app-to-nodes.yaml", "app_to_nodes:\n  demo: [node-1]\n")
+	write("node-to-ip.yaml", "node_to_ip:\n  node-1: 10.0.0.1\n  node-2: 10.0.0.2\n")
+
+	cfg := &config.Config{}
+	cfg.PM.Deployment = config.DeploymentConfig{Timeout: 10, MaxConcurrent: 1}
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.ConfigPaths = config.ConfigPathsConfig{
+		AppToNodes: filepath.Join(dir, "app-to-nodes.yaml"),
+		NodeToIP:   filepath.Join(dir, "node-to-ip.yaml"),
+	}
+
+	// Apply 进行中时热加载映射，本次 Apply 仍使用开始时的节点和 IP
+	release := make(chan struct{})
+	var mu sync.Mutex
+	var applied []string
+	agents := agentFunc(func(r *http.Request) (*http.Response, error) {
+		w := httptest.NewRecorder()
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusNotFound)
+			return w.Result(), nil
+		}
+		<-release
+		mu.Lock()
+		applied = append(applied, r.URL.Hostname())
+		mu.Unlock()
+		_, _ = w.Write([]byte(`{"code":0}`))
+		return w.Result(), nil
+	})
+	svc := NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents})
+	if err := svc.ReloadMappings(); err != nil {
+		t.Fatalf("initial ReloadMappings: %v", err)
+	}
+	_, done, err := svc.StartApplyDeployment(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{{Version: "v1", Percent: 1}}})
+	if err != nil {
+		t.Fatalf("StartApplyDeployment: %v", err)
+	}
+
+	write("app-to-nodes.yaml", "app_to_nodes:\n  demo: [node-2]\n")
+	write("node-to-ip.yaml", "node_to_ip:\n  node-1: 10.0.0.11\n  node-2: 10.0.0.2\n")
+	if err := svc.ReloadMappings(); err != nil {
+		t.Fatalf("ReloadMappings: %v", err)
+	}
+	close(release)
+	if result := <-done; !result.Success || !reflect.DeepEqual(applied, []string{"10.0.0.1"}) {
+		t.Errorf("in-flight apply = %+v to %v, want node-1 at its previous ip", result, applied)
+	}
+	if nodes, _ := svc.appNodes(cfg.PM.Mappings(), "demo"); !reflect.DeepEqual(nodes, []string{"node-2"}) {
+		t.Errorf("appNodes after reload = %v", nodes)
+	}
+
+	// 引用不存在的节点或 IP 重复时保留原映射
+	previous := cfg.PM.Mappings()
+	write("app-to-nodes.yaml", "app_to_nodes:\n  demo: [node-3]\n")
+	if err := svc.ReloadMappings(); err == nil || cfg.PM.Mappings() != previous {
+		t.Errorf("dangling node reference: err = %v, mappings replaced = %v", err, cfg.PM.Mappings() != previous)
+	}
+	write("app-to-nodes.yaml", "app_to_nodes:\n  demo: [node-2]\n")
+	write("node-to-ip.yaml", "node_to_ip:\n  node-1: 10.0.0.2\n  node-2: 10.0.0.2\n")
+	if err := svc.ReloadMappings(); err == nil || cfg.PM.Mappings() != previous {
+		t.Errorf("duplicate ip: err = %v, mappings replaced = %v", err, cfg.PM.Mappings() != previous)
+	}
+
+	// 监听到文件变化后自动重新加载
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := svc.WatchMappings(ctx); err != nil {
+		t.Fatalf("WatchMappings: %v", err)
+	}
+	write("node-to-ip.yaml", "node_to_ip:\n  node-1: 10.0.0.1\n  node-2: 10.0.0.3\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for cfg.PM.Mappings().NodeToIP["node-2"] != "10.0.0.3" {
+		if time.Now().After(deadline) {
+			t.Fatal("mappings were not reloaded after the file changed")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+func TestCordonAndDrain(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.PM.Deployment = config.DeploymentConfig{Timeout: 10, MaxConcurrent: 2}
+	cfg.PM.Agent = config.AgentConfig{Port: 8081, Path: "/v1"}
+	cfg.PM.AppToNodes = map[string][]string{"demo": {"node-1", "node-2", "node-3"}}
+	cfg.PM.NodeToIP = map[string]string{"node-1": "10.0.0.1", "node-2": "10.0.0.2", "node-3": "10.0.0.3"}
+
+	// 按节点 IP 记录运行的应用版本
+	var mu sync.Mutex
+	running := make(map[string]map[string]string)
+	agents := agentFunc(func(r *http.Request) (*http.Response, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		host := r.URL.Hostname()
+		if running[host] == nil {
+			running[host] = make(map[string]string)
+		}
+		apps := running[host]
+		w := httptest.NewRecorder()
+		path := strings.TrimPrefix(r.URL.Path, "/v1")
+		app := path[strings.LastIndex(path, "/")+1:]
+		var data interface{}
+		switch {
+		case r.Method == http.MethodPost && path == "/apply":
+			var req models.ApplyRequest
+			_ = json.NewDecoder(r.Body).Decode(&req)
+			apps[req.App] = req.Version
+		case path == "/status":
+			status := models.StatusResponse{}
+			for app, version := range apps {
+				status.Apps = append(status.Apps, models.AgentAppStatus{App: app, Version: version})
+			}
+			data = status
+		case apps[app] == "":
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"code":404,"message":"app not found"}`))
+			return w.Result(), nil
+		case r.Method == http.MethodDelete:
+			delete(apps, app)
+		default:
+			data = models.AppStatusResponse{App: app, Version: apps[app], Healthy: models.HealthInfo{Level: 100}}
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"code": 0, "data": data})
+		return w.Result(), nil
+	})
+	svc := NewOperatorPMService(cfg).WithHTTPClient(&http.Client{Transport: agents})
+
+	// 维护中的节点不参与放置
+	if _, err := svc.Cordon("node-3"); err != nil {
+		t.Fatalf("Cordon: %v", err)
+	}
+	if _, err := svc.Cordon("node-9"); !errors.Is(err, operatorapi.ErrNodeNotFound) {
+		t.Errorf("Cordon unknown node error = %v, want ErrNodeNotFound", err)
+	}
+	resp, err := svc.ApplyDeployment(&models.ApplyDeploymentRequest{App: "demo", Versions: []models.VersionDeployment{
+		{Version: "v1", Percent: 0.5, Package: models.DeploymentPackage{Type: "docker", Image: "demo:v1"}},
+	}})
+	if err != nil || !resp.Success || !reflect.DeepEqual(placementOf(resp.Nodes), map[string][]string{"v1": {"node-1"}}) {
+		t.Fatalf("ApplyDeployment = %+v, %v, want v1 on node-1 only", resp, err)
+	}
+	if _, err := svc.Uncordon("node-3"); err != nil {
+		t.Fatalf("Uncordon: %v", err)
+	}
+
+	// drain 把应用迁移到其他节点后停止，状态中节点标记为维护中
+	drained, err := svc.Drain("node-1")
+	if err != nil {
+		t.Fatalf("Drain: %v", err)
+	}
+	want := []models.DrainedApp{{App: "demo", Version: "v1", Target: "node-2", Stopped: true}}
+	if !reflect.DeepEqual(drained.Apps, want) || !drained.Cordoned {
+		t.Errorf("Drain = %+v, want %+v", drained, want)
+	}
+	if running["10.0.0.1"]["demo"] != "" || running["10.0.0.2"]["demo"] != "v1" {
+		t.Errorf("running after drain = %v", running)
+	}
+
+	mu.Lock()
+	running["10.0.0.1"]["demo"] = "v1" // 维护期间节点上仍有实例
+	mu.Unlock()
+	status, err := svc.GetApplicationStatus("demo")
+	if err != nil {
+		t.Fatalf("GetApplicationStatus: %v", err)
+	}
+	cordoned := make(map[string]bool)
+	for _, node := range status.Versions[0].Nodes {
+		cordoned[node.Node] = node.Cordoned
+	}
+	if !reflect.DeepEqual(cordoned, map[string]bool{"node-1": true, "node-2": false}) {
+		t.Errorf("cordoned in status = %v", cordoned)
+	}
+}
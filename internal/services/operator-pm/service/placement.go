@@ -0,0 +1,260 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/boreas/internal/pkg/models"
+	"github.com/boreas/internal/pkg/operatorapi"
+	"github.com/boreas/internal/services/operator-pm/config"
+)
+
+// placementRequest 一次放置计算的输入
+type placementRequest struct {
+	nodes    []string                     // 应用可用的节点，按 app_to_nodes 中的顺序
+	versions []models.VersionDeployment   // 请求的版本
+	current  map[string]string            // 节点 -> 当前运行的版本
+	policy   config.PlacementConfig       // 应用的放置策略
+	labels   map[string]map[string]string // 节点标签
+	load     map[string]int               // 节点 -> 运行的其他应用数
+}
+
+// placeVersions 把节点分配给各版本：每个节点最多运行一个版本，节点数按 Percent 分配（pinned 策略为固定节点数）。
+// 依次分配固定节点、保留节点上已运行的版本（保证多次 Apply 结果稳定），再按策略从空闲节点中补足。
+// 返回按节点顺序排列的分配结果，未分配的节点不在结果中
+func placeVersions(p placementRequest) ([]models.NodeAssignment, error) {
+	index := make(map[string]int, len(p.nodes))
+	for i, node := range p.nodes {
+		index[node] = i
+	}
+
+	active := make([]models.VersionDeployment, 0, len(p.versions))
+	for _, v := range p.versions {
+		if v.Percent > 0 {
+			active = append(active, v)
+		}
+	}
+
+	pinnedTo := make(map[string]string)
+	for _, v := range active {
+		for _, node := range p.policy.Pinned[v.Version] {
+			if _, ok := index[node]; !ok {
+				return nil, fmt.Errorf("%w: node %s pinned to %s is not configured for the app", operatorapi.ErrPlacement, node, v.Version)
+			}
+			if other, ok := pinnedTo[node]; ok && other != v.Version {
+				return nil, fmt.Errorf("%w: node %s is pinned to both %s and %s", operatorapi.ErrPlacement, node, other, v.Version)
+			}
+			pinnedTo[node] = v.Version
+		}
+	}
+
+	counts, err := placementCounts(p, active)
+	if err != nil {
+		return nil, err
+	}
+
+	assigned := make(map[string]string, len(p.nodes)) // 节点 -> 版本
+	perVersion := make(map[string][]string)           // 版本 -> 节点
+	assign := func(node, version string) {
+		assigned[node] = version
+		perVersion[version] = append(perVersion[version], node)
+	}
+
+	for _, node := range p.nodes {
+		if version, ok := pinnedTo[node]; ok {
+			assign(node, version)
+		}
+	}
+
+	// 保留已运行该版本的节点，超出节点数时按策略优先保留
+	for _, v := range active {
+		var candidates []string
+		for _, node := range p.nodes {
+			if p.current[node] == v.Version && assigned[node] == "" {
+				candidates = append(candidates, node)
+			}
+		}
+		for len(perVersion[v.Version]) < counts[v.Version] && len(candidates) > 0 {
+			node := p.pick(v.Version, candidates, perVersion, index)
+			assign(node, v.Version)
+			candidates = removeNode(candidates, node)
+		}
+	}
+
+	for _, v := range active {
+		for len(perVersion[v.Version]) < counts[v.Version] {
+			var free []string
+			for _, node := range p.nodes {
+				if assigned[node] == "" {
+					free = append(free, node)
+				}
+			}
+			if len(free) == 0 {
+				return nil, fmt.Errorf("%w: not enough nodes for %s", operatorapi.ErrPlacement, v.Version)
+			}
+			assign(p.pick(v.Version, free, perVersion, index), v.Version)
+		}
+	}
+
+	result := make([]models.NodeAssignment, 0, len(assigned))
+	for _, node := range p.nodes {
+		if version := assigned[node]; version != "" {
+			result = append(result, models.NodeAssignment{Node: node, Version: version})
+		}
+	}
+	return result, nil
+}
+
+// releasedNodes 当前运行应用、本次没有分配版本的节点，返回按节点名排序的 stop 分配；keep 返回 true 的节点保持不变
+func releasedNodes(current map[string]string, assignments []models.NodeAssignment, keep func(node string) bool) []models.NodeAssignment {
+	assigned := make(map[string]bool, len(assignments))
+	for _, a := range assignments {
+		assigned[a.Node] = true
+	}
+	var result []models.NodeAssignment
+	for node, version := range current {
+		if !assigned[node] && !keep(node) {
+			result = append(result, models.NodeAssignment{Node: node, Version: version, Action: models.AssignmentActionStop})
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].Node < result[j].Node })
+	return result
+}
+
+// placementCounts 各版本的节点数：pinned 策略为固定节点数；其他策略按 Percent 用最大余数法分配，
+// 每个 Percent > 0 的版本至少 1 个节点，且不少于固定给它的节点数
+func placementCounts(p placementRequest, active []models.VersionDeployment) (map[string]int, error) {
+	total := len(p.nodes)
+	counts := make(map[string]int, len(active))
+	if p.policy.Strategy == config.PlacementPinned {
+		for _, v := range active {
+			if len(p.policy.Pinned[v.Version]) == 0 {
+				return nil, fmt.Errorf("%w: no nodes pinned for %s", operatorapi.ErrPlacement, v.Version)
+			}
+			counts[v.Version] = len(p.policy.Pinned[v.Version])
+		}
+		return counts, nil
+	}
+
+	if len(active) > total {
+		return nil, fmt.Errorf("%w: %d versions but only %d nodes", operatorapi.ErrPlacement, len(active), total)
+	}
+
+	type share struct {
+		version   string
+		count     int
+		remainder float64
+	}
+	shares := make([]share, len(active))
+	var percentSum float64
+	sum := 0
+	for i, v := range active {
+		exact := v.Percent * float64(total)
+		floor := int(math.Floor(exact + 1e-9))
+		shares[i] = share{version: v.Version, count: floor, remainder: exact - float64(floor)}
+		percentSum += v.Percent
+		sum += floor
+	}
+	target := int(math.Round(percentSum * float64(total)))
+	if target > total {
+		target = total
+	}
+	order := make([]int, len(shares))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(i, j int) bool { return shares[order[i]].remainder > shares[order[j]].remainder })
+	for _, i := range order {
+		if sum >= target {
+			break
+		}
+		if shares[i].remainder > 1e-9 {
+			shares[i].count++
+			sum++
+		}
+	}
+
+	for i := range shares {
+		min := len(p.policy.Pinned[shares[i].version])
+		if min == 0 {
+			min = 1
+		}
+		if shares[i].count < min {
+			sum += min - shares[i].count
+			shares[i].count = min
+		}
+	}
+	// 保底和固定节点使总数超出时，从节点最多的版本扣除
+	for sum > total {
+		largest := -1
+		for i := range shares {
+			floor := len(p.policy.Pinned[shares[i].version])
+			if floor == 0 {
+				floor = 1
+			}
+			if shares[i].count > floor && (largest < 0 || shares[i].count > shares[largest].count) {
+				largest = i
+			}
+		}
+		if largest < 0 {
+			return nil, fmt.Errorf("%w: pinned nodes exceed the %d available nodes", operatorapi.ErrPlacement, total)
+		}
+		shares[largest].count--
+		sum--
+	}
+
+	for _, sh := range shares {
+		counts[sh.version] = sh.count
+	}
+	return counts, nil
+}
+
+// pick 按策略从候选节点中为版本选择一个节点
+func (p placementRequest) pick(version string, candidates []string, perVersion map[string][]string, index map[string]int) string {
+	label := p.policy.Label
+	if label == "" {
+		label = config.DefaultSpreadLabel
+	}
+	spread := make(map[string]int)
+	if p.policy.Strategy == config.PlacementSpread {
+		for _, node := range perVersion[version] {
+			spread[p.labels[node][label]]++
+		}
+	}
+
+	best := candidates[0]
+	for _, node := range candidates[1:] {
+		if p.better(node, best, spread, label, index) {
+			best = node
+		}
+	}
+	return best
+}
+
+// better a 是否比 b 更适合：spread 优先选择该版本节点最少的标签取值，least_loaded 优先选择负载最低的节点，
+// 最后按配置顺序
+func (p placementRequest) better(a, b string, spread map[string]int, label string, index map[string]int) bool {
+	switch p.policy.Strategy {
+	case config.PlacementSpread:
+		sa, sb := spread[p.labels[a][label]], spread[p.labels[b][label]]
+		if sa != sb {
+			return sa < sb
+		}
+	case config.PlacementLeastLoaded:
+		if p.load[a] != p.load[b] {
+			return p.load[a] < p.load[b]
+		}
+	}
+	return index[a] < index[b]
+}
+
+func removeNode(nodes []string, node string) []string {
+	result := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if n != node {
+			result = append(result, n)
+		}
+	}
+	return result
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/boreas/internal/pkg/logger"
+	"github.com/boreas/internal/services/operator-pm/config"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// reloadDebounce 映射文件变化后等待的时间，编辑器保存和 ConfigMap 更新通常会连续触发多个事件
+const reloadDebounce = 500 * time.Millisecond
+
+// ReloadMappings 重新读取 app-to-nodes.yaml 和 node-to-ip.yaml，校验通过后整体替换生效的映射并记录变化；
+// 文件无法读取或校验失败时保留原映射并返回错误。正在进行的操作继续使用开始时的快照
+func (s *OperatorPMService) ReloadMappings() error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+	m, err := config.LoadMappings(s.cfg.PM.ConfigPaths)
+	if err != nil {
+		return err
+	}
+	registered := func(node string) bool {
+		_, ok := s.inventory.node(node)
+		return ok
+	}
+	if err := m.Validate(registered); err != nil {
+		return err
+	}
+	for _, a := range s.inventory.listAssignments() {
+		for _, node := range a.Nodes {
+			if _, ok := m.NodeToIP[node]; !ok && !registered(node) {
+				return fmt.Errorf("invalid mappings: assignment of app %s references node %s removed from node-to-ip", a.App, node)
+			}
+		}
+	}
+
+	changes := m.Diff(s.cfg.PM.Mappings())
+	if len(changes) == 0 {
+		return nil
+	}
+	s.cfg.PM.SetMappings(m)
+	logger.GetLogger().Info("Reloaded node mappings", zap.Strings("changes", changes))
+	return nil
+}
+
+// WatchMappings 监听映射文件所在目录，文件变化时调用 ReloadMappings，ctx 结束时停止
+// 监听目录而不是文件本身，以便处理编辑器的重命名保存和 Kubernetes ConfigMap 的符号链接替换
+func (s *OperatorPMService) WatchMappings(ctx context.Context) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create mapping watcher: %w", err)
+	}
+	dirs := map[string]bool{
+		filepath.Dir(s.cfg.PM.ConfigPaths.AppToNodesPath()): true,
+		filepath.Dir(s.cfg.PM.ConfigPaths.NodeToIPPath()):   true,
+	}
+	for dir := range dirs {
+		if err := watcher.Add(dir); err != nil {
+			watcher.Close()
+			return fmt.Errorf("failed to watch %s: %w", dir, err)
+		}
+	}
+
+	go func() {
+		defer watcher.Close()
+		timer := time.NewTimer(reloadDebounce)
+		timer.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if event.Op != fsnotify.Chmod {
+					timer.Reset(reloadDebounce)
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				logger.GetLogger().Warn("Mapping watcher error", zap.Error(err))
+			case <-timer.C:
+				if err := s.ReloadMappings(); err != nil {
+					logger.GetLogger().Error("Failed to reload node mappings, keeping the previous mappings", zap.Error(err))
+				}
+			}
+		}
+	}()
+	return nil
+}